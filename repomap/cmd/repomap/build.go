@@ -0,0 +1,328 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spanexx/agents-cli/repomap/internal/cache"
+	"github.com/spanexx/agents-cli/repomap/internal/discovery"
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/history"
+	"github.com/spanexx/agents-cli/repomap/internal/modules"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+	"github.com/spanexx/agents-cli/repomap/internal/parsing"
+	"github.com/spanexx/agents-cli/repomap/internal/ranking"
+	"github.com/spanexx/agents-cli/repomap/internal/source"
+	"github.com/spanexx/agents-cli/repomap/pkg/cli"
+	"github.com/spanexx/agents-cli/repomap/pkg/util"
+)
+
+// buildGraph parses the files of the walked tree under absRoot, reusing
+// the results cached for unchanged files, builds their import graph and
+// ranks them. Modules and JavaScript configs come from the project files
+// of the walk. File nodes are returned sorted by rank.
+func buildGraph(absRoot string, tree *discovery.Tree, includeVendor bool, rc rankConfig, flags *cli.Flags, c *cache.Cache, logger util.Logger) (*graph.Graph, []*output.FileNode) {
+	// Apply CLI filters
+	filteredFiles := filterFiles(tree.Files, flags)
+	logger.Debug("Found %d files", len(filteredFiles))
+
+	// 3. Parsing (Definitions & Imports)
+	logger.Debug("Phase B: Parsing %d files...", len(filteredFiles))
+
+	workspace := modules.FromFiles(absRoot, tree.ProjectFiles)
+	if len(workspace.Modules) == 0 {
+		workspace = modules.Single(modules.RootModule(absRoot))
+	}
+
+	graphBuilder := graph.NewBuilder()
+	graphBuilder.SetJSResolver(graph.NewJSResolver(absRoot, tree.ProjectFiles))
+	fileNodes := make([]*output.FileNode, len(filteredFiles))
+
+	// Files are parsed in parallel; each worker fills its own nodes.
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				path := filteredFiles[i]
+				p := parseFile(path, c, logger)
+
+				// Add to Graph Builder (relative to root)
+				relPath, _ := filepath.Rel(absRoot, path)
+				relPath = filepath.ToSlash(relPath)
+
+				graphBuilder.AddFile(relPath, p.Imports)
+
+				ext := strings.ToLower(filepath.Ext(path))
+				lang := strings.TrimPrefix(ext, ".")
+				if lang == "" {
+					lang = "unknown"
+				}
+
+				moduleName := ""
+				if mod := workspace.ModuleFor(relPath); mod != nil {
+					moduleName = mod.Path
+				}
+
+				fileNodes[i] = &output.FileNode{
+					Path:        relPath,
+					Language:    lang,
+					Definitions: p.Definitions,
+					Imports:     p.Imports,
+					TokenCount:  0,
+					External:    includeVendor && discovery.IsVendored(relPath),
+					Module:      moduleName,
+				}
+			}
+		}()
+	}
+	for i := range filteredFiles {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	hits, misses := c.Counts()
+	logger.Debug("Parsed %d files and reused %d from the cache", misses, hits)
+
+	// 4. Graph Construction
+	logger.Debug("Phase C: Building import graph...")
+	importGraph := graphBuilder.BuildWorkspace(workspace)
+	unresolvedCount := 0
+	for _, node := range fileNodes {
+		node.UnresolvedImports = importGraph.Unresolved[node.Path]
+		unresolvedCount += len(node.UnresolvedImports)
+	}
+	logger.Debug("Resolved import graph (%d unresolved imports)", unresolvedCount)
+
+	if rc.history {
+		logger.Debug("Reading change history...")
+		if err := addHistory(absRoot, fileNodes, rc.historyCommits); err != nil {
+			logger.Warn("Failed to read git history: %v", err)
+		}
+	}
+
+	// 5. Ranking
+	logger.Debug("Phase D: Ranking files...")
+	rankFiles(importGraph, fileNodes, rc)
+
+	return importGraph, fileNodes
+}
+
+// parsed holds the definitions and imports extracted from a file.
+type parsed struct {
+	Definitions []string `json:"definitions"`
+	Imports     []string `json:"imports"`
+}
+
+// parseFile extracts the definitions and imports of the file at path, or
+// takes them from the cache if its content was parsed before. Results of
+// files that failed to parse are not cached, so the failure is reported
+// again on the next run.
+func parseFile(path string, c *cache.Cache, logger util.Logger) parsed {
+	var p parsed
+	extractor := parsing.DefaultRegistry.Get(path)
+	if extractor == nil {
+		return p
+	}
+	key := parseKey(path)
+	if key != "" && c.Get("parse", key, &p) {
+		return p
+	}
+
+	var defErr, impErr error
+	p.Definitions, defErr = extractor.ExtractDefinitions(path)
+	if defErr != nil {
+		logger.Warn("Failed to parse definitions for %s: %v", path, defErr)
+	}
+	p.Imports, impErr = extractor.ExtractImports(path)
+	if impErr != nil {
+		logger.Warn("Failed to parse imports for %s: %v", path, impErr)
+	}
+	if key != "" && defErr == nil && impErr == nil {
+		if err := c.Put("parse", key, p); err != nil {
+			logger.Debug("Failed to cache %s: %v", path, err)
+		}
+	}
+	return p
+}
+
+// parseKey returns the cache key of what the extractors derive from the
+// file at path: its content, and its extension, which selects the
+// extractor. It returns "" if the file cannot be read.
+func parseKey(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return cache.Key(parsing.Version, []byte(strings.ToLower(filepath.Ext(path))), content)
+}
+
+// addOutlines extracts the outline of each file whose extractor can locate
+// its definitions, for the tree format, reusing cached outlines.
+func addOutlines(absRoot string, fileNodes []*output.FileNode, c *cache.Cache, logger util.Logger) {
+	for _, node := range fileNodes {
+		outliner, ok := parsing.DefaultRegistry.Get(node.Path).(parsing.Outliner)
+		if !ok {
+			continue
+		}
+		path := filepath.Join(absRoot, filepath.FromSlash(node.Path))
+		key := parseKey(path)
+		var lines []parsing.Line
+		if key == "" || !c.Get("outline", key, &lines) {
+			var err error
+			lines, err = outliner.ExtractOutline(path)
+			if err != nil {
+				logger.Warn("Failed to outline %s: %v", node.Path, err)
+				continue
+			}
+			if key != "" {
+				if err := c.Put("outline", key, lines); err != nil {
+					logger.Debug("Failed to cache the outline of %s: %v", node.Path, err)
+				}
+			}
+		}
+		for _, l := range lines {
+			node.Outline = append(node.Outline, output.Line{Number: l.Number, Text: l.Text})
+		}
+	}
+}
+
+// addSources reads the source of the first n ranked files for the pack
+// format, prepared as opts asks. Files that cannot be read are kept with
+// the reason, so the pack can report them as omitted.
+func addSources(absRoot string, fileNodes []*output.FileNode, n int, opts source.Options, logger util.Logger) {
+	for _, node := range fileNodes[:min(n, len(fileNodes))] {
+		f, err := source.Read(filepath.Join(absRoot, filepath.FromSlash(node.Path)), node.Language, opts)
+		if err != nil {
+			logger.Debug("Leaving the source of %s out of the pack: %v", node.Path, err)
+			node.Source = &output.Source{Skipped: err.Error()}
+			continue
+		}
+		node.Source = &output.Source{Text: f.Text, Stripped: f.Stripped, Redacted: f.Redacted, Elided: f.Elided}
+	}
+}
+
+// graphEdges returns the edges of the import graph, ordered by source and
+// destination.
+func graphEdges(g *graph.Graph) []*output.Edge {
+	froms := make([]string, 0, len(g.Edges))
+	for from := range g.Edges {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	// Sorting each file's imports is much cheaper than sorting every edge.
+	var edges []*output.Edge
+	for _, from := range froms {
+		start := len(edges)
+		for _, to := range g.Edges[from] {
+			edges = append(edges, &output.Edge{From: from, To: to, Weight: g.Weight(from, to)})
+		}
+		imports := edges[start:]
+		sort.Slice(imports, func(i, j int) bool { return imports[i].To < imports[j].To })
+	}
+	return edges
+}
+
+// rankFiles scores the file nodes on the import graph, assigns their
+// importance and sorts them by rank.
+func rankFiles(g *graph.Graph, fileNodes []*output.FileNode, rc rankConfig) {
+	ranks, importance := rc.rank(g, fileSignals(fileNodes, time.Now()))
+
+	// Enrich file nodes
+	for _, node := range fileNodes {
+		node.Rank = ranks[node.Path]
+		if imp, ok := importance[node.Path]; ok {
+			node.Importance = imp
+		} else {
+			node.Importance = "low"
+		}
+		// Vendored code is context, not the subject of the map.
+		if node.External {
+			node.Rank *= externalRankWeight
+			node.Importance = "low"
+		}
+	}
+
+	// Sort by Rank, then path, so ties always come out in the same order
+	sort.Slice(fileNodes, func(i, j int) bool {
+		if fileNodes[i].Rank != fileNodes[j].Rank {
+			return fileNodes[i].Rank > fileNodes[j].Rank
+		}
+		return fileNodes[i].Path < fileNodes[j].Path
+	})
+}
+
+// fileSignals collects the per-file ranking signals of the file nodes.
+// Recency is measured relative to now.
+func fileSignals(fileNodes []*output.FileNode, now time.Time) ranking.Signals {
+	signals := ranking.Signals{
+		Definitions: make(map[string]int, len(fileNodes)),
+		Churn:       make(map[string]int, len(fileNodes)),
+		Recency:     make(map[string]float64, len(fileNodes)),
+		Authors:     make(map[string]int, len(fileNodes)),
+	}
+	for _, node := range fileNodes {
+		signals.Definitions[node.Path] = len(node.Definitions)
+		signals.Churn[node.Path] = node.Churn
+		signals.Authors[node.Path] = node.Authors
+		if t, err := time.Parse(time.RFC3339, node.LastModified); err == nil {
+			signals.Recency[node.Path] = history.Stats{LastModified: t}.Recency(now)
+		}
+	}
+	return signals
+}
+
+// groupSignals aggregates file signals per group: definitions and churn
+// add up, while recency and authors take the value of the most active file.
+func groupSignals(files ranking.Signals, key func(string) string) ranking.Signals {
+	grouped := ranking.Signals{
+		Definitions: make(map[string]int),
+		Churn:       make(map[string]int),
+		Recency:     make(map[string]float64),
+		Authors:     make(map[string]int),
+	}
+	for p, n := range files.Definitions {
+		grouped.Definitions[key(p)] += n
+	}
+	for p, n := range files.Churn {
+		grouped.Churn[key(p)] += n
+	}
+	for p, r := range files.Recency {
+		grouped.Recency[key(p)] = max(grouped.Recency[key(p)], r)
+	}
+	for p, n := range files.Authors {
+		grouped.Authors[key(p)] = max(grouped.Authors[key(p)], n)
+	}
+	return grouped
+}
+
+// addHistory fills the change history fields of the file nodes. Without
+// a git repository only the last modified time is known.
+func addHistory(absRoot string, fileNodes []*output.FileNode, maxCommits int) error {
+	paths := make([]string, len(fileNodes))
+	for i, node := range fileNodes {
+		paths[i] = node.Path
+	}
+	h, err := history.Load(absRoot, paths, maxCommits)
+	if err != nil {
+		return err
+	}
+	for _, node := range fileNodes {
+		s, ok := h.Files[node.Path]
+		if !ok {
+			continue
+		}
+		node.Churn = s.Churn
+		node.Authors = s.Authors
+		node.LastModified = s.LastModified.UTC().Format(time.RFC3339)
+	}
+	return nil
+}
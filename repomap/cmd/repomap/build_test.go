@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/cache"
+	"github.com/spanexx/agents-cli/repomap/internal/discovery"
+	"github.com/spanexx/agents-cli/repomap/pkg/cli"
+	"github.com/spanexx/agents-cli/repomap/pkg/config"
+	"github.com/spanexx/agents-cli/repomap/pkg/util"
+)
+
+func TestBuildGraph_WarmRun(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":       "module example.com/app\n",
+		"main.go":      "package main\n\nimport \"example.com/app/sub\"\n\nfunc main() { sub.Run() }\n",
+		"sub/sub.go":   "package sub\n\nfunc Run() {}\n",
+		"web/app.ts":   "import { x } from \"./lib\";\n",
+		"web/lib.ts":   "export const x = 1;\n",
+		"package.json": "{}\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := cli.NewApp("repomap", version)
+	app.AddFlag("include-ext", "", "")
+	app.AddFlag("exclude-ext", "", "")
+	app.AddFlag("ignore-tests", "", false)
+	addRankFlags(app)
+	flags, err := app.Parse(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := loadRankConfig(flags, &config.Config{Settings: map[string]interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := util.NewLogger(io.Discard, io.Discard, false)
+
+	tree, err := discovery.WalkTree(root, discovery.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.ProjectFiles) != 2 {
+		t.Fatalf("expected go.mod and package.json from the walk, got %v", tree.ProjectFiles)
+	}
+
+	build := func() *cache.Cache {
+		c := cache.Open(root)
+		buildGraph(root, tree, false, rc, flags, c, logger)
+		return c
+	}
+	if hits, misses := build().Counts(); hits != 0 || misses != 4 {
+		t.Fatalf("expected a cold run to parse all 4 files, got %d hits and %d misses", hits, misses)
+	}
+
+	// A module added after the walk stays unseen: the build reads the
+	// project files of the walk rather than walking the tree again.
+	if err := os.WriteFile(filepath.Join(root, "sub", "go.mod"), []byte("module example.com/sub\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := cache.Open(root)
+	g, nodes := buildGraph(root, tree, false, rc, flags, c, logger)
+	if hits, misses := c.Counts(); hits != 4 || misses != 0 {
+		t.Errorf("expected a warm run to reuse all 4 files, got %d hits and %d misses", hits, misses)
+	}
+	for _, node := range nodes {
+		if node.Path == "sub/sub.go" && node.Module != "example.com/app" {
+			t.Errorf("expected sub/sub.go in the module of the walk, got %q", node.Module)
+		}
+	}
+	for from, to := range map[string]string{"main.go": "sub/sub.go", "web/app.ts": "web/lib.ts"} {
+		if len(g.Edges[from]) != 1 || g.Edges[from][0] != to {
+			t.Errorf("expected %s to import %s, got %v", from, to, g.Edges[from])
+		}
+	}
+}
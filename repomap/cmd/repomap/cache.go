@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/analysis"
+	"github.com/spanexx/agents-cli/repomap/internal/cache"
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// cacheUsage is the usage of the cache command.
+const cacheUsage = "cache stats|clear"
+
+// runCacheCommand shows the size of the cache of the repository at root,
+// or clears it.
+func runCacheCommand(w io.Writer, c *cache.Cache, args []string) error {
+	if len(args) != 1 || (args[0] != "stats" && args[0] != "clear") {
+		return fmt.Errorf("usage: repomap %s", cacheUsage)
+	}
+	stats, err := c.Stats()
+	if err != nil {
+		return err
+	}
+	entries, size := 0, int64(0)
+	for _, s := range stats {
+		entries += s.Entries
+		size += s.Bytes
+	}
+
+	if args[0] == "clear" {
+		if err := c.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Removed %d entries (%s) from %s\n", entries, formatBytes(size), c.Path())
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s\n", c.Path())
+	for _, s := range stats {
+		fmt.Fprintf(w, "  %-12s %8d entries %10s\n", s.Kind, s.Entries, formatBytes(s.Bytes))
+	}
+	fmt.Fprintf(w, "  %-12s %8d entries %10s\n", "total", entries, formatBytes(size))
+	return nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// detectDuplication runs the duplication detector over the files, or takes
+// its issues from the cache if it ran over the same files before.
+func detectDuplication(src analysis.Sources, c *cache.Cache) ([]output.Issue, error) {
+	detector := analysis.NewDuplicationDetector()
+	parts := append([][]byte{[]byte(fmt.Sprint(detector.MinTokens))}, sourceParts(src)...)
+	key := cache.Key(analysis.DuplicationVersion, parts...)
+
+	var issues []output.Issue
+	if c.Get("duplication", key, &issues) {
+		return issues, nil
+	}
+	issues, err := detector.Analyze(src)
+	if err != nil {
+		return nil, err
+	}
+	c.Put("duplication", key, issues)
+	return issues, nil
+}
+
+// validateIntents checks the imports of the files against the layers of
+// their intents, or takes the issues from the cache if the same files, with
+// the same intents and imports, were validated before.
+func validateIntents(files []*output.FileNode, src analysis.Sources, c *cache.Cache) []output.Issue {
+	parts := sourceParts(src)
+	for _, f := range files {
+		parts = append(parts, []byte(f.Path), []byte(f.Intent), []byte(strings.Join(f.Imports, "\n")))
+	}
+	key := cache.Key(analysis.IntentVersion, parts...)
+
+	var issues []output.Issue
+	if c.Get("intent", key, &issues) {
+		return issues
+	}
+	issues = analysis.NewIntentValidator().Validate(files, src)
+	c.Put("intent", key, issues)
+	return issues
+}
+
+// detectCycles finds the import cycles of the graph, or takes them from
+// the cache if a graph with the same imports was checked before.
+func detectCycles(g *graph.Graph, c *cache.Cache) []analysis.Cycle {
+	froms := make([]string, 0, len(g.Edges))
+	for from := range g.Edges {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	var parts [][]byte
+	for _, from := range froms {
+		parts = append(parts, []byte(from), []byte(strings.Join(g.Edges[from], "\n")), []byte(strings.Join(g.Via[from], "\n")))
+	}
+	key := cache.Key(analysis.CycleVersion, parts...)
+
+	var cycles []analysis.Cycle
+	if c.Get("cycles", key, &cycles) {
+		return cycles
+	}
+	cycles = analysis.DetectCycles(g)
+	c.Put("cycles", key, cycles)
+	return cycles
+}
+
+// sourceParts returns the paths and contents of the files, in path order,
+// as parts of a cache key.
+func sourceParts(src analysis.Sources) [][]byte {
+	paths := make([]string, 0, len(src))
+	for p := range src {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	parts := make([][]byte, 0, 2*len(paths))
+	for _, p := range paths {
+		parts = append(parts, []byte(p), src[p])
+	}
+	return parts
+}
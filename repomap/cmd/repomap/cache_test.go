@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/analysis"
+	"github.com/spanexx/agents-cli/repomap/internal/cache"
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+func TestAnalysisCache(t *testing.T) {
+	root := t.TempDir()
+	src := analysis.Sources{
+		"domain/user.go":          []byte("package domain\n\nimport \"example.com/app/infrastructure/db\"\n"),
+		"infrastructure/db/db.go": []byte("package db\n\nimport \"example.com/app/domain\"\n"),
+	}
+	files := []*output.FileNode{
+		{Path: "domain/user.go", Intent: "domain", Imports: []string{"example.com/app/infrastructure/db"}},
+		{Path: "infrastructure/db/db.go", Imports: []string{"example.com/app/domain"}},
+	}
+	b := graph.NewBuilder()
+	for _, f := range files {
+		b.AddFile(f.Path, f.Imports)
+	}
+	g := b.Build("example.com/app")
+
+	cold := cache.Open(root)
+	issues := validateIntents(files, src, cold)
+	cycles := detectCycles(g, cold)
+	if len(issues) != 1 || len(cycles) == 0 {
+		t.Fatalf("expected an intent violation and a cycle, got %+v and %+v", issues, cycles)
+	}
+
+	warm := cache.Open(root)
+	if got := validateIntents(files, src, warm); !reflect.DeepEqual(got, issues) {
+		t.Errorf("expected the cached intent issues %+v, got %+v", issues, got)
+	}
+	if got := detectCycles(g, warm); !reflect.DeepEqual(got, cycles) {
+		t.Errorf("expected the cached cycles %+v, got %+v", cycles, got)
+	}
+	if hits, misses := warm.Counts(); hits != 2 || misses != 0 {
+		t.Errorf("expected a warm run to reuse both results, got %d hits and %d misses", hits, misses)
+	}
+
+	// Another intent is another entry.
+	files[0].Intent = "adapter"
+	validateIntents(files, src, warm)
+	if _, misses := warm.Counts(); misses != 1 {
+		t.Errorf("expected a changed intent to miss the cache, got %d misses", misses)
+	}
+}
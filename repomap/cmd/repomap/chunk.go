@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// writeChunks writes the chunks of a split map to numbered files named
+// after path, or to stdout as one JSON array when path is empty, and
+// reports their token counts on stderr.
+func writeChunks(path string, chunks []*output.Rendered, chunkTokens int) error {
+	total, largest := 0, 0
+	for _, c := range chunks {
+		total += c.Tokens
+		largest = max(largest, c.Tokens)
+	}
+
+	dest := "stdout"
+	if path == "" {
+		joined, err := output.JoinJSON(chunks)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stdout.Write(joined.Data); err != nil {
+			return err
+		}
+	} else {
+		for i, c := range chunks {
+			if err := output.WriteFile(chunkPath(path, i+1, len(chunks)), c.Data); err != nil {
+				return err
+			}
+		}
+		dest = chunkPath(path, 1, len(chunks))
+		if len(chunks) > 1 {
+			dest += " through " + chunkPath(path, len(chunks), len(chunks))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "repomap: wrote %d chunks of at most %d tokens (%d in all, budget %d each) to %s\n",
+		len(chunks), largest, total, chunkTokens, dest)
+	return nil
+}
+
+// chunkPath numbers path for chunk n of total, before its extension and
+// zero-padded so the files sort in order: map.json becomes map.01.json.
+func chunkPath(path string, n, total int) string {
+	ext := filepath.Ext(path)
+	width := len(fmt.Sprint(total))
+	return fmt.Sprintf("%s.%0*d%s", strings.TrimSuffix(path, ext), width, n, ext)
+}
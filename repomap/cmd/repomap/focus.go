@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+	"github.com/spanexx/agents-cli/repomap/internal/search"
+)
+
+// querySeedLimit caps the number of --query matches used as seeds, so the
+// long tail of files sharing a common term does not flatten the ranking.
+const querySeedLimit = 20
+
+// focusSeeds builds the personalization vector for --focus and --query.
+// Each focus entry names a file, a directory or a symbol and contributes a
+// weight of 1 shared by the files it matches. Query matches contribute
+// their BM25 scores relative to the best match. It returns nil when
+// neither is set.
+func focusSeeds(files []*output.FileNode, focus []string, query string) (map[string]float64, error) {
+	if len(focus) == 0 && strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	seeds := make(map[string]float64)
+
+	for _, entry := range focus {
+		matches := matchFocus(files, entry)
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("focus %q matches no file, directory or symbol", entry)
+		}
+		for _, p := range matches {
+			seeds[p] += 1 / float64(len(matches))
+		}
+	}
+
+	if strings.TrimSpace(query) != "" {
+		ix := search.NewIndex()
+		for _, f := range files {
+			ix.Add(f.Path, append([]string{f.Path, f.Intent}, f.Definitions...)...)
+		}
+		hits := ix.Search(query)
+		if len(hits) == 0 {
+			return nil, fmt.Errorf("query %q matches no file", query)
+		}
+		if len(hits) > querySeedLimit {
+			hits = hits[:querySeedLimit]
+		}
+		for _, hit := range hits {
+			seeds[hit.ID] += hit.Score / hits[0].Score
+		}
+	}
+	return seeds, nil
+}
+
+// matchFocus returns the files named by a focus entry: the file itself,
+// the files under a directory, or the files defining a symbol.
+func matchFocus(files []*output.FileNode, entry string) []string {
+	entry = strings.TrimSuffix(strings.TrimPrefix(entry, "./"), "/")
+
+	var matches []string
+	for _, f := range files {
+		if f.Path == entry || strings.HasPrefix(f.Path, entry+"/") {
+			matches = append(matches, f.Path)
+		}
+	}
+	if len(matches) > 0 || strings.Contains(entry, "/") {
+		return matches
+	}
+
+	symbol := regexp.MustCompile(`\b` + regexp.QuoteMeta(entry) + `\b`)
+	for _, f := range files {
+		for _, def := range f.Definitions {
+			if symbol.MatchString(def) {
+				matches = append(matches, f.Path)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// groupSeeds sums file seeds per group for ranking collapsed graphs.
+func groupSeeds(seeds map[string]float64, key func(string) string) map[string]float64 {
+	if seeds == nil {
+		return nil
+	}
+	grouped := make(map[string]float64, len(seeds))
+	for p, v := range seeds {
+		grouped[key(p)] += v
+	}
+	return grouped
+}
@@ -6,6 +6,7 @@ and outputs a structured XML or JSON representation suitable for LLM context.
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
@@ -16,16 +17,19 @@ import (
 	"time"
 
 	"github.com/spanexx/agents-cli/repomap/internal/analysis"
+	"github.com/spanexx/agents-cli/repomap/internal/cache"
+	"github.com/spanexx/agents-cli/repomap/internal/deps"
 	"github.com/spanexx/agents-cli/repomap/internal/discovery"
+	"github.com/spanexx/agents-cli/repomap/internal/export"
 	"github.com/spanexx/agents-cli/repomap/internal/graph"
 	"github.com/spanexx/agents-cli/repomap/internal/output" // Internal output structs
 	"github.com/spanexx/agents-cli/repomap/internal/parsing"
 	"github.com/spanexx/agents-cli/repomap/internal/planning"
-	"github.com/spanexx/agents-cli/repomap/internal/ranking"
+	"github.com/spanexx/agents-cli/repomap/internal/source"
+	"github.com/spanexx/agents-cli/repomap/internal/tokenizer"
 	"github.com/spanexx/agents-cli/repomap/pkg/adapter"
 	"github.com/spanexx/agents-cli/repomap/pkg/cli"
 	"github.com/spanexx/agents-cli/repomap/pkg/config"
-	pkgOutput "github.com/spanexx/agents-cli/repomap/pkg/output" // Framework output writer
 	"github.com/spanexx/agents-cli/repomap/pkg/providers/gemini_cli"
 	"github.com/spanexx/agents-cli/repomap/pkg/providers/qodercli"
 	"github.com/spanexx/agents-cli/repomap/pkg/providers/qwen_cli"
@@ -35,19 +39,73 @@ import (
 
 const version = "0.1.1"
 
+// externalRankWeight scales the rank of vendored files so they sort below
+// first-party code with a similar number of importers.
+const externalRankWeight = 0.1
+
+// packageSymbolLimit caps the exported symbols listed per package when
+// rendering at package or directory granularity.
+const packageSymbolLimit = 5
+
 func main() {
 	app := cli.NewApp("repomap", version)
 	app.SetDescription("Generate a token-optimized map of your Go repository.")
 	app.AddExample("repomap --root . --output xml")
 	app.AddExample("repomap --output json --max-tokens 4000")
+	app.AddExample("repomap --output markdown --markdown-layout compact --max-tokens 4000")
+	app.AddExample("repomap --output template:csv --out map.csv")
+	app.AddExample("repomap --output pack --query \"token budget\" --strip-comments --max-tokens 16000")
+	app.AddExample("repomap --output markdown --chunk-tokens 8000 --out map.md")
+	app.AddExample("repomap --granularity package --max-tokens 2000")
+	app.AddExample("repomap --query \"token budget truncation\" --max-tokens 4000")
+	app.AddExample("repomap rdeps pkg/adapter/adapter.go --depth 2 --output text")
+	app.AddExample("repomap path cmd/repomap pkg/tools")
+	app.AddExample("repomap validate map.json")
+	app.AddExample("repomap --output sarif --fail-on high --out repomap.sarif")
+	app.AddExample("repomap --output html --analyze --out report.html")
+	app.AddExample("repomap --graph-format mermaid --graph-prefix internal/ --graph-max-nodes 30")
+
+	// Query Commands
+	app.AddCommand("deps <path>...", "List what the paths import, transitively (limit with --depth)")
+	app.AddCommand("rdeps <path>...", "List what imports the paths, transitively (limit with --depth)")
+	app.AddCommand("path <from> <to>", "Show the shortest import path between two paths")
+	app.AddCommand("impact <path>...", "List everything affected by a change to the paths, by importance")
+	app.AddCommand(schemaCommands["schema"], "Print the JSON Schema of maps (default) or plans")
+	app.AddCommand(schemaCommands["validate"], "Check a JSON map or plan against its schema")
+	app.AddCommand(cacheUsage, "Show the size of the parse cache of the root, or clear it")
 
 	// CLI Flags
 	app.AddFlag("root", "Repository root directory", ".")
-	app.AddFlag("output", "Output format (xml|json|text)", "xml")
+	app.AddFlag("output", "Output format (xml|json|text|tree|markdown|pack|sarif|html)", "xml")
+	app.AddFlag("markdown-layout", "Layout of markdown output (detailed|compact)", output.LayoutDetailed)
+	app.AddFlag("template", "Render the map with this Go text/template file", "")
+	app.AddFlag("pack-files", "Number of top-ranked files whose source the pack output considers", 20)
+	app.AddFlag("pack-file-tokens", "Cap on the source tokens packed per file (0 for no cap)", 0)
+	app.AddFlag("strip-comments", "Strip comments from the sources of the pack output", false)
+	app.AddFlag("redact", "Redact secrets from the sources of the pack output", true)
 	app.AddFlag("max-tokens", "Maximum token budget (0 for unlimited)", 0)
+	app.AddFlag("out", "Write the map to this file (atomically) instead of stdout", "")
+	app.AddFlag("chunk-tokens", "Split the whole map into chunks of at most this many tokens, written to numbered --out files or as a JSON array", 0)
+	app.AddFlag("reproducible", "Leave the generation time out of the map metadata", false)
+	app.AddFlag("tokenizer", "Tokenizer used to count tokens (heuristic|cl100k|o200k)", "heuristic")
+	app.AddFlag("tokenizer-dir", "Directory holding the BPE vocabularies of cl100k and o200k", tokenizer.DefaultDir())
 	app.AddFlag("include-ext", "Comma-separated extensions to include (default: .go)", "")
 	app.AddFlag("exclude-ext", "Comma-separated extensions to exclude", "")
 	app.AddFlag("ignore-tests", "Ignore test files (*_test.go)", false)
+	app.AddFlag("granularity", "Map granularity (file|package|dir)", "file")
+	app.AddFlag("dir-depth", "Directory depth used to group files with --granularity dir", 2)
+	addRankFlags(app)
+	app.AddFlag("focus", "Comma-separated files, directories or symbols to rank the map around", []string{})
+	app.AddFlag("query", "Rank the map around files whose paths, definitions or intents match this text", "")
+	app.AddFlag("depth", "Depth limit for deps and rdeps (0 for unlimited)", 0)
+	app.AddFlag("graph-format", "Export the import graph instead of the map (dot|graphml|mermaid|cytoscape)", "")
+	app.AddFlag("graph-prefix", "Comma-separated path prefixes of nodes to keep in the exported graph", []string{})
+	app.AddFlag("graph-max-nodes", "Keep at most this many of the highest-ranked nodes in the exported graph (0 for no cap)", 0)
+	app.AddFlag("deps", "Include a dependencies section listing third-party modules, versions and importers", false)
+	app.AddFlag("skip-dirs", "Comma-separated directory patterns to skip (prefix with ! to re-include)", discovery.DefaultSkipDirs)
+	app.AddFlag("include-vendor", "Walk vendor/ and include its files as low-weight external nodes", false)
+	app.AddFlag("follow-symlinks", "Follow symlinks that stay inside the root (cycle-safe)", false)
+	app.AddFlag("no-cache", "Neither read nor write the parse cache under "+cache.Dir, false)
 	app.AddFlag("verbose", "Enable verbose logging", false)
 	app.AddFlag("version", "Show version information", false)
 
@@ -56,6 +114,7 @@ func main() {
 	app.AddFlag("port", "Server port", "8080")
 	app.AddFlag("plan", "Path to plan file for agent interaction", "PLAN/plan.json")
 	app.AddFlag("analyze", "Run static analysis (duplication, intent, cycles)", false)
+	app.AddFlag("fail-on", "Exit with status 2 when analysis finds issues of this severity or above (high|medium|low)", "")
 
 	// Parse Flags
 	flags, err := app.Parse(os.Args[1:])
@@ -72,8 +131,22 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Initialize Logger
-	logger := util.NewLogger(os.Stdout, os.Stderr, flags.GetBool("verbose"))
+	// Initialize Logger. Stdout carries the map, so progress goes to stderr
+	// and a SARIF or JSON map can be piped while analysis reports.
+	logger := util.NewLogger(os.Stderr, os.Stderr, flags.GetBool("verbose"))
+
+	if args := flags.Args(); len(args) > 0 {
+		if _, ok := schemaCommands[args[0]]; ok {
+			valid, err := runSchemaCommand(os.Stdout, args[0], args[1:])
+			if err != nil {
+				logger.Error("%v", err)
+			}
+			if !valid {
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	}
 
 	// Load Configuration (merging defaults -> config -> flags)
 	// For now, we simple take flags as source of truth, but loading config is part of framework.
@@ -107,6 +180,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	if args := flags.Args(); len(args) > 0 && args[0] == "cache" {
+		if err := runCacheCommand(os.Stdout, cache.Open(absRoot), args[1:]); err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+		return
+	}
+	noCache := flags.GetBool("no-cache")
+	if _, ok := visited["no-cache"]; !ok && cfg.GetBool("no-cache") {
+		noCache = true
+	}
+	var fileCache *cache.Cache
+	if !noCache {
+		fileCache = cache.Open(absRoot)
+	}
+
 	outputFmt := flags.GetString("output")
 	if cfg.GetString("output") != "" {
 		if _, ok := visited["output"]; !ok {
@@ -115,125 +204,260 @@ func main() {
 		}
 	}
 
-	// Validate output format using framework factory check (or just try to create one)
-	writer, err := pkgOutput.NewWriter(outputFmt)
-	if err != nil {
-		logger.Error("Invalid output format: %v", err)
-		os.Exit(1)
+	maxTokens := flags.GetInt("max-tokens")
+	if _, ok := visited["max-tokens"]; !ok && cfg.GetInt("max-tokens") > 0 {
+		maxTokens = cfg.GetInt("max-tokens")
 	}
 
-	start := time.Now()
-	// Use explicit logging instead of 'log' function
-	if flags.GetBool("verbose") {
-		logger.Info("Starting Repomap on %s", absRoot)
+	chunkTokens := flags.GetInt("chunk-tokens")
+	if _, ok := visited["chunk-tokens"]; !ok && cfg.GetInt("chunk-tokens") > 0 {
+		chunkTokens = cfg.GetInt("chunk-tokens")
+	}
+	if chunkTokens < 0 {
+		logger.Error("Invalid chunk size: %d tokens", chunkTokens)
+		os.Exit(1)
+	}
+	if chunkTokens > 0 {
+		// Chunks spread the whole map; a configured budget does not apply.
+		if _, ok := visited["max-tokens"]; ok {
+			logger.Error("--chunk-tokens splits the whole map and cannot be combined with --max-tokens")
+			os.Exit(1)
+		}
+		maxTokens = 0
 	}
 
-	// 2. Discovery
-	logger.Debug("Phase A: Discovering files...")
-
-	files, err := discovery.Walk(absRoot)
-	if err != nil {
-		logger.Error("Discovery failed: %v", err)
+	renderOpts := output.Options{MaxTokens: maxTokens, Layout: flags.GetString("markdown-layout")}
+	if _, ok := visited["markdown-layout"]; !ok && cfg.GetString("markdown-layout") != "" {
+		renderOpts.Layout = cfg.GetString("markdown-layout")
+	}
+	if !contains(output.Layouts, renderOpts.Layout) {
+		logger.Error("Invalid markdown layout: %s (expected one of %s)", renderOpts.Layout, strings.Join(output.Layouts, ", "))
 		os.Exit(1)
 	}
 
-	// Apply CLI filters
-	filteredFiles := filterFiles(files, flags)
-	logger.Debug("Found %d files", len(filteredFiles))
+	templatePath := flags.GetString("template")
+	if _, ok := visited["template"]; !ok && cfg.GetString("template") != "" {
+		templatePath = cfg.GetString("template")
+	}
+	if templatePath != "" {
+		src, err := os.ReadFile(templatePath)
+		if err != nil {
+			logger.Error("Failed to read template: %v", err)
+			os.Exit(1)
+		}
+		renderOpts.Template = string(src)
+		outputFmt = "template"
+	}
 
-	// 3. Parsing (Definitions & Imports)
-	logger.Debug("Phase B: Parsing %d files...", len(filteredFiles))
+	if !contains(output.Formats, outputFmt) && !strings.HasPrefix(outputFmt, "template") {
+		logger.Error("Invalid output format: %s (expected one of %s, or template:<name>)", outputFmt, strings.Join(output.Formats, ", "))
+		os.Exit(1)
+	}
+	if err := output.CheckFormat(outputFmt, renderOpts); err != nil {
+		logger.Error("Invalid output format: %v", err)
+		os.Exit(1)
+	}
+	packFiles := flags.GetInt("pack-files")
+	if _, ok := visited["pack-files"]; !ok && cfg.GetInt("pack-files") > 0 {
+		packFiles = cfg.GetInt("pack-files")
+	}
+	sourceOpts := source.Options{
+		StripComments: flags.GetBool("strip-comments"),
+		Redact:        flags.GetBool("redact"),
+		MaxTokens:     flags.GetInt("pack-file-tokens"),
+		Count:         output.CountTokens,
+	}
+	if _, ok := visited["strip-comments"]; !ok && cfg.GetBool("strip-comments") {
+		sourceOpts.StripComments = true
+	}
+	if _, ok := visited["pack-file-tokens"]; !ok && cfg.GetInt("pack-file-tokens") > 0 {
+		sourceOpts.MaxTokens = cfg.GetInt("pack-file-tokens")
+	}
+	if packFiles < 0 || sourceOpts.MaxTokens < 0 {
+		logger.Error("Invalid pack settings: --pack-files and --pack-file-tokens cannot be negative")
+		os.Exit(1)
+	}
+	failOn := flags.GetString("fail-on")
+	if _, ok := visited["fail-on"]; !ok && cfg.GetString("fail-on") != "" {
+		failOn = cfg.GetString("fail-on")
+	}
+	if failOn != "" && !contains(analysis.Severities, failOn) {
+		logger.Error("Invalid --fail-on severity: %s (expected one of %s)", failOn, strings.Join(analysis.Severities, ", "))
+		os.Exit(1)
+	}
+	// SARIF output and the issue gate both need the analysis.
+	analyze := flags.GetBool("analyze") || outputFmt == "sarif" || failOn != ""
+	if output.IsReport(outputFmt) && chunkTokens > 0 {
+		logger.Error("The %s report covers the whole map and cannot be split with --chunk-tokens", outputFmt)
+		os.Exit(1)
+	}
+	if chunkTokens > 0 && outputFmt != "json" && flags.GetString("out") == "" {
+		logger.Error("Chunks are written to numbered files with --out, or to stdout as a JSON array with --output json")
+		os.Exit(1)
+	}
 
-	graphBuilder := graph.NewBuilder()
-	fileNodes := make([]*output.FileNode, 0, len(filteredFiles))
+	granularity := flags.GetString("granularity")
+	if _, ok := visited["granularity"]; !ok && cfg.GetString("granularity") != "" {
+		granularity = cfg.GetString("granularity")
+	}
+	dirDepth := flags.GetInt("dir-depth")
+	if _, ok := visited["dir-depth"]; !ok && cfg.GetInt("dir-depth") > 0 {
+		dirDepth = cfg.GetInt("dir-depth")
+	}
 
-	for _, path := range filteredFiles {
-		// Extract Definitions via Registry
-		extractor := parsing.DefaultRegistry.Get(path)
-		var defs []string
-		if extractor != nil {
-			defs, err = extractor.ExtractDefinitions(path)
-			if err != nil {
-				logger.Warn("Failed to parse definitions for %s: %v", path, err)
-			}
-		}
+	var groupKey func(string) string
+	switch granularity {
+	case "file":
+	case "package":
+		groupKey = graph.PackageKey
+	case "dir":
+		groupKey = graph.DirKey(dirDepth)
+	default:
+		logger.Error("Invalid granularity: %s (expected file, package or dir)", granularity)
+		os.Exit(1)
+	}
+	if outputFmt == "sarif" && groupKey != nil {
+		logger.Error("SARIF output locates issues in files and needs --granularity file")
+		os.Exit(1)
+	}
 
-		// Extract Imports via Registry
-		var imports []string
-		if extractor != nil {
-			imports, err = extractor.ExtractImports(path)
-			if err != nil {
-				logger.Warn("Failed to parse imports for %s: %v", path, err)
-			}
-		}
+	tokenizerName := flags.GetString("tokenizer")
+	if _, ok := visited["tokenizer"]; !ok && cfg.GetString("tokenizer") != "" {
+		tokenizerName = cfg.GetString("tokenizer")
+	}
+	tokenizerDir := flags.GetString("tokenizer-dir")
+	if _, ok := visited["tokenizer-dir"]; !ok && cfg.GetString("tokenizer-dir") != "" {
+		tokenizerDir = cfg.GetString("tokenizer-dir")
+	}
+	// A budget counted with another tokenizer than the one asked for would
+	// not hold, so a missing vocabulary is an error.
+	tok, err := tokenizer.New(tokenizerName, tokenizerDir)
+	if err != nil {
+		logger.Error("Tokenizer unavailable: %v", err)
+		os.Exit(1)
+	}
+	output.SetCounter(tok.Count)
 
-		// Add to Graph Builder (relative to root)
-		relPath, _ := filepath.Rel(absRoot, path)
-		relPath = filepath.ToSlash(relPath)
+	graphFormat := flags.GetString("graph-format")
+	if graphFormat != "" && !contains(export.Formats, graphFormat) {
+		logger.Error("Invalid graph format: %s (expected one of %s)", graphFormat, strings.Join(export.Formats, ", "))
+		os.Exit(1)
+	}
 
-		graphBuilder.AddFile(relPath, imports)
+	rc, err := loadRankConfig(flags, cfg)
+	if err != nil {
+		logger.Error("Invalid ranking settings: %v", err)
+		os.Exit(1)
+	}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		lang := strings.TrimPrefix(ext, ".")
-		if lang == "" {
-			lang = "unknown"
+	var command string
+	var commandArgs []string
+	if args := flags.Args(); len(args) > 0 {
+		command, commandArgs = args[0], args[1:]
+		if err := checkQueryArgs(command, commandArgs); err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
 		}
+	}
 
-		fileNodes = append(fileNodes, &output.FileNode{
-			Path:        relPath,
-			Language:    lang,
-			Definitions: defs,
-			Imports:     imports,
-			TokenCount:  0,
-		})
+	start := time.Now()
+	// Use explicit logging instead of 'log' function
+	if flags.GetBool("verbose") {
+		logger.Info("Starting Repomap on %s", absRoot)
 	}
 
-	// 4. Graph Construction
-	logger.Debug("Phase C: Building import graph...")
-	moduleName := findModuleName(absRoot)
-	importGraph := graphBuilder.Build(moduleName)
+	// 2. Discovery
+	logger.Debug("Phase A: Discovering files...")
 
-	// 5. Ranking
-	logger.Debug("Phase D: Ranking files...")
-	ranks := ranking.Rank(importGraph)
-	importance := ranking.AssignImportance(ranks)
+	walkOpts := discovery.Options{
+		SkipDirs:       flags.GetStringSlice("skip-dirs"),
+		IncludeVendor:  flags.GetBool("include-vendor"),
+		FollowSymlinks: flags.GetBool("follow-symlinks"),
+	}
+	if _, ok := visited["skip-dirs"]; !ok && cfg.GetStringSlice("skip-dirs") != nil {
+		walkOpts.SkipDirs = cfg.GetStringSlice("skip-dirs")
+	}
+	if _, ok := visited["include-vendor"]; !ok && cfg.GetBool("include-vendor") {
+		walkOpts.IncludeVendor = true
+	}
+	if _, ok := visited["follow-symlinks"]; !ok && cfg.GetBool("follow-symlinks") {
+		walkOpts.FollowSymlinks = true
+	}
 
-	// Enrich file nodes
-	for _, node := range fileNodes {
-		if r, ok := ranks[node.Path]; ok {
-			node.Rank = r
-		}
-		if imp, ok := importance[node.Path]; ok {
-			node.Importance = imp
-		} else {
-			node.Importance = "low"
-		}
+	tree, err := discovery.WalkTree(absRoot, walkOpts)
+	if err != nil {
+		logger.Error("Discovery failed: %v", err)
+		os.Exit(1)
 	}
+	for _, p := range tree.Unreadable {
+		logger.Debug("Skipping unreadable %s", p)
+	}
+	importGraph, fileNodes := buildGraph(absRoot, tree, walkOpts.IncludeVendor, rc, flags, fileCache, logger)
 
-	// Sort by Rank
-	sort.Slice(fileNodes, func(i, j int) bool {
-		return fileNodes[i].Rank > fileNodes[j].Rank
-	})
+	if command != "" {
+		err := runQuery(os.Stdout, command, commandArgs, importGraph, fileNodes, groupKey, rc,
+			outputFmt, flags.GetInt("depth"), maxTokens)
+		if err != nil {
+			logger.Error("Query failed: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// 5.5 Intent Assignment (Heuristic + LLM)
 	// We do this before output so it's included in the result.
 	var provider adapter.Provider
-	if flags.GetBool("analyze") {
+	if analyze {
 		// Initialize provider for analysis if requested
 		var err error
-		provider, err = initProvider()
+		provider, err = initProvider(logger)
 		if err != nil {
 			logger.Warn("Failed to initialize LLM provider for intent analysis: %v (falling back to heuristics)", err)
 		}
 	}
 	analysis.AssignIntent(fileNodes, provider)
 
+	// 5.6 Task Focus
+	seeds, err := focusSeeds(fileNodes, flags.GetStringSlice("focus"), flags.GetString("query"))
+	if err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+	if seeds != nil {
+		logger.Debug("Re-ranking around %d seed files...", len(seeds))
+		rc.opts.Personalization = seeds
+		rankFiles(importGraph, fileNodes, rc)
+	}
+
 	// 6. Output
 	logger.Debug("Phase E: Rendering output...")
+	if outputFmt == "tree" && groupKey == nil {
+		addOutlines(absRoot, fileNodes, fileCache, logger)
+	}
+	if outputFmt == "pack" && groupKey == nil {
+		addSources(absRoot, fileNodes, packFiles, sourceOpts, logger)
+	}
 
 	// Wrap in RepoMap for proper root element in XML/JSON
+	reproducible := flags.GetBool("reproducible")
+	if _, ok := visited["reproducible"]; !ok && cfg.GetBool("reproducible") {
+		reproducible = true
+	}
 	result := &output.RepoMap{
+		Meta:  mapMetadata(absRoot, rootDir, tok.Name(), maxTokens, fileNodes, flags, cfg, reproducible, logger),
+		Name:  filepath.Base(absRoot),
 		Files: fileNodes,
+		Edges: graphEdges(importGraph),
+	}
+
+	// 5.4 External Dependencies
+	includeDeps := flags.GetBool("deps")
+	if _, ok := visited["deps"]; !ok && cfg.GetBool("deps") {
+		includeDeps = true
+	}
+	if includeDeps {
+		logger.Debug("Collecting third-party dependencies...")
+		result.Dependencies = deps.Collect(importGraph.External, deps.ManifestsFromFiles(absRoot, tree.ProjectFiles))
 	}
 
 	// 5.5 Planning (Merge Plan)
@@ -256,92 +480,40 @@ func main() {
 	}
 
 	// 5.6 Static Analysis
-	if flags.GetBool("analyze") {
+	failing := 0
+	if analyze {
 		logger.Info("Running static analysis...")
 
-		// Map generated result files to a map for easy lookup
-		nodeMap := make(map[string]*output.FileNode)
-		contentMap := make(map[string][]byte)
-
+		// Read the content of existing files (not planned ones) to detect
+		// duplication and locate issues.
+		contentMap := make(analysis.Sources)
 		for _, node := range result.Files {
-			nodeMap[node.Path] = node
-			// Read content for duplication detection
-			// Only for existing files (Status != "planned")
-			fullPath := filepath.Join(absRoot, node.Path)
-			if _, err := os.Stat(fullPath); err == nil {
-				data, err := os.ReadFile(fullPath)
-				if err == nil {
-					contentMap[node.Path] = data
-				}
+			if data, err := os.ReadFile(filepath.Join(absRoot, node.Path)); err == nil {
+				contentMap[node.Path] = data
 			}
 		}
 
 		// A. Duplication Detection
-		dupeDetector := analysis.NewDuplicationDetector()
-		dupes, err := dupeDetector.Analyze(contentMap)
+		dupes, err := detectDuplication(contentMap, fileCache)
 		if err != nil {
 			logger.Warn("Duplication analysis failed: %v", err)
 		} else {
 			logger.Info("Found %d duplication issues", len(dupes))
-			// Distribute issues to files?
-			// The issues describe "files involved".
-			// We can attach the issue to all involved files.
-			// Currently Issue struct is simple.
-			// Let's attach to the first file mentioned in description?
-			// Or just attach to a random one?
-			// Better: Duplicate detector should probably return map[filename][]Issue?
-			// Current implementation returns []Issue.
-			// For MVP, simplistic attachment:
-			for _, issue := range dupes {
-				// Parse paths from description? (Brittle but working based on implementation)
-				// "Found duplicate code block ... across X files: path/a.go, path/b.go"
-				blockParts := strings.Split(issue.Description, ": ")
-				if len(blockParts) > 1 {
-					paths := strings.Split(blockParts[1], ", ")
-					for _, p := range paths {
-						if node, ok := nodeMap[p]; ok {
-							node.Issues = append(node.Issues, issue)
-						}
-					}
-				}
-			}
+			analysis.AttachIssues(result.Files, dupes)
 		}
 
 		// B. Intent Validation
-		intentValidator := analysis.NewIntentValidator()
-		intentIssues := intentValidator.Validate(result.Files)
+		intentIssues := validateIntents(result.Files, contentMap, fileCache)
 		logger.Info("Found %d intent violations", len(intentIssues))
-		for _, issue := range intentIssues {
-			// Parse path from description?
-			// "Architecture Violation: 'intent' layer (path/to/file) imports..."
-			// We need a better way to link issues to files.
-			// Validator.Validate loops over files.
-			// Maybe we should update Validator to attach issues directly?
-			// Or just parse.
-			start := strings.Index(issue.Description, "(")
-			end := strings.Index(issue.Description, ")")
-			if start != -1 && end != -1 && end > start {
-				path := issue.Description[start+1 : end]
-				if node, ok := nodeMap[path]; ok {
-					node.Issues = append(node.Issues, issue)
-				}
-			}
-		}
+		analysis.AttachIssues(result.Files, intentIssues)
 
 		// C. Circular Dependencies
-		depGraph := analysis.BuildGraph(result.Files, "")
-		cycleIssues := depGraph.DetectCycles()
-		logger.Info("Found %d circular dependencies", len(cycleIssues))
-		for _, issue := range cycleIssues {
-			// "Circular dependency detected: a -> b -> a"
-			// Attach to the last node (neighbor)
-			parts := strings.Split(issue.Description, " -> ")
-			if len(parts) > 0 {
-				lastPath := parts[len(parts)-1]
-				if node, ok := nodeMap[lastPath]; ok {
-					node.Issues = append(node.Issues, issue)
-				}
-			}
+		cycles := detectCycles(importGraph, fileCache)
+		logger.Info("Found %d circular dependencies", len(cycles))
+		analysis.AttachCycles(result.Files, cycles, contentMap)
+
+		if failOn != "" {
+			failing = analysis.CountAtLeast(result.Files, failOn)
 		}
 	}
 
@@ -363,16 +535,141 @@ func main() {
 		os.Exit(0)
 	}
 
-	if err := writer.Write(result); err != nil {
-		logger.Error("Rendering failed: %v", err)
-		os.Exit(1)
+	var pkgs []*output.PackageNode
+	var pkgGraph *graph.Graph
+	if groupKey != nil {
+		logger.Debug("Collapsing map to %s granularity...", granularity)
+		pkgs, pkgGraph = collapseMap(importGraph, result.Files, groupKey, rc)
 	}
 
-	// Ensure we print a newline? Writer might do it.
+	if graphFormat != "" {
+		opts := export.Options{
+			Prefixes: flags.GetStringSlice("graph-prefix"),
+			MaxNodes: flags.GetInt("graph-max-nodes"),
+		}
+		sub := export.FromFiles(importGraph, result.Files, opts)
+		if groupKey != nil {
+			sub = export.FromPackages(pkgGraph, pkgs, opts)
+		}
+		if err := writeGraph(flags.GetString("out"), graphFormat, sub); err != nil {
+			logger.Error("Graph export failed: %v", err)
+			os.Exit(1)
+		}
+		exitOnIssues(failing, failOn, logger)
+		return
+	}
+
+	if groupKey != nil {
+		result.Packages = pkgs
+		result.Files = nil
+	}
+
+	if chunkTokens > 0 {
+		chunks, err := output.Split(result, outputFmt, renderOpts, chunkTokens)
+		if err != nil {
+			logger.Error("Rendering failed: %v", err)
+			os.Exit(1)
+		}
+		if err := writeChunks(flags.GetString("out"), chunks, chunkTokens); err != nil {
+			logger.Error("Writing output failed: %v", err)
+			os.Exit(1)
+		}
+	} else {
+		rendered, err := output.Render(result, outputFmt, renderOpts)
+		if err != nil {
+			logger.Error("Rendering failed: %v", err)
+			os.Exit(1)
+		}
+		if err := writeMap(flags.GetString("out"), rendered, maxTokens); err != nil {
+			logger.Error("Writing output failed: %v", err)
+			os.Exit(1)
+		}
+	}
 
 	if flags.GetBool("verbose") {
 		logger.Info("Completed in %v", time.Since(start))
 	}
+	exitOnIssues(failing, failOn, logger)
+}
+
+// exitOnIssues exits with status 2, which tells failing issues apart from
+// errors, when the analysis found issues at or above the --fail-on
+// severity.
+func exitOnIssues(failing int, failOn string, logger util.Logger) {
+	if failing == 0 {
+		return
+	}
+	logger.Error("Found %d issues of %s severity or above", failing, failOn)
+	os.Exit(2)
+}
+
+// writeGraph writes the subgraph in format to path, or to stdout when path
+// is empty.
+func writeGraph(path, format string, sub *export.Subgraph) error {
+	if path == "" {
+		return export.Write(os.Stdout, format, sub)
+	}
+	var b bytes.Buffer
+	if err := export.Write(&b, format, sub); err != nil {
+		return err
+	}
+	return output.WriteFile(path, b.Bytes())
+}
+
+// writeMap writes the rendered map to path, or to stdout when path is
+// empty, and reports its token count on stderr.
+func writeMap(path string, r *output.Rendered, maxTokens int) error {
+	dest := "stdout"
+	if path == "" {
+		if _, err := os.Stdout.Write(r.Data); err != nil {
+			return err
+		}
+	} else {
+		if err := output.WriteFile(path, r.Data); err != nil {
+			return err
+		}
+		dest = path
+	}
+
+	budget := ""
+	if maxTokens > 0 {
+		budget = fmt.Sprintf(" (budget %d)", maxTokens)
+	}
+	fmt.Fprintf(os.Stderr, "repomap: wrote %d tokens to %s%s\n", r.Tokens, dest, budget)
+	return nil
+}
+
+// collapseMap aggregates ranked file nodes into package nodes grouped by key
+// and ranks them on the collapsed import graph, which is returned with them.
+func collapseMap(g *graph.Graph, files []*output.FileNode, key func(string) string, rc rankConfig) ([]*output.PackageNode, *graph.Graph) {
+	pkgGraph := graph.Collapse(g, key)
+	pkgs := output.CollapseFiles(files, key, packageSymbolLimit)
+	rc.opts.Personalization = groupSeeds(rc.opts.Personalization, key)
+
+	ranks, importance := rc.rank(pkgGraph, groupSignals(fileSignals(files, time.Now()), key))
+	for _, pkg := range pkgs {
+		pkg.Rank = ranks[pkg.Path]
+		pkg.Importance = importance[pkg.Path]
+		if pkg.Importance == "" || pkg.External {
+			pkg.Importance = "low"
+		}
+		if pkg.External {
+			pkg.Rank *= externalRankWeight
+		}
+
+		for _, dep := range pkgGraph.Edges[pkg.Path] {
+			pkg.Dependencies = append(pkg.Dependencies, output.PackageEdge{
+				Path:   dep,
+				Weight: pkgGraph.Weight(pkg.Path, dep),
+			})
+		}
+		sort.SliceStable(pkg.Dependencies, func(i, j int) bool {
+			return pkg.Dependencies[i].Weight > pkg.Dependencies[j].Weight
+		})
+	}
+
+	output.SortPackages(pkgs)
+	return pkgs, pkgGraph
 }
 
 func filterFiles(files []string, flags *cli.Flags) []string {
@@ -433,21 +730,7 @@ func contains(list []string, item string) bool {
 	return false
 }
 
-func findModuleName(root string) string {
-	goModPath := filepath.Join(root, "go.mod")
-	data, err := os.ReadFile(goModPath)
-	if err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			if strings.HasPrefix(line, "module ") {
-				return strings.TrimSpace(strings.TrimPrefix(line, "module "))
-			}
-		}
-	}
-	return ""
-}
-
-func initProvider() (adapter.Provider, error) {
+func initProvider(logger util.Logger) (adapter.Provider, error) {
 	ctx := context.Background()
 	providerName := os.Getenv("REPOMAP_PROVIDER")
 	if providerName == "" {
@@ -469,15 +752,15 @@ func initProvider() (adapter.Provider, error) {
 		case "gemini-cli":
 			gp, err := gemini_cli.New(ctx)
 			if err != nil {
-				fmt.Printf("Warning: failed to create gemini provider: %v\n", err)
+				logger.Warn("Failed to create gemini provider: %v", err)
 				continue
 			}
 			if err := gp.Init(ctx); err != nil {
-				fmt.Printf("Warning: Gemini provider init failed: %v\n", err)
+				logger.Warn("Gemini provider init failed: %v", err)
 			}
 			p = gp
 		default:
-			fmt.Printf("Warning: unknown provider: %s\n", name)
+			logger.Warn("Unknown provider: %s", name)
 			continue
 		}
 		if p != nil {
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spanexx/agents-cli/repomap/internal/history"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+	"github.com/spanexx/agents-cli/repomap/pkg/cli"
+	"github.com/spanexx/agents-cli/repomap/pkg/config"
+	"github.com/spanexx/agents-cli/repomap/pkg/util"
+)
+
+// unrecordedFlags do not change the content of a map, or would make it
+// depend on the machine it was generated on, so they are left out of its
+// metadata.
+var unrecordedFlags = map[string]bool{
+	"root": true, "out": true, "verbose": true, "version": true,
+	"serve": true, "port": true, "tokenizer-dir": true, "reproducible": true, "no-cache": true,
+}
+
+// mapMetadata describes how the map of absRoot is generated. Reproducible
+// maps leave out the generation time, so the same tree and options always
+// yield the same bytes.
+func mapMetadata(absRoot, root, tokenizerName string, maxTokens int, files []*output.FileNode,
+	flags *cli.Flags, cfg *config.Config, reproducible bool, logger util.Logger) *output.Metadata {
+	meta := &output.Metadata{
+		Version:   version,
+		Root:      root,
+		Tokenizer: tokenizerName,
+		MaxTokens: maxTokens,
+		Modules:   fileModules(files),
+		Options:   recordedOptions(flags, cfg),
+	}
+	if !reproducible {
+		meta.Generated = time.Now().UTC().Format(time.RFC3339)
+	}
+	rev, err := history.Head(absRoot)
+	if err != nil {
+		logger.Warn("Failed to read git HEAD: %v", err)
+	}
+	meta.Commit, meta.Dirty = rev.Commit, rev.Dirty
+	return meta
+}
+
+// fileModules returns the sorted, distinct modules of the files.
+func fileModules(files []*output.FileNode) []string {
+	seen := make(map[string]bool)
+	var modules []string
+	for _, f := range files {
+		if f.Module != "" && !seen[f.Module] {
+			seen[f.Module] = true
+			modules = append(modules, f.Module)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// recordedOptions returns the options set on the command line or in the
+// config file, by name. The command line wins over the config file, as it
+// does when the options are applied.
+func recordedOptions(flags *cli.Flags, cfg *config.Config) []output.Option {
+	visited := flags.GetVisitedValues()
+	var options []output.Option
+	for name := range flags.GetValues() {
+		if unrecordedFlags[name] {
+			continue
+		}
+		if v, ok := visited[name]; ok {
+			options = append(options, output.Option{Name: name, Value: flagValue(v)})
+		} else if v, ok := cfg.Settings[name]; ok {
+			options = append(options, output.Option{Name: name, Value: configValue(v)})
+		}
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i].Name < options[j].Name })
+	return options
+}
+
+// flagValue formats a parsed flag value, which the cli package holds as a
+// pointer or a flag.Value.
+func flagValue(value interface{}) string {
+	switch v := value.(type) {
+	case *string:
+		return *v
+	case *int:
+		return fmt.Sprint(*v)
+	case *bool:
+		return fmt.Sprint(*v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// configValue formats a config file setting the way the same option is
+// written on the command line.
+func configValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = configValue(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+	"github.com/spanexx/agents-cli/repomap/internal/query"
+)
+
+// queryCommands maps each query subcommand to the number of path arguments
+// it needs; -1 means one or more.
+var queryCommands = map[string]int{
+	"deps":   -1,
+	"rdeps":  -1,
+	"path":   2,
+	"impact": -1,
+}
+
+// checkQueryArgs validates the arguments of a query subcommand.
+func checkQueryArgs(command string, args []string) error {
+	want, ok := queryCommands[command]
+	if !ok {
+		return fmt.Errorf("unknown command: %s", command)
+	}
+	if want == -1 && len(args) == 0 {
+		return fmt.Errorf("%s needs at least one path", command)
+	}
+	if want > 0 && len(args) != want {
+		return fmt.Errorf("%s needs exactly %d paths, got %d", command, want, len(args))
+	}
+	return nil
+}
+
+// runQuery answers a query subcommand over the import graph and renders the
+// result. With a group key the query runs on the collapsed package graph.
+func runQuery(w io.Writer, command string, args []string, g *graph.Graph, files []*output.FileNode, groupKey func(string) string, rc rankConfig, format string, depth, maxTokens int) error {
+	ranks := make(map[string]float64)
+	importance := make(map[string]string)
+	if groupKey != nil {
+		pkgs, pkgGraph := collapseMap(g, files, groupKey, rc)
+		g = pkgGraph
+		for _, p := range pkgs {
+			ranks[p.Path] = p.Rank
+			importance[p.Path] = p.Importance
+		}
+	} else {
+		for _, f := range files {
+			ranks[f.Path] = f.Rank
+			importance[f.Path] = f.Importance
+		}
+	}
+
+	q := query.New(g)
+	q.Ranks = ranks
+	q.Importance = importance
+
+	targets := make([][]string, len(args))
+	for i, arg := range args {
+		nodes, err := q.Resolve(arg)
+		if err != nil {
+			return err
+		}
+		targets[i] = nodes
+	}
+
+	result := &query.Result{Command: command, Targets: args}
+	switch command {
+	case "deps":
+		result.Depth = depth
+		result.Entries = q.Deps(flatten(targets), depth)
+	case "rdeps":
+		result.Depth = depth
+		result.Entries = q.Rdeps(flatten(targets), depth)
+	case "path":
+		result.Path = q.Path(targets[0], targets[1])
+	case "impact":
+		result.Entries = q.Impact(flatten(targets))
+	}
+
+	return query.Render(w, result, format, maxTokens)
+}
+
+func flatten(lists [][]string) []string {
+	var out []string
+	for _, l := range lists {
+		out = append(out, l...)
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/ranking"
+	"github.com/spanexx/agents-cli/repomap/pkg/cli"
+	"github.com/spanexx/agents-cli/repomap/pkg/config"
+)
+
+// rankConfig holds the ranking settings resolved from flags and config.
+type rankConfig struct {
+	opts       ranking.Options
+	thresholds ranking.Thresholds
+	// history enables reading git history, at most historyCommits commits.
+	history        bool
+	historyCommits int
+}
+
+// addRankFlags registers the ranking flags. Numbers that are not integers
+// are taken as strings since the flag set has no float type.
+func addRankFlags(app *cli.App) {
+	defaults := ranking.DefaultOptions()
+	app.AddFlag("damping", "PageRank damping factor", strconv.FormatFloat(defaults.Damping, 'g', -1, 64))
+	app.AddFlag("rank-iterations", "Maximum PageRank iterations", defaults.MaxIterations)
+	app.AddFlag("rank-tolerance", "PageRank convergence tolerance", strconv.FormatFloat(defaults.Tolerance, 'g', -1, 64))
+	app.AddFlag("rank-weights", "Blend of ranking signals (pagerank, indegree, outdegree, definitions, and churn, recency, authors from --history)", defaults.Weights.String())
+	app.AddFlag("importance-thresholds", "Score thresholds for high and medium importance", "0.7,0.3")
+	app.AddFlag("history", "Read git history (churn, last modified, authors) for every file", false)
+	app.AddFlag("history-commits", "Maximum number of commits to read with --history (0 for all)", 1000)
+}
+
+// loadRankConfig reads the ranking flags, falling back to config values for
+// flags that were not set on the command line.
+func loadRankConfig(flags *cli.Flags, cfg *config.Config) (rankConfig, error) {
+	visited := flags.GetVisitedValues()
+	setting := func(name string) string {
+		if _, ok := visited[name]; !ok {
+			if v := cfg.GetString(name); v != "" {
+				return v
+			}
+			if v := cfg.GetFloat(name); v != 0 {
+				return strconv.FormatFloat(v, 'g', -1, 64)
+			}
+		}
+		return flags.GetString(name)
+	}
+
+	rc := rankConfig{opts: ranking.DefaultOptions()}
+	var err error
+
+	if rc.opts.Damping, err = strconv.ParseFloat(setting("damping"), 64); err != nil || rc.opts.Damping < 0 || rc.opts.Damping >= 1 {
+		return rc, fmt.Errorf("invalid damping %q: must be in [0, 1)", setting("damping"))
+	}
+	if rc.opts.Tolerance, err = strconv.ParseFloat(setting("rank-tolerance"), 64); err != nil || rc.opts.Tolerance < 0 {
+		return rc, fmt.Errorf("invalid rank-tolerance %q", setting("rank-tolerance"))
+	}
+	rc.opts.MaxIterations = flags.GetInt("rank-iterations")
+	if _, ok := visited["rank-iterations"]; !ok && cfg.GetInt("rank-iterations") > 0 {
+		rc.opts.MaxIterations = cfg.GetInt("rank-iterations")
+	}
+	if rc.opts.Weights, err = ranking.ParseWeights(setting("rank-weights")); err != nil {
+		return rc, err
+	}
+	if rc.thresholds, err = ranking.ParseThresholds(setting("importance-thresholds")); err != nil {
+		return rc, err
+	}
+
+	// History weights need the history, so they turn it on.
+	rc.history = flags.GetBool("history") || rc.opts.Weights.UsesHistory()
+	if _, ok := visited["history"]; !ok && cfg.GetBool("history") {
+		rc.history = true
+	}
+	rc.historyCommits = flags.GetInt("history-commits")
+	if _, ok := visited["history-commits"]; !ok && cfg.GetInt("history-commits") > 0 {
+		rc.historyCommits = cfg.GetInt("history-commits")
+	}
+	return rc, nil
+}
+
+// rank scores the nodes of g and assigns their importance levels.
+func (rc rankConfig) rank(g *graph.Graph, signals ranking.Signals) (map[string]float64, map[string]string) {
+	scores := ranking.Score(g, signals, rc.opts)
+	return scores, ranking.AssignImportanceWith(scores, rc.thresholds)
+}
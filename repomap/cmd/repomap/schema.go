@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+	"github.com/spanexx/agents-cli/repomap/internal/schema"
+)
+
+// schemaCommands are subcommands that need no repository. They map to the
+// usage shown when their arguments are wrong.
+var schemaCommands = map[string]string{
+	"schema":   "schema [repomap|plan]",
+	"validate": "validate <file>",
+}
+
+// runSchemaCommand prints a schema or validates a map or plan against its
+// schema. It reports whether the document was valid; other failures are
+// returned as errors.
+func runSchemaCommand(w io.Writer, command string, args []string) (bool, error) {
+	switch {
+	case command == "schema" && len(args) <= 1:
+		name := "repomap"
+		if len(args) == 1 {
+			name = args[0]
+		}
+		data, err := schema.Load(name)
+		if err != nil {
+			return false, err
+		}
+		_, err = w.Write(data)
+		return err == nil, err
+	case command == "validate" && len(args) == 1:
+		doc, err := os.ReadFile(args[0])
+		if err != nil {
+			return false, err
+		}
+		name := schema.Detect(doc)
+		violations, err := schema.Validate(name, doc)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", args[0], err)
+		}
+		for _, v := range violations {
+			fmt.Fprintf(w, "%s: %s\n", args[0], v)
+		}
+		if len(violations) > 0 {
+			fmt.Fprintf(w, "%s: %d violations of the %s schema (version %s)\n", args[0], len(violations), name, output.SchemaVersion)
+			return false, nil
+		}
+		fmt.Fprintf(w, "%s: valid %s (schema version %s)\n", args[0], name, output.SchemaVersion)
+		return true, nil
+	}
+	return false, fmt.Errorf("usage: repomap %s", schemaCommands[command])
+}
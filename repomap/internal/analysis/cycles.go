@@ -0,0 +1,150 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// CycleVersion identifies the results of DetectCycles. Bump it whenever a
+// change to it changes the cycles it reports, so that results cached by an
+// older version are not reused.
+const CycleVersion = "1"
+
+// Cycle is a strongly connected component of the import graph.
+type Cycle struct {
+	// Level is "file" or "package".
+	Level string
+	// Members lists every node in the component, sorted.
+	Members []string
+	// Path is a shortest cycle through the component, ending where it starts.
+	Path []string
+	// Files lists the files the issue is attached to: every member of a file
+	// cycle, or the files whose imports close a package cycle.
+	Files []string
+	// Imports maps each of Files to an import of the file that closes the
+	// cycle, when the graph records it.
+	Imports map[string]string
+}
+
+// Issue describes the cycle as an analysis issue, located at the imports
+// closing it in each of its files.
+func (c Cycle) Issue(src Sources) output.Issue {
+	desc := fmt.Sprintf("Circular dependency detected: %s", strings.Join(c.Path, " -> "))
+	severity := "high"
+	if c.Level == "package" {
+		desc = fmt.Sprintf("Circular package dependency detected: %s", strings.Join(c.Path, " -> "))
+		severity = "medium"
+	}
+	// The shortest cycle may not visit every member of the component.
+	if len(c.Members) > len(c.Path)-1 {
+		desc += fmt.Sprintf(" (%d %ss are mutually dependent)", len(c.Members), c.Level)
+	}
+	locations := make([]output.Location, len(c.Files))
+	for i, f := range c.Files {
+		locations[i] = output.Location{Path: f, Line: src.ImportLine(f, c.Imports[f])}
+	}
+	return output.Issue{
+		Type:        "circular_dependency",
+		Severity:    severity,
+		Description: desc,
+		Cycle:       c.Path,
+		Locations:   locations,
+	}
+}
+
+// DetectCycles reports each strongly connected component of the resolved
+// import graph once at file level and once per package (directory) level.
+// Components within a single package are file-level only.
+func DetectCycles(g *graph.Graph) []Cycle {
+	var cycles []Cycle
+	for _, scc := range g.StronglyConnected() {
+		path := g.ShortestCycle(scc)
+		members := make(map[string]bool, len(scc))
+		for _, f := range scc {
+			members[f] = true
+		}
+
+		// Each file closes the cycle through its import of the next file on
+		// the path, or else of any other member.
+		imports := make(map[string]string, len(scc))
+		for i := 0; i+1 < len(path); i++ {
+			imports[path[i]] = g.Import(path[i], path[i+1])
+		}
+		for _, f := range scc {
+			if _, ok := imports[f]; ok {
+				continue
+			}
+			for _, dst := range g.Edges[f] {
+				if members[dst] {
+					imports[f] = g.Import(f, dst)
+					break
+				}
+			}
+		}
+
+		cycles = append(cycles, Cycle{
+			Level:   "file",
+			Members: scc,
+			Path:    path,
+			Files:   scc,
+			Imports: imports,
+		})
+	}
+
+	pkgGraph := graph.Collapse(g, graph.PackageKey)
+	for _, scc := range pkgGraph.StronglyConnected() {
+		members := make(map[string]bool, len(scc))
+		for _, p := range scc {
+			members[p] = true
+		}
+
+		// The files closing the cycle are those importing another member package.
+		var files []string
+		imports := make(map[string]string)
+		for _, src := range sortedKeys(g.Edges) {
+			srcPkg := graph.PackageKey(src)
+			if !members[srcPkg] {
+				continue
+			}
+			for _, dst := range g.Edges[src] {
+				if dstPkg := graph.PackageKey(dst); dstPkg != srcPkg && members[dstPkg] {
+					files = append(files, src)
+					imports[src] = g.Import(src, dst)
+					break
+				}
+			}
+		}
+
+		cycles = append(cycles, Cycle{
+			Level:   "package",
+			Members: scc,
+			Path:    pkgGraph.ShortestCycle(scc),
+			Files:   files,
+			Imports: imports,
+		})
+	}
+	return cycles
+}
+
+// AttachCycles adds each cycle's issue to the files it involves, locating
+// the imports that close it in src.
+func AttachCycles(files []*output.FileNode, cycles []Cycle, src Sources) {
+	issues := make([]output.Issue, len(cycles))
+	for i, c := range cycles {
+		issues[i] = c.Issue(src)
+	}
+	AttachIssues(files, issues)
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -6,11 +6,17 @@ import (
 	"fmt"
 	"go/scanner"
 	"go/token"
+	"sort"
 	"strings"
 
 	"github.com/spanexx/agents-cli/repomap/internal/output"
 )
 
+// DuplicationVersion identifies the results of the detector. Bump it
+// whenever a change to the detector changes the issues it reports, so that
+// results cached by an older version are not reused.
+const DuplicationVersion = "1"
+
 // DuplicationDetector scans files for duplicate code blocks.
 type DuplicationDetector struct {
 	MinTokens int // Minimum tokens to consider a block
@@ -25,6 +31,12 @@ func NewDuplicationDetector() *DuplicationDetector {
 
 // Tokenize processes a file strictly for structural hashing (ignoring comments/whitespace).
 func (d *DuplicationDetector) Tokenize(content []byte) ([]string, error) {
+	tokens, _ := tokenize(content)
+	return tokens, nil
+}
+
+// tokenize returns the tokens of content and the line each starts on.
+func tokenize(content []byte) ([]string, []int) {
 	var s scanner.Scanner
 	fset := token.NewFileSet()
 	file := fset.AddFile("", fset.Base(), len(content))
@@ -32,8 +44,9 @@ func (d *DuplicationDetector) Tokenize(content []byte) ([]string, error) {
 	s.Init(file, content, nil, 0) // No comments
 
 	var tokens []string
+	var lines []int
 	for {
-		_, tok, lit := s.Scan()
+		pos, tok, lit := s.Scan()
 		if tok == token.EOF {
 			break
 		}
@@ -46,24 +59,26 @@ func (d *DuplicationDetector) Tokenize(content []byte) ([]string, error) {
 			lit = tok.String()
 		}
 		tokens = append(tokens, lit)
+		lines = append(lines, file.Line(pos))
 	}
-	return tokens, nil
+	return tokens, lines
 }
 
 // Analyze checks a set of files for duplicates.
 // This is a simplified approach: exact sliding window hash match across all files.
+// Each issue is located in every file involved, spanning the lines from its
+// first to its last duplicated window there.
 func (d *DuplicationDetector) Analyze(files map[string][]byte) ([]output.Issue, error) {
 	// 1. Tokenize all files
 	fileTokens := make(map[string][]string)
+	fileLines := make(map[string][]int)
 	for path, content := range files {
-		toks, err := d.Tokenize(content)
-		if err != nil {
-			continue // Skip unparseable
-		}
+		toks, lines := tokenize(content)
 		if len(toks) < d.MinTokens {
 			continue
 		}
 		fileTokens[path] = toks
+		fileLines[path] = lines
 	}
 
 	// 2. Build Hash Map
@@ -96,15 +111,14 @@ func (d *DuplicationDetector) Analyze(files map[string][]byte) ([]output.Issue,
 	// This is complex. For MVP, we'll just report "Found duplication involving X files".
 	// Or we report distinct blocks.
 
-	// Set of reported (File, Start) to avoid redundant noise
-	reported := make(map[string]bool)
+	// Issues by description, to avoid redundant noise, with the span of
+	// lines duplicated in each file.
+	reported := make(map[string]int)
+	var spans []map[string]output.Location
 
 	for _, occurrences := range blockMap {
 		if len(occurrences) > 1 {
 			// Found duplicate!
-			// Check if we already reported a block covering this
-			// (Simplification: just take first occurrence and report it if not overlaps drastically)
-
 			// Group by file to see cross-file vs intra-file
 			filesInvolved := make(map[string]bool)
 			for _, occ := range occurrences {
@@ -118,20 +132,51 @@ func (d *DuplicationDetector) Analyze(files map[string][]byte) ([]output.Issue,
 				for f := range filesInvolved {
 					paths = append(paths, f)
 				}
+				sort.Strings(paths)
 				desc += strings.Join(paths, ", ")
 
 				// De-duplicate the issue report string itself
-				if !reported[desc] {
+				i, ok := reported[desc]
+				if !ok {
+					i = len(issues)
 					issues = append(issues, output.Issue{
 						Type:        "duplication",
 						Severity:    "medium",
 						Description: desc,
 					})
-					reported[desc] = true
+					spans = append(spans, make(map[string]output.Location))
+					reported[desc] = i
+				}
+				for _, occ := range occurrences {
+					lines := fileLines[occ.File]
+					start, end := lines[occ.Start], lines[occ.Start+windowSize-1]
+					span, ok := spans[i][occ.File]
+					if !ok {
+						span = output.Location{Path: occ.File, Line: start, EndLine: end}
+					}
+					span.Line, span.EndLine = min(span.Line, start), max(span.EndLine, end)
+					spans[i][occ.File] = span
 				}
 			}
 		}
 	}
 
+	for i := range issues {
+		for _, path := range sortedLocationKeys(spans[i]) {
+			issues[i].Locations = append(issues[i].Locations, spans[i][path])
+		}
+	}
+
+	// Blocks are visited in map order; sort for a stable report.
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Description < issues[j].Description })
 	return issues, nil
 }
+
+func sortedLocationKeys(m map[string]output.Location) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,7 +1,11 @@
 package analysis
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
 )
 
 func TestDuplicationDetector(t *testing.T) {
@@ -54,6 +58,14 @@ func TestDuplicationDetector(t *testing.T) {
 		if issue.Type == "duplication" {
 			found = true
 			t.Logf("Found issue: %s", issue.Description)
+			if !strings.HasSuffix(issue.Description, ": file1.go, file2.go") {
+				t.Errorf("expected the files in sorted order: %s", issue.Description)
+			}
+			// The block runs from the function header to its closing brace.
+			want := []output.Location{{Path: "file1.go", Line: 4, EndLine: 9}, {Path: "file2.go", Line: 4, EndLine: 10}}
+			if !reflect.DeepEqual(issue.Locations, want) {
+				t.Errorf("expected the block located in both files, got %+v", issue.Locations)
+			}
 		}
 	}
 
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// Severities lists the issue severities from least to most severe.
+var Severities = []string{"low", "medium", "high"}
+
+// AtLeast reports whether severity is at or above threshold. Unknown
+// severities count as medium.
+func AtLeast(severity, threshold string) bool {
+	return severityLevel(severity) >= severityLevel(threshold)
+}
+
+func severityLevel(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return 1
+}
+
+// Sources holds file contents by path, used to locate issues in the files.
+type Sources map[string][]byte
+
+// ImportLine returns the line of the file at path on which imp is imported,
+// or 0 if the file or the import cannot be found. A line quoting the import
+// is preferred, as in Go, JavaScript or C, over one merely mentioning it, as
+// in Python or Rust.
+func (s Sources) ImportLine(path, imp string) int {
+	content, ok := s[path]
+	if !ok || imp == "" {
+		return 0
+	}
+	lines := strings.Split(string(content), "\n")
+	for _, quoted := range []string{`"` + imp + `"`, `'` + imp + `'`, "<" + imp + ">"} {
+		for i, line := range lines {
+			if strings.Contains(line, quoted) {
+				return i + 1
+			}
+		}
+	}
+	for i, line := range lines {
+		if strings.Contains(line, imp) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// AttachIssues adds each issue to the files it is located in.
+func AttachIssues(files []*output.FileNode, issues []output.Issue) {
+	byPath := make(map[string]*output.FileNode, len(files))
+	for _, f := range files {
+		byPath[f.Path] = f
+	}
+	for _, issue := range issues {
+		seen := make(map[string]bool)
+		for _, l := range issue.Locations {
+			if f, ok := byPath[l.Path]; ok && !seen[l.Path] {
+				f.Issues = append(f.Issues, issue)
+				seen[l.Path] = true
+			}
+		}
+	}
+}
+
+// CountAtLeast counts the issues of the files at or above threshold, an
+// issue involving several files once per file.
+func CountAtLeast(files []*output.FileNode, threshold string) int {
+	n := 0
+	for _, f := range files {
+		for _, issue := range f.Issues {
+			if AtLeast(issue.Severity, threshold) {
+				n++
+			}
+		}
+	}
+	return n
+}
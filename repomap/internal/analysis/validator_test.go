@@ -1,8 +1,10 @@
 package analysis
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
 	"github.com/spanexx/agents-cli/repomap/internal/output"
 )
 
@@ -11,8 +13,9 @@ func TestIntentValidator(t *testing.T) {
 
 	files := []*output.FileNode{
 		{
-			Path:    "internal/domain/user.go",
-			Intent:  "Domain Logic",
+			Path: "internal/domain/user.go",
+			// Matches both the core and the domain layer.
+			Intent:  "Core Domain Logic",
 			Imports: []string{"fmt", "github.com/project/internal/infrastructure/db"},
 		},
 		{
@@ -27,7 +30,8 @@ func TestIntentValidator(t *testing.T) {
 		},
 	}
 
-	issues := validator.Validate(files)
+	src := Sources{"internal/domain/user.go": []byte("package domain\n\nimport (\n\t\"fmt\"\n\t\"github.com/project/internal/infrastructure/db\"\n)\n")}
+	issues := validator.Validate(files, src)
 
 	if len(issues) != 1 {
 		t.Errorf("Expected 1 violation, got %d", len(issues))
@@ -38,32 +42,61 @@ func TestIntentValidator(t *testing.T) {
 			t.Errorf("Expected architectural_violation, got %s", issues[0].Type)
 		}
 		t.Logf("Found expected violation: %s", issues[0].Description)
+		if want := []output.Location{{Path: "internal/domain/user.go", Line: 5}}; !reflect.DeepEqual(issues[0].Locations, want) {
+			t.Errorf("expected the violation at the import, got %+v", issues[0].Locations)
+		}
 	}
 }
 
 func TestCycleDetection(t *testing.T) {
-	// A -> B -> A
-	files := []*output.FileNode{
-		{Path: "pkg/a/a.go", Imports: []string{"pkg/b"}},
-		{Path: "pkg/b/b.go", Imports: []string{"pkg/a"}},
+	// pkg/a/a.go -> pkg/b/b.go -> pkg/a/a.go, and pkg/b/b2.go -> pkg/a/a.go
+	g := &graph.Graph{
+		Edges: map[string][]string{
+			"pkg/a/a.go":  {"pkg/b/b.go", "pkg/b/b2.go"},
+			"pkg/b/b.go":  {"pkg/a/a.go"},
+			"pkg/b/b2.go": {"pkg/a/a.go"},
+			// An unrelated directory with the same suffix must not be involved.
+			"other/pkg/a/x.go": {"pkg/a/a.go"},
+		},
+		Via: map[string][]string{
+			"pkg/a/a.go":  {"example.com/pkg/b", "example.com/pkg/b"},
+			"pkg/b/b.go":  {"example.com/pkg/a"},
+			"pkg/b/b2.go": {"example.com/pkg/a"},
+		},
 	}
 
-	// We need to Mock BuildGraph logic?
-	// BuildGraph relies on mapping import paths to files.
-	// Our BuildGraph matches "pkg/b" to "pkg/b/b.go" if suffix matches.
+	cycles := DetectCycles(g)
+	if len(cycles) != 2 {
+		t.Fatalf("expected one file-level and one package-level cycle, got %d: %+v", len(cycles), cycles)
+	}
 
-	g := BuildGraph(files, "")
-	issues := g.DetectCycles()
+	file, pkg := cycles[0], cycles[1]
+	if file.Level != "file" || !reflect.DeepEqual(file.Path, []string{"pkg/a/a.go", "pkg/b/b.go", "pkg/a/a.go"}) {
+		t.Errorf("unexpected file cycle: %+v", file)
+	}
+	if !reflect.DeepEqual(file.Files, []string{"pkg/a/a.go", "pkg/b/b.go", "pkg/b/b2.go"}) {
+		t.Errorf("file cycle should involve every member, got %v", file.Files)
+	}
+	if pkg.Level != "package" || !reflect.DeepEqual(pkg.Path, []string{"pkg/a", "pkg/b", "pkg/a"}) {
+		t.Errorf("unexpected package cycle: %+v", pkg)
+	}
 
-	// Recursion might find multiple paths for the same cycle A->B->A and B->A->B
-	// But our DFS tracks visited per traversal.
-	// We should find at least one.
+	files := []*output.FileNode{{Path: "pkg/a/a.go"}, {Path: "pkg/b/b.go"}, {Path: "pkg/b/b2.go"}, {Path: "other/pkg/a/x.go"}}
+	src := Sources{"pkg/b/b.go": []byte("package b\n\nimport \"example.com/pkg/a\"\n")}
+	AttachCycles(files, cycles, src)
+	if len(files[0].Issues) != 2 || len(files[2].Issues) != 2 {
+		t.Errorf("expected both cycles attached to members, got %+v and %+v", files[0].Issues, files[2].Issues)
+	}
+	if len(files[3].Issues) != 0 {
+		t.Errorf("file outside the cycle got issues: %+v", files[3].Issues)
+	}
 
-	if len(issues) == 0 {
-		t.Errorf("Expected circular dependency, got 0 issues")
+	if loc, ok := files[1].Issues[0].In("pkg/b/b.go"); !ok || loc.Line != 3 {
+		t.Errorf("expected the cycle located at the import in pkg/b/b.go, got %+v", files[1].Issues[0].Locations)
 	}
 
-	for _, i := range issues {
-		t.Logf("Found cycle: %s", i.Description)
+	issue := file.Issue(nil)
+	if issue.Type != "circular_dependency" || issue.Description != "Circular dependency detected: pkg/a/a.go -> pkg/b/b.go -> pkg/a/a.go (3 files are mutually dependent)" {
+		t.Errorf("unexpected issue: %+v", issue)
 	}
 }
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// Dir is where the cache is kept, relative to the repository root.
+const Dir = ".repomap/cache"
+
+// Cache stores results by kind and key. A nil Cache stores nothing, so
+// callers need not check whether caching is enabled.
+type Cache struct {
+	dir          string
+	hits, misses atomic.Int64
+}
+
+// Open returns the cache of the repository at root. Nothing is created
+// until an entry is stored.
+func Open(root string) *Cache {
+	return &Cache{dir: filepath.Join(root, filepath.FromSlash(Dir))}
+}
+
+// Key returns the key of a result derived from parts, such as a file
+// extension and content, by code at version.
+func Key(version string, parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range append([][]byte{[]byte(version)}, parts...) {
+		// Lengths keep ("ab", "c") and ("a", "bc") apart.
+		h.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(p))))
+		h.Write(p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(kind, key string) string {
+	return filepath.Join(c.dir, kind, key[:2], key+".json")
+}
+
+// Get decodes the entry of kind and key into v and reports whether it was
+// found.
+func (c *Cache) Get(kind, key string, v any) bool {
+	if c == nil {
+		return false
+	}
+	data, err := os.ReadFile(c.path(kind, key))
+	if err != nil || json.Unmarshal(data, v) != nil {
+		c.misses.Add(1)
+		return false
+	}
+	c.hits.Add(1)
+	return true
+}
+
+// Put stores v as the entry of kind and key, replacing it atomically.
+func (c *Cache) Put(kind, key string, v any) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	path := c.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	c.ignore()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+key+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ignore keeps the cache out of version control.
+func (c *Cache) ignore() {
+	path := filepath.Join(c.dir, ".gitignore")
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		os.WriteFile(path, []byte("*\n"), 0644)
+	}
+}
+
+// Counts returns the number of lookups that hit and missed so far.
+func (c *Cache) Counts() (hits, misses int64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Stats describes the entries stored for one kind of result.
+type Stats struct {
+	Kind    string
+	Entries int
+	Bytes   int64
+}
+
+// Stats returns the entries stored per kind, sorted by kind.
+func (c *Cache) Stats() ([]Stats, error) {
+	kinds := make(map[string]*Stats)
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" || d.Name()[0] == '.' {
+			return nil
+		}
+		rel, _ := filepath.Rel(c.dir, path)
+		kind := filepath.ToSlash(filepath.Dir(filepath.Dir(rel)))
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed meanwhile
+		}
+		s, ok := kinds[kind]
+		if !ok {
+			s = &Stats{Kind: kind}
+			kinds[kind] = s
+		}
+		s.Entries++
+		s.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]Stats, 0, len(kinds))
+	for _, s := range kinds {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Kind < stats[j].Kind })
+	return stats, nil
+}
+
+// Clear removes every entry.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+// Path returns the directory of the cache.
+func (c *Cache) Path() string {
+	return c.dir
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type entry struct {
+	Names []string `json:"names"`
+}
+
+func TestGetPut(t *testing.T) {
+	root := t.TempDir()
+	c := Open(root)
+	key := Key("1", []byte(".go"), []byte("package a"))
+
+	var got entry
+	if c.Get("parse", key, &got) {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	want := entry{Names: []string{"A", "B"}}
+	if err := c.Put("parse", key, want); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if !c.Get("parse", key, &got) || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v back, got %+v", want, got)
+	}
+	if hits, misses := c.Counts(); hits != 1 || misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %d and %d", hits, misses)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, Dir, ".gitignore")); err != nil || string(data) != "*\n" {
+		t.Errorf("expected the cache to ignore itself, got %q (%v)", data, err)
+	}
+
+	// Another kind, content or version is another entry.
+	for _, other := range []struct{ kind, key string }{
+		{"outline", key},
+		{"parse", Key("1", []byte(".go"), []byte("package b"))},
+		{"parse", Key("2", []byte(".go"), []byte("package a"))},
+		{"parse", Key("1", []byte(".g"), []byte("opackage a"))},
+	} {
+		if c.Get(other.kind, other.key, &got) {
+			t.Errorf("expected a miss for %s/%s", other.kind, other.key)
+		}
+	}
+}
+
+func TestCorruptEntry(t *testing.T) {
+	c := Open(t.TempDir())
+	key := Key("1", []byte("x"))
+	if err := c.Put("parse", key, entry{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.path("parse", key), []byte(`{"names": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got entry
+	if c.Get("parse", key, &got) {
+		t.Error("expected a truncated entry to miss")
+	}
+}
+
+func TestStatsClear(t *testing.T) {
+	c := Open(t.TempDir())
+	if stats, err := c.Stats(); err != nil || len(stats) != 0 {
+		t.Fatalf("expected no stats before anything is stored, got %+v (%v)", stats, err)
+	}
+	for i, kind := range []string{"parse", "parse", "outline"} {
+		if err := c.Put(kind, Key("1", []byte{byte(i)}), entry{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Kind != "outline" || stats[0].Entries != 1 || stats[1].Kind != "parse" || stats[1].Entries != 2 || stats[1].Bytes == 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.Path()); !os.IsNotExist(err) {
+		t.Errorf("expected the cache to be removed, got %v", err)
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	var got entry
+	if err := c.Put("parse", Key("1"), entry{}); err != nil || c.Get("parse", Key("1"), &got) {
+		t.Error("expected a nil cache to store nothing")
+	}
+	if hits, misses := c.Counts(); hits != 0 || misses != 0 {
+		t.Errorf("expected no lookups, got %d and %d", hits, misses)
+	}
+}
+
+func TestConcurrentPut(t *testing.T) {
+	root := t.TempDir()
+	key := Key("1", []byte("shared"))
+	want := entry{Names: []string{"A"}}
+
+	// Separate caches stand in for separate processes.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := Open(root)
+			for j := 0; j < 20; j++ {
+				if err := c.Put("parse", key, want); err != nil {
+					t.Errorf("Put failed: %v", err)
+				}
+				var got entry
+				if c.Get("parse", key, &got) && !reflect.DeepEqual(got, want) {
+					t.Errorf("read a partial entry: %+v", got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	files, err := os.ReadDir(filepath.Dir(Open(root).path("parse", key)))
+	if err != nil || len(files) != 1 {
+		t.Errorf("expected a single entry without leftover temporary files, got %v (%v)", files, err)
+	}
+}
@@ -0,0 +1,12 @@
+/*
+Package cache keeps the results of parsing and analyzing files between runs.
+
+Entries are content-addressed: the key of an entry is the SHA-256 of the
+content it was derived from and the version of the code that derived it, so
+a changed file or a new extractor simply misses and stale entries are never
+read. Entries are JSON files under .repomap/cache in the repository, one
+directory per kind of result. They are written to a temporary file and
+renamed into place, so concurrent processes only ever see whole entries, and
+an entry that cannot be decoded counts as a miss.
+*/
+package cache
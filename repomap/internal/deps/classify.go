@@ -0,0 +1,219 @@
+package deps
+
+import (
+	"path"
+	"strings"
+)
+
+// Kind classifies an import by where the imported code lives.
+type Kind int
+
+const (
+	// Local code lives in the repository.
+	Local Kind = iota
+	// Stdlib code ships with the language toolchain or runtime.
+	Stdlib
+	// ThirdParty code is fetched from a package registry.
+	ThirdParty
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Stdlib:
+		return "stdlib"
+	case ThirdParty:
+		return "third-party"
+	}
+	return "local"
+}
+
+// Ecosystems name the package registries a third-party module comes from.
+const (
+	EcosystemGo     = "go"
+	EcosystemNPM    = "npm"
+	EcosystemCargo  = "cargo"
+	EcosystemPyPI   = "pypi"
+	EcosystemMaven  = "maven"
+	EcosystemSystem = "system"
+)
+
+// Import is a classified import. Module and Ecosystem are set for
+// third-party imports only.
+type Import struct {
+	Kind      Kind
+	Module    string
+	Ecosystem string
+}
+
+// Classify decides whether an import found in file src is local, standard
+// library or third-party, based on the importing file's language. It is meant
+// for imports the graph builder could not resolve to repository files.
+// knownGo lists Go module paths declared in go.mod files; the longest match
+// names the module of a Go import.
+func Classify(src, imp string, knownGo []string) Import {
+	switch strings.ToLower(path.Ext(src)) {
+	case ".go":
+		return classifyGo(imp, knownGo)
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return classifyJS(imp)
+	case ".py":
+		return classifyPython(imp)
+	case ".rs":
+		return classifyRust(imp)
+	case ".java":
+		return classifyJava(imp)
+	case ".c", ".h", ".cc", ".cpp", ".hpp":
+		return classifyC(imp)
+	}
+	return Import{Kind: Local}
+}
+
+func classifyGo(imp string, knownGo []string) Import {
+	first, _, _ := strings.Cut(imp, "/")
+	// The standard library never has a dot in its first path element.
+	if !strings.Contains(first, ".") {
+		return Import{Kind: Stdlib}
+	}
+
+	best := ""
+	for _, mod := range knownGo {
+		if (imp == mod || strings.HasPrefix(imp, mod+"/")) && len(mod) > len(best) {
+			best = mod
+		}
+	}
+	if best == "" {
+		best = goModuleGuess(imp)
+	}
+	return Import{Kind: ThirdParty, Module: best, Ecosystem: EcosystemGo}
+}
+
+// goModuleGuess derives a module path from an import path on well-known
+// hosts, where modules sit at a fixed depth.
+func goModuleGuess(imp string) string {
+	parts := strings.Split(imp, "/")
+	depth := len(parts)
+	switch parts[0] {
+	case "github.com", "gitlab.com", "bitbucket.org", "golang.org", "go.googlesource.com":
+		depth = 3
+	case "gopkg.in":
+		depth = 2
+	}
+	if depth < len(parts) {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+func classifyJS(imp string) Import {
+	if strings.HasPrefix(imp, ".") || strings.HasPrefix(imp, "/") {
+		return Import{Kind: Local}
+	}
+	if strings.HasPrefix(imp, "node:") || nodeBuiltins[strings.SplitN(imp, "/", 2)[0]] {
+		return Import{Kind: Stdlib}
+	}
+	return Import{Kind: ThirdParty, Module: npmPackageName(imp), Ecosystem: EcosystemNPM}
+}
+
+// npmPackageName strips a subpath from a bare specifier:
+// "@scope/pkg/sub" -> "@scope/pkg", "lodash/fp" -> "lodash".
+func npmPackageName(imp string) string {
+	parts := strings.Split(imp, "/")
+	if strings.HasPrefix(imp, "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+func classifyPython(imp string) Import {
+	if strings.HasPrefix(imp, ".") {
+		return Import{Kind: Local}
+	}
+	top, _, _ := strings.Cut(imp, ".")
+	if pythonStdlib[top] {
+		return Import{Kind: Stdlib}
+	}
+	return Import{Kind: ThirdParty, Module: top, Ecosystem: EcosystemPyPI}
+}
+
+func classifyRust(imp string) Import {
+	crate, _, _ := strings.Cut(imp, "::")
+	switch crate {
+	case "crate", "self", "super":
+		return Import{Kind: Local}
+	case "std", "core", "alloc", "proc_macro", "test":
+		return Import{Kind: Stdlib}
+	}
+	return Import{Kind: ThirdParty, Module: crate, Ecosystem: EcosystemCargo}
+}
+
+func classifyJava(imp string) Import {
+	parts := strings.Split(imp, ".")
+	switch parts[0] {
+	case "java", "javax", "jdk", "sun":
+		return Import{Kind: Stdlib}
+	}
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return Import{Kind: ThirdParty, Module: strings.Join(parts, "."), Ecosystem: EcosystemMaven}
+}
+
+func classifyC(imp string) Import {
+	header, system := strings.CutPrefix(imp, "<")
+	if !system {
+		return Import{Kind: Local}
+	}
+	header = strings.TrimSuffix(header, ">")
+	if cStdlib[header] || !strings.Contains(header, "/") && !strings.Contains(header, ".") {
+		// <stdio.h> and C++ headers such as <vector>.
+		return Import{Kind: Stdlib}
+	}
+	lib, _, _ := strings.Cut(header, "/")
+	return Import{Kind: ThirdParty, Module: strings.TrimSuffix(lib, ".h"), Ecosystem: EcosystemSystem}
+}
+
+var nodeBuiltins = setOf(
+	"assert", "async_hooks", "buffer", "child_process", "cluster", "console",
+	"constants", "crypto", "dgram", "diagnostics_channel", "dns", "domain",
+	"events", "fs", "http", "http2", "https", "inspector", "module", "net",
+	"os", "path", "perf_hooks", "process", "punycode", "querystring",
+	"readline", "repl", "stream", "string_decoder", "sys", "timers", "tls",
+	"trace_events", "tty", "url", "util", "v8", "vm", "wasi", "worker_threads",
+	"zlib",
+)
+
+var pythonStdlib = setOf(
+	"__future__", "abc", "argparse", "array", "ast", "asyncio", "base64",
+	"binascii", "bisect", "builtins", "bz2", "calendar", "cmath", "codecs",
+	"collections", "concurrent", "configparser", "contextlib", "contextvars",
+	"copy", "csv", "ctypes", "dataclasses", "datetime", "decimal", "difflib",
+	"dis", "email", "enum", "errno", "fnmatch", "fractions", "functools", "gc",
+	"getpass", "gettext", "glob", "gzip", "hashlib", "heapq", "hmac", "html",
+	"http", "importlib", "inspect", "io", "ipaddress", "itertools", "json",
+	"logging", "lzma", "math", "mimetypes", "multiprocessing", "numbers",
+	"operator", "os", "pathlib", "pickle", "platform", "pprint", "queue",
+	"random", "re", "secrets", "select", "selectors", "shlex", "shutil",
+	"signal", "socket", "sqlite3", "ssl", "stat", "statistics", "string",
+	"struct", "subprocess", "sys", "tarfile", "tempfile", "textwrap",
+	"threading", "time", "timeit", "tkinter", "token", "tokenize", "traceback",
+	"types", "typing", "unicodedata", "unittest", "urllib", "uuid", "venv",
+	"warnings", "weakref", "xml", "zipfile", "zlib", "zoneinfo",
+)
+
+var cStdlib = setOf(
+	"assert.h", "complex.h", "ctype.h", "errno.h", "fenv.h", "float.h",
+	"inttypes.h", "limits.h", "locale.h", "math.h", "setjmp.h", "signal.h",
+	"stdalign.h", "stdarg.h", "stdatomic.h", "stdbool.h", "stddef.h",
+	"stdint.h", "stdio.h", "stdlib.h", "string.h", "threads.h", "time.h",
+	"uchar.h", "wchar.h", "wctype.h", "unistd.h", "fcntl.h", "pthread.h",
+	"sys/types.h", "sys/stat.h", "sys/socket.h", "sys/time.h", "sys/wait.h",
+	"netinet/in.h", "arpa/inet.h", "dirent.h", "dlfcn.h",
+)
+
+func setOf(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
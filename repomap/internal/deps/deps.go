@@ -0,0 +1,63 @@
+package deps
+
+import (
+	"sort"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// Collect groups third-party imports into one dependency per module.
+// external maps a source file to the imports the graph builder could not
+// resolve to repository files (graph.Graph.External); standard library
+// imports among them are dropped. Dependencies are sorted by the number of
+// importing files, most used first, then by name.
+func Collect(external map[string][]string, m *Manifests) []*output.Dependency {
+	knownGo := m.GoModules()
+	byModule := make(map[string]*output.Dependency)
+	seenFile := make(map[string]map[string]bool)
+
+	srcs := make([]string, 0, len(external))
+	for src := range external {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+
+	for _, src := range srcs {
+		for _, imp := range external[src] {
+			c := Classify(src, imp, knownGo)
+			if c.Kind != ThirdParty {
+				continue
+			}
+			key := c.Ecosystem + ":" + c.Module
+			dep, ok := byModule[key]
+			if !ok {
+				dep = &output.Dependency{
+					Name:      c.Module,
+					Ecosystem: c.Ecosystem,
+					Version:   m.Version(c.Ecosystem, c.Module),
+				}
+				byModule[key] = dep
+				seenFile[key] = make(map[string]bool)
+			}
+			if !seenFile[key][src] {
+				seenFile[key][src] = true
+				dep.Files = append(dep.Files, src)
+			}
+		}
+	}
+
+	deps := make([]*output.Dependency, 0, len(byModule))
+	for _, dep := range byModule {
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if len(deps[i].Files) != len(deps[j].Files) {
+			return len(deps[i].Files) > len(deps[j].Files)
+		}
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return deps[i].Ecosystem < deps[j].Ecosystem
+	})
+	return deps
+}
@@ -0,0 +1,162 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles writes the files under root and returns their paths.
+func writeFiles(t *testing.T, root string, files map[string]string) []string {
+	t.Helper()
+	var paths []string
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func TestClassify(t *testing.T) {
+	knownGo := []string{"github.com/google/uuid", "google.golang.org/genai"}
+	tests := []struct {
+		src, imp string
+		want     Import
+	}{
+		{"main.go", "fmt", Import{Kind: Stdlib}},
+		{"main.go", "net/http", Import{Kind: Stdlib}},
+		{"main.go", "github.com/google/uuid", Import{ThirdParty, "github.com/google/uuid", EcosystemGo}},
+		{"main.go", "google.golang.org/genai/types", Import{ThirdParty, "google.golang.org/genai", EcosystemGo}},
+		{"main.go", "github.com/spf13/cobra/doc", Import{ThirdParty, "github.com/spf13/cobra", EcosystemGo}},
+		{"web/app.ts", "react-dom/client", Import{ThirdParty, "react-dom", EcosystemNPM}},
+		{"web/app.ts", "@tanstack/react-query", Import{ThirdParty, "@tanstack/react-query", EcosystemNPM}},
+		{"web/app.ts", "node:fs", Import{Kind: Stdlib}},
+		{"web/app.ts", "path", Import{Kind: Stdlib}},
+		{"web/app.ts", "./styles.css", Import{Kind: Local}},
+		{"tools/gen.py", "os.path", Import{Kind: Stdlib}},
+		{"tools/gen.py", "requests.adapters", Import{ThirdParty, "requests", EcosystemPyPI}},
+		{"src/lib.rs", "std::collections", Import{Kind: Stdlib}},
+		{"src/lib.rs", "serde::Deserialize", Import{ThirdParty, "serde", EcosystemCargo}},
+		{"src/App.java", "java.util.List", Import{Kind: Stdlib}},
+		{"src/App.java", "org.slf4j.Logger", Import{ThirdParty, "org.slf4j", EcosystemMaven}},
+		{"src/main.c", "<stdio.h>", Import{Kind: Stdlib}},
+		{"src/main.cpp", "<vector>", Import{Kind: Stdlib}},
+		{"src/main.c", "<openssl/ssl.h>", Import{ThirdParty, "openssl", EcosystemSystem}},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.src, tt.imp, knownGo); got != tt.want {
+			t.Errorf("Classify(%q, %q) = %+v, want %+v", tt.src, tt.imp, got, tt.want)
+		}
+	}
+}
+
+func TestManifestsFromFiles(t *testing.T) {
+	root := t.TempDir()
+	files := writeFiles(t, root, map[string]string{
+		"go.mod": "module example.com/app\n\nrequire github.com/google/uuid v1.6.0\n",
+		"go.sum": "github.com/google/uuid v1.6.0 h1:x=\ngolang.org/x/sync v0.7.0/go.mod h1:y=\n",
+		"web/package.json": `{
+  "dependencies": {"react": "^18.2.0", "left-pad": "^1.0.0"},
+  "devDependencies": {"@types/node": "^20.0.0"}
+}`,
+		"web/package-lock.json": `{"lockfileVersion": 3, "packages": {
+  "": {"name": "web"},
+  "node_modules/react": {"version": "18.3.1"},
+  "node_modules/react/node_modules/loose-envify": {"version": "9.9.9"}
+}}`,
+		"other/yarn.lock": `# yarn lockfile v1
+
+"@types/node@^20.0.0", "@types/node@^20.1.0":
+  version "20.11.5"
+  resolved "https://registry.yarnpkg.com/@types/node/-/node-20.11.5.tgz"
+`,
+		"pnpm/pnpm-lock.yaml": `lockfileVersion: '9.0'
+
+packages:
+
+  left-pad@1.3.0:
+    resolution: {integrity: sha512-x}
+
+  '@babel/core@7.24.0(supports-color@8.1.1)':
+    resolution: {integrity: sha512-y}
+`,
+		"crates/core/Cargo.toml": `[package]
+name = "core"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+tokio-util = "0.7"
+
+[dependencies.rand]
+version = "0.8.5"
+`,
+		"Cargo.lock": `[[package]]
+name = "serde"
+version = "1.0.197"
+`,
+		"node_modules/ignored/package.json": `{"dependencies": {"never": "1.0.0"}}`,
+	})
+
+	m := ManifestsFromFiles(root, files)
+	tests := []struct {
+		ecosystem, name, want string
+	}{
+		{EcosystemGo, "github.com/google/uuid", "v1.6.0"},
+		{EcosystemGo, "golang.org/x/sync", "v0.7.0"},
+		{EcosystemNPM, "react", "18.3.1"},
+		{EcosystemNPM, "loose-envify", ""},
+		{EcosystemNPM, "@types/node", "20.11.5"},
+		{EcosystemNPM, "left-pad", "1.3.0"},
+		{EcosystemNPM, "@babel/core", "7.24.0"},
+		{EcosystemNPM, "never", ""},
+		{EcosystemCargo, "serde", "1.0.197"},
+		{EcosystemCargo, "tokio_util", "0.7"},
+		{EcosystemCargo, "rand", "0.8.5"},
+	}
+	for _, tt := range tests {
+		if got := m.Version(tt.ecosystem, tt.name); got != tt.want {
+			t.Errorf("Version(%s, %s) = %q, want %q", tt.ecosystem, tt.name, got, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(m.GoModules(), []string{"github.com/google/uuid", "golang.org/x/sync"}) {
+		t.Errorf("unexpected Go modules: %v", m.GoModules())
+	}
+}
+
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	files := writeFiles(t, root, map[string]string{
+		"go.mod": "module example.com/app\n\nrequire github.com/google/uuid v1.6.0\n",
+	})
+
+	external := map[string][]string{
+		"a.go":          {"fmt", "github.com/google/uuid"},
+		"b.go":          {"github.com/google/uuid", "github.com/google/uuid"},
+		"c.go":          {"github.com/heavy/lib/sub"},
+		"web/main.ts":   {"react", "react/jsx-runtime", "node:path"},
+		"web/style.tsx": {"./style.css"},
+	}
+
+	deps := Collect(external, ManifestsFromFiles(root, files))
+	if len(deps) != 3 {
+		t.Fatalf("expected 3 dependencies, got %d: %+v", len(deps), deps)
+	}
+
+	uuid := deps[0]
+	if uuid.Name != "github.com/google/uuid" || uuid.Version != "v1.6.0" || !reflect.DeepEqual(uuid.Files, []string{"a.go", "b.go"}) {
+		t.Errorf("unexpected first dependency: %+v", uuid)
+	}
+	if deps[1].Name != "github.com/heavy/lib" || deps[1].Version != "" || !reflect.DeepEqual(deps[1].Files, []string{"c.go"}) {
+		t.Errorf("unexpected second dependency: %+v", deps[1])
+	}
+	if deps[2].Name != "react" || deps[2].Ecosystem != EcosystemNPM || len(deps[2].Files) != 1 {
+		t.Errorf("unexpected third dependency: %+v", deps[2])
+	}
+}
@@ -0,0 +1,10 @@
+/*
+Package deps classifies imports and describes the third-party modules a
+repository depends on.
+
+It includes:
+- Per-language classification of imports as local, standard library or third-party.
+- Readers for go.mod/go.sum, package.json with npm, yarn and pnpm lockfiles, and Cargo.toml/Cargo.lock.
+- Aggregation of third-party imports into module nodes with versions and importing files.
+*/
+package deps
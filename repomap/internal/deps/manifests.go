@@ -0,0 +1,377 @@
+package deps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/modules"
+)
+
+// Manifests holds the versions of third-party modules declared in a
+// repository, keyed by ecosystem and module name. Exact versions from
+// lockfiles (and go.mod, whose requirements are what the build selects)
+// take precedence over ranges from manifests (and go.sum). When several
+// files name the same module, the one closest to the root wins.
+type Manifests struct {
+	locked   map[string]map[string]string
+	declared map[string]map[string]string
+}
+
+// manifestReaders maps a file name to its parser and whether it records
+// exact (locked) versions.
+var manifestReaders = map[string]struct {
+	ecosystem string
+	locked    bool
+	parse     func([]byte) map[string]string
+}{
+	"go.mod":            {EcosystemGo, true, modules.ParseRequires},
+	"go.sum":            {EcosystemGo, false, modules.ParseGoSum},
+	"package.json":      {EcosystemNPM, false, parsePackageJSON},
+	"package-lock.json": {EcosystemNPM, true, parsePackageLock},
+	"yarn.lock":         {EcosystemNPM, true, parseYarnLock},
+	"pnpm-lock.yaml":    {EcosystemNPM, true, parsePnpmLock},
+	"Cargo.toml":        {EcosystemCargo, false, parseCargoToml},
+	"Cargo.lock":        {EcosystemCargo, true, parseCargoLock},
+}
+
+// ManifestsFromFiles reads the known manifests and lockfiles among the files
+// found under root. Files in hidden directories and dependency or build
+// caches (node_modules, vendor, target, testdata) are ignored, as are
+// unreadable or malformed ones.
+func ManifestsFromFiles(root string, files []string) *Manifests {
+	m := &Manifests{
+		locked:   make(map[string]map[string]string),
+		declared: make(map[string]map[string]string),
+	}
+
+	var found []string
+	for _, p := range files {
+		if _, ok := manifestReaders[filepath.Base(p)]; !ok {
+			continue
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !ignoredPath(filepath.ToSlash(rel)) {
+			found = append(found, p)
+		}
+	}
+
+	// Shallow files first, so the root manifest wins over nested ones;
+	// files at the same depth in path order.
+	sort.Slice(found, func(i, j int) bool {
+		di, dj := strings.Count(found[i], string(filepath.Separator)), strings.Count(found[j], string(filepath.Separator))
+		if di != dj {
+			return di < dj
+		}
+		return found[i] < found[j]
+	})
+
+	for _, p := range found {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		reader := manifestReaders[filepath.Base(p)]
+		target := m.declared
+		if reader.locked {
+			target = m.locked
+		}
+		if target[reader.ecosystem] == nil {
+			target[reader.ecosystem] = make(map[string]string)
+		}
+		for name, version := range reader.parse(data) {
+			if reader.ecosystem == EcosystemCargo {
+				name = cargoCrateName(name)
+			}
+			if _, ok := target[reader.ecosystem][name]; !ok && version != "" {
+				target[reader.ecosystem][name] = version
+			}
+		}
+	}
+	return m
+}
+
+// ignoredDir reports whether directories named name are left out when
+// looking for manifests.
+func ignoredDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" ||
+		name == "vendor" || name == "target" || name == "testdata"
+}
+
+// ignoredPath reports whether the root-relative directory dir lies in an
+// ignored directory.
+func ignoredPath(dir string) bool {
+	if dir == "." {
+		return false
+	}
+	for _, name := range strings.Split(dir, "/") {
+		if ignoredDir(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the version recorded for a module, or "" when unknown.
+func (m *Manifests) Version(ecosystem, name string) string {
+	if ecosystem == EcosystemCargo {
+		name = cargoCrateName(name)
+	}
+	if v := m.locked[ecosystem][name]; v != "" {
+		return v
+	}
+	return m.declared[ecosystem][name]
+}
+
+// GoModules returns the Go module paths named in go.mod and go.sum files,
+// sorted, for use with Classify.
+func (m *Manifests) GoModules() []string {
+	seen := make(map[string]bool)
+	for _, versions := range []map[string]string{m.locked[EcosystemGo], m.declared[EcosystemGo]} {
+		for name := range versions {
+			seen[name] = true
+		}
+	}
+	mods := make([]string, 0, len(seen))
+	for name := range seen {
+		mods = append(mods, name)
+	}
+	sort.Strings(mods)
+	return mods
+}
+
+// cargoCrateName normalizes a package name to the name used in `use` paths.
+func cargoCrateName(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// parsePackageJSON returns the version ranges of every dependency kind.
+func parsePackageJSON(data []byte) map[string]string {
+	var pkg map[string]json.RawMessage
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	versions := make(map[string]string)
+	for _, field := range []string{"dependencies", "devDependencies", "peerDependencies", "optionalDependencies"} {
+		var deps map[string]string
+		if json.Unmarshal(pkg[field], &deps) != nil {
+			continue
+		}
+		for name, spec := range deps {
+			if _, ok := versions[name]; !ok {
+				versions[name] = spec
+			}
+		}
+	}
+	return versions
+}
+
+// parsePackageLock reads top-level packages from package-lock.json, using the
+// "packages" map (lockfile v2/v3) or the "dependencies" map (v1).
+func parsePackageLock(data []byte) map[string]string {
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if json.Unmarshal(data, &lock) != nil {
+		return nil
+	}
+	versions := make(map[string]string)
+	for key, pkg := range lock.Packages {
+		name, ok := strings.CutPrefix(key, "node_modules/")
+		if !ok || strings.Contains(name, "/node_modules/") {
+			continue
+		}
+		versions[name] = pkg.Version
+	}
+	for name, dep := range lock.Dependencies {
+		if _, ok := versions[name]; !ok {
+			versions[name] = dep.Version
+		}
+	}
+	return versions
+}
+
+// parseYarnLock reads yarn.lock in both the classic and the berry (YAML)
+// format. An entry header lists the specifiers it satisfies, e.g.
+// `"@scope/pkg@^1.0.0", "@scope/pkg@npm:^1.1.0":`, followed by an indented
+// version line.
+func parseYarnLock(data []byte) map[string]string {
+	versions := make(map[string]string)
+	var names []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			names = names[:0]
+			for _, spec := range strings.Split(strings.TrimSuffix(line, ":"), ",") {
+				if name := specName(strings.Trim(strings.TrimSpace(spec), `"`)); name != "" {
+					names = append(names, name)
+				}
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(trimmed, "version"); ok && len(names) > 0 {
+			version := strings.Trim(strings.TrimSpace(strings.TrimPrefix(rest, ":")), `"`)
+			for _, name := range names {
+				if _, ok := versions[name]; !ok {
+					versions[name] = version
+				}
+			}
+			names = names[:0]
+		}
+	}
+	return versions
+}
+
+// specName returns the package name of "name@range", keeping a scope's "@".
+func specName(spec string) string {
+	at := strings.LastIndex(spec, "@")
+	if at <= 0 {
+		return ""
+	}
+	return spec[:at]
+}
+
+// parsePnpmLock reads the keys of the "packages" section of pnpm-lock.yaml,
+// which look like "/name@1.2.3" (v6) or "name@1.2.3(peer@2.0.0)" (v9).
+func parsePnpmLock(data []byte) map[string]string {
+	versions := make(map[string]string)
+	inPackages := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			inPackages = strings.TrimSpace(line) == "packages:"
+			continue
+		}
+		// Package keys are indented by exactly two spaces.
+		if !inPackages || strings.HasPrefix(line, "   ") {
+			continue
+		}
+		key := strings.Trim(strings.TrimSuffix(strings.TrimSpace(line), ":"), `'"`)
+		key = strings.TrimPrefix(key, "/")
+		if i := strings.Index(key, "("); i != -1 {
+			key = key[:i]
+		}
+		if name := specName(key); name != "" {
+			if _, ok := versions[name]; !ok {
+				versions[name] = key[len(name)+1:]
+			}
+		}
+	}
+	return versions
+}
+
+// parseCargoToml reads dependency tables: [dependencies], [dev-dependencies],
+// [build-dependencies], their target-specific and workspace forms, and
+// [dependencies.name] subtables. Both `name = "1.0"` and
+// `name = { version = "1.0" }` are understood.
+func parseCargoToml(data []byte) map[string]string {
+	versions := make(map[string]string)
+	section, subtable := "", ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i != -1 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			header := strings.Trim(line, "[]")
+			section, subtable = "", ""
+			for _, table := range []string{"dependencies", "dev-dependencies", "build-dependencies"} {
+				if header == table || strings.HasSuffix(header, "."+table) {
+					section = table
+				} else if i := strings.Index(header, table+"."); i != -1 && (i == 0 || header[i-1] == '.') {
+					subtable = header[i+len(table)+1:]
+				}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch {
+		case subtable != "":
+			if key == "version" {
+				versions[subtable] = strings.Trim(value, `"'`)
+			}
+		case section != "":
+			if strings.HasPrefix(value, "{") {
+				value = inlineTableValue(value, "version")
+			}
+			versions[key] = strings.Trim(value, `"'`)
+		}
+	}
+	return versions
+}
+
+// inlineTableValue returns the value of key in a TOML inline table.
+func inlineTableValue(table, key string) string {
+	for _, field := range strings.Split(strings.Trim(table, "{}"), ",") {
+		k, v, ok := strings.Cut(field, "=")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// parseCargoLock reads [[package]] entries from Cargo.lock. When a crate is
+// locked at several versions, the first is kept.
+func parseCargoLock(data []byte) map[string]string {
+	versions := make(map[string]string)
+	name := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "[[package]]" {
+			name = ""
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.TrimSpace(key) {
+		case "name":
+			name = value
+		case "version":
+			if _, seen := versions[name]; name != "" && !seen {
+				versions[name] = value
+			}
+		}
+	}
+	return versions
+}
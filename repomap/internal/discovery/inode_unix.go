@@ -0,0 +1,23 @@
+//go:build !windows
+
+package discovery
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a directory by device and inode so that symlink cycles
+// can be detected regardless of the path used to reach it.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+func idOf(path string, info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
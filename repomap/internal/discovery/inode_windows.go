@@ -0,0 +1,22 @@
+//go:build windows
+
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// fileID identifies a directory by its fully resolved path, as device/inode
+// numbers are not exposed through os.FileInfo on Windows.
+type fileID struct {
+	path string
+}
+
+func idOf(path string, info os.FileInfo) (fileID, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	return fileID{path: resolved}, true
+}
@@ -8,58 +8,214 @@ import (
 	"github.com/spanexx/agents-cli/repomap/internal/parsing"
 )
 
+// DefaultSkipDirs is the directory skip list used when none is configured.
+// Patterns are matched against directory names; ".*" covers hidden directories
+// other than .github, whose workflows and tooling belong in a map.
+var DefaultSkipDirs = []string{".*", "!.github", "node_modules", "vendor", "dist", "build"}
+
+// Options controls how Walk traverses the directory tree.
+type Options struct {
+	// SkipDirs lists glob patterns for directories that should not be walked.
+	// Patterns without a slash match the directory name, patterns with a slash
+	// match the path relative to root. A leading "!" re-includes a directory
+	// skipped by an earlier pattern (e.g. ".*", "!.github").
+	SkipDirs []string
+	// IncludeVendor walks vendor/ directories regardless of SkipDirs.
+	// Files found there can be identified with IsVendored.
+	IncludeVendor bool
+	// FollowSymlinks descends into symlinked directories and includes symlinked
+	// files. Targets outside root are never followed and directories already
+	// visited (by device/inode) are skipped to break cycles.
+	FollowSymlinks bool
+}
+
+// DefaultOptions returns the options used by Walk.
+func DefaultOptions() Options {
+	return Options{
+		SkipDirs: append([]string(nil), DefaultSkipDirs...),
+	}
+}
+
+// projectFiles are the names of the files that describe how the sources fit
+// together: Go module and workspace files, JavaScript and TypeScript
+// configs, and dependency manifests and lockfiles.
+var projectFiles = map[string]bool{
+	"go.mod": true, "go.work": true, "go.sum": true,
+	"package.json": true, "tsconfig.json": true, "jsconfig.json": true,
+	"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.toml": true, "Cargo.lock": true,
+}
+
+// Tree is what a walk finds.
+type Tree struct {
+	// Files are the source files to map.
+	Files []string
+	// ProjectFiles are the module files, configs and manifests found along
+	// the way, so later phases need not walk the tree again.
+	ProjectFiles []string
+	// Unreadable are the directories and files that could not be read and
+	// were left out of the walk.
+	Unreadable []string
+}
+
 // Walk traverses the directory tree rooted at root and returns a list of files
 // that match the default filtering criteria (Go files, non-binary, non-hidden)
 // and respect .gitignore rules.
 func Walk(root string) ([]string, error) {
-	var files []string
+	return WalkWithOptions(root, DefaultOptions())
+}
 
+// WalkWithOptions is like Walk but uses the given skip list and symlink policy.
+func WalkWithOptions(root string, opts Options) ([]string, error) {
+	tree, err := WalkTree(root, opts)
+	return tree.Files, err
+}
+
+// WalkTree is like WalkWithOptions but also returns the project files under
+// root. They are subject to the same skip list and .gitignore rules as the
+// sources, whatever their extension.
+func WalkTree(root string, opts Options) (*Tree, error) {
 	// Get supported extensions from the parsing registry
 	supportedExts := make(map[string]bool)
 	for _, ext := range parsing.DefaultRegistry.SupportedExtensions() {
 		supportedExts[ext] = true
 	}
 
-	// Binary extensions to exclude
-	excludeExts := map[string]bool{
-		".exe":   true,
-		".o":     true,
-		".a":     true,
-		".so":    true,
-		".dylib": true,
-		".dll":   true,
-		".bin":   true,
-	}
-
 	// Parse .gitignore if it exists
 	gitignore, err := ParseGitignore(root)
-	// We ignore error here as ParseGitignore returns usable object even on error (empty)
-	// or we can just proceed. Actually ParseGitignore returns nil on error.
 	if err != nil {
-		// If we can't parse gitignore (e.g. permission error), we proceed without it?
-		// Or strictly fail? Let's proceed with empty one.
+		// If we can't parse gitignore (e.g. permission error), proceed with an empty one.
 		gitignore = &Gitignore{root: root}
 	}
 
-	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+	w := &walker{
+		root:          root,
+		opts:          opts,
+		gitignore:     gitignore,
+		supportedExts: supportedExts,
+		visited:       make(map[fileID]bool),
+	}
+
+	if opts.FollowSymlinks {
+		realRoot, err := filepath.EvalSymlinks(root)
 		if err != nil {
-			return err
+			return &w.tree, err
 		}
+		w.realRoot = realRoot
+		if info, err := os.Stat(realRoot); err == nil {
+			if id, ok := idOf(realRoot, info); ok {
+				w.visited[id] = true
+			}
+		}
+	}
+
+	if err := w.walk(root, root); err != nil {
+		return &w.tree, err
+	}
+
+	// Symlinked directories are walked after the real tree so that a directory
+	// reachable both ways is reported under its real path.
+	for len(w.pending) > 0 {
+		link := w.pending[0]
+		w.pending = w.pending[1:]
+		if err := w.walkLink(link); err != nil {
+			return &w.tree, err
+		}
+	}
+	return &w.tree, nil
+}
+
+// IsVendored reports whether a root-relative path lies inside a vendor/ directory.
+func IsVendored(relPath string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if part == "vendor" {
+			return true
+		}
+	}
+	return false
+}
+
+// Binary extensions to exclude
+var excludeExts = map[string]bool{
+	".exe":   true,
+	".o":     true,
+	".a":     true,
+	".so":    true,
+	".dylib": true,
+	".dll":   true,
+	".bin":   true,
+}
+
+type walker struct {
+	root          string
+	realRoot      string
+	opts          Options
+	gitignore     *Gitignore
+	supportedExts map[string]bool
+	visited       map[fileID]bool
+	pending       []symlink
+	tree          Tree
+}
+
+// symlink is a symlinked directory queued for walking.
+type symlink struct {
+	path   string
+	target string
+	info   os.FileInfo
+}
+
+// walk walks the real directory dir and reports paths as if rooted at logical.
+// The two differ only below a followed symlink.
+func (w *walker) walk(dir, logical string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == w.root {
+				return err
+			}
+			// An unreadable entry hides its own files, not the others.
+			w.tree.Unreadable = append(w.tree.Unreadable, logical+strings.TrimPrefix(path, dir))
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		path = logical + strings.TrimPrefix(path, dir)
 
 		// Check gitignore first
-		if gitignore != nil && gitignore.Matches(path) {
+		if w.gitignore.Matches(path) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		// Skip hidden directories (starting with .) or node_modules
 		if d.IsDir() {
-			name := d.Name()
-			if (strings.HasPrefix(name, ".") && name != ".") || name == "node_modules" || name == "vendor" || name == "dist" || name == "build" {
+			if path == logical {
+				return nil
+			}
+			if w.skipDir(path, d.Name()) {
 				return filepath.SkipDir
 			}
+			if w.opts.FollowSymlinks {
+				info, err := d.Info()
+				if err != nil {
+					return nil
+				}
+				if id, ok := idOf(filepath.Join(dir, strings.TrimPrefix(path, logical)), info); ok {
+					if w.visited[id] {
+						return filepath.SkipDir
+					}
+					w.visited[id] = true
+				}
+			}
+			return nil
+		}
+
+		if d.Type()&os.ModeSymlink != 0 {
+			if w.opts.FollowSymlinks {
+				return w.followSymlink(path, d.Name())
+			}
 			return nil
 		}
 
@@ -68,20 +224,100 @@ func Walk(root string) ([]string, error) {
 			return nil
 		}
 
-		ext := filepath.Ext(path)
+		w.addFile(path)
+		return nil
+	})
+}
 
-		// Skip binary files
-		if excludeExts[ext] {
+// followSymlink resolves a symlink found at path, recording a file target or
+// queueing a directory target for walkLink.
+// Broken links and links escaping the root are ignored.
+func (w *walker) followSymlink(path, name string) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(w.realRoot, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil
+	}
+
+	if info.Mode().IsRegular() {
+		w.addFile(path)
+		return nil
+	}
+	if !info.IsDir() || w.skipDir(path, name) {
+		return nil
+	}
+	w.pending = append(w.pending, symlink{path: path, target: target, info: info})
+	return nil
+}
+
+// walkLink walks a queued symlinked directory unless its target was already visited.
+func (w *walker) walkLink(link symlink) error {
+	if id, ok := idOf(link.target, link.info); ok {
+		if w.visited[id] {
 			return nil
 		}
+		w.visited[id] = true
+	}
+	return w.walk(link.target, link.path)
+}
 
-		// Include only allowed extensions from the registry
-		if supportedExts[ext] {
-			files = append(files, path)
-		}
+func (w *walker) addFile(path string) {
+	if projectFiles[filepath.Base(path)] {
+		w.tree.ProjectFiles = append(w.tree.ProjectFiles, path)
+	}
 
-		return nil
-	})
+	ext := filepath.Ext(path)
+
+	// Skip binary files
+	if excludeExts[ext] {
+		return
+	}
+
+	// Include only allowed extensions from the registry
+	if w.supportedExts[ext] {
+		w.tree.Files = append(w.tree.Files, path)
+	}
+}
+
+// skipDir applies the skip list to a directory. Like .gitignore, the last
+// matching pattern wins.
+func (w *walker) skipDir(path, name string) bool {
+	if name == "vendor" && w.opts.IncludeVendor {
+		return false
+	}
+
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
 
-	return files, err
+	skip := false
+	for _, pattern := range w.opts.SkipDirs {
+		pattern = strings.TrimSpace(pattern)
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "!"), "/")
+		if pattern == "" {
+			continue
+		}
+
+		var match bool
+		if strings.Contains(pattern, "/") {
+			match, _ = filepath.Match(strings.TrimPrefix(pattern, "/"), rel)
+		} else {
+			match, _ = filepath.Match(pattern, name)
+		}
+		if match {
+			skip = !negate
+		}
+	}
+	return skip
 }
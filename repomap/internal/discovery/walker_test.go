@@ -3,6 +3,7 @@ package discovery
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -91,3 +92,188 @@ vendor/
 		}
 	}
 }
+
+func TestWalkWithOptions_SkipDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, file := range []string{
+		"main.go",
+		"build/build.go",
+		".github/tools/gen.go",
+		".git/hooks/hook.go",
+		"vendor/github.com/x/y/y.go",
+		"testdata/gen/skip.go",
+	} {
+		path := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := Options{
+		SkipDirs:      []string{".*", "!.github", "testdata/gen"},
+		IncludeVendor: true,
+	}
+	found, err := WalkWithOptions(tmpDir, opts)
+	if err != nil {
+		t.Fatalf("WalkWithOptions failed: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, f := range found {
+		rel, _ := filepath.Rel(tmpDir, f)
+		got[filepath.ToSlash(rel)] = true
+	}
+
+	want := []string{"main.go", "build/build.go", ".github/tools/gen.go", "vendor/github.com/x/y/y.go"}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("expected %s to be walked", w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d files, got %v", len(want), got)
+	}
+
+	if !IsVendored("vendor/github.com/x/y/y.go") || IsVendored("pkg/vendors/a.go") {
+		t.Error("IsVendored misclassified paths")
+	}
+}
+
+func TestWalkWithOptions_FollowSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	outside := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "pkg", "a.go"), []byte("package pkg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "escape.go"), []byte("package out"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	links := map[string]string{
+		"linked":       filepath.Join(tmpDir, "pkg"), // duplicate of pkg/
+		"pkg/loop":     tmpDir,                       // cycle back to root
+		"outside":      outside,                      // escapes root
+		"link_file.go": filepath.Join(tmpDir, "pkg", "a.go"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(tmpDir, name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	// Without following, symlinks are ignored entirely.
+	found, err := WalkWithOptions(tmpDir, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if len(found) != 1 {
+		t.Errorf("expected only pkg/a.go without following symlinks, got %v", found)
+	}
+
+	opts := DefaultOptions()
+	opts.FollowSymlinks = true
+	found, err = WalkWithOptions(tmpDir, opts)
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, f := range found {
+		rel, _ := filepath.Rel(tmpDir, f)
+		got[filepath.ToSlash(rel)] = true
+	}
+	if !got["pkg/a.go"] || !got["link_file.go"] {
+		t.Errorf("expected pkg/a.go and link_file.go, got %v", got)
+	}
+	if got["linked/a.go"] {
+		t.Error("directory reached twice through a symlink should be skipped")
+	}
+	for f := range got {
+		if strings.HasPrefix(f, "outside/") || strings.HasPrefix(f, "pkg/loop/") {
+			t.Errorf("unexpected file %s", f)
+		}
+	}
+}
+
+func TestWalkTree_ProjectFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, file := range []string{
+		"main.go",
+		"go.mod",
+		"web/package.json",
+		"web/tsconfig.json",
+		"web/README.md",
+		"ignored/go.mod",
+		"node_modules/dep/package.json",
+	} {
+		path := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("ignored/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := WalkTree(tmpDir, DefaultOptions())
+	if err != nil {
+		t.Fatalf("WalkTree failed: %v", err)
+	}
+
+	var got []string
+	for _, f := range tree.ProjectFiles {
+		rel, _ := filepath.Rel(tmpDir, f)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{"go.mod", "web/package.json", "web/tsconfig.json"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected project files %v, got %v", want, got)
+	}
+	if len(tree.Files) != 1 {
+		t.Errorf("expected only main.go as a source, got %v", tree.Files)
+	}
+}
+
+func TestWalkTree_Unreadable(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, file := range []string{"main.go", "locked/secret.go", "pkg/a.go"} {
+		path := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	locked := filepath.Join(tmpDir, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+	if _, err := os.ReadDir(locked); err == nil {
+		t.Skip("permissions are not enforced for this user")
+	}
+
+	tree, err := WalkTree(tmpDir, DefaultOptions())
+	if err != nil {
+		t.Fatalf("expected an unreadable directory not to fail the walk, got %v", err)
+	}
+	if len(tree.Files) != 2 {
+		t.Errorf("expected main.go and pkg/a.go, got %v", tree.Files)
+	}
+	if len(tree.Unreadable) != 1 || tree.Unreadable[0] != locked {
+		t.Errorf("expected %s to be reported unreadable, got %v", locked, tree.Unreadable)
+	}
+}
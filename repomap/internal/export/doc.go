@@ -0,0 +1,14 @@
+/*
+Package export writes the import graph in graph interchange formats.
+
+Supported formats:
+- DOT: For Graphviz.
+- GraphML: For yEd, Gephi and other graph tools.
+- Mermaid: For Markdown renderers such as GitHub pull requests and docs.
+- Cytoscape: Cytoscape.js elements JSON.
+
+Nodes carry rank, importance, intent and issue counts as attributes. A
+Subgraph can be limited to path prefixes and capped to the highest-ranked
+nodes so that the output stays readable.
+*/
+package export
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+func testSubgraph(opts Options) *Subgraph {
+	g := &graph.Graph{
+		Edges: map[string][]string{
+			"cmd/main.go":     {"pkg/a/a.go", "pkg/b/b.go"},
+			"pkg/a/a.go":      {"pkg/b/b.go"},
+			"pkg/b/b.go":      {"internal/x/x.go"},
+			"internal/x/x.go": {},
+		},
+	}
+	files := []*output.FileNode{
+		{Path: "pkg/b/b.go", Rank: 1, Importance: "high", Intent: "Core \"types\"", Issues: []output.Issue{{Type: "cycle"}}},
+		{Path: "pkg/a/a.go", Rank: 0.5, Importance: "medium"},
+		{Path: "internal/x/x.go", Rank: 0.5, Importance: "medium"},
+		{Path: "cmd/main.go", Rank: 0, Importance: "low"},
+	}
+	return FromFiles(g, files, opts)
+}
+
+func TestFromFiles(t *testing.T) {
+	s := testSubgraph(Options{})
+	if len(s.Nodes) != 4 || len(s.Edges) != 4 {
+		t.Fatalf("expected 4 nodes and 4 edges, got %d and %d", len(s.Nodes), len(s.Edges))
+	}
+	if s.Edges[0].From != "cmd/main.go" || s.Edges[0].To != "pkg/a/a.go" {
+		t.Errorf("edges should be sorted, got %+v", s.Edges[0])
+	}
+
+	s = testSubgraph(Options{Prefixes: []string{"pkg/", "./cmd"}})
+	if len(s.Nodes) != 3 || len(s.Edges) != 3 {
+		t.Errorf("prefix filter: expected 3 nodes and 3 edges, got %+v", s)
+	}
+
+	s = testSubgraph(Options{MaxNodes: 2})
+	if len(s.Nodes) != 2 || s.Nodes[0].ID != "pkg/b/b.go" || s.Nodes[1].ID != "pkg/a/a.go" {
+		t.Errorf("cap should keep the highest-ranked nodes, got %+v", s.Nodes)
+	}
+	if len(s.Edges) != 1 {
+		t.Errorf("cap should drop edges to removed nodes, got %+v", s.Edges)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	s := testSubgraph(Options{})
+	s.Edges[0].Weight = 3
+
+	tests := map[string][]string{
+		"dot": {
+			"digraph repomap {",
+			`"pkg/b/b.go" [score=1, importance="high", issues=1, intent="Core \"types\"", fillcolor="#f4a582"];`,
+			`"cmd/main.go" -> "pkg/a/a.go" [weight=3, label="3"];`,
+			`"pkg/a/a.go" -> "pkg/b/b.go";`,
+		},
+		"mermaid": {
+			"graph LR",
+			`n0["pkg/b/b.go"]:::high`,
+			"n3 -->|3| n1",
+			"n1 --> n0",
+			"classDef high fill:#f4a582",
+		},
+		"graphml": {
+			`<key id="rank" for="node" attr.name="rank" attr.type="double"></key>`,
+			`<node id="pkg/b/b.go">`,
+			`<data key="intent">Core &#34;types&#34;</data>`,
+			`<edge source="cmd/main.go" target="pkg/a/a.go">`,
+		},
+		"cytoscape": {
+			`"id": "pkg/b/b.go"`,
+			`"source": "cmd/main.go"`,
+			`"weight": 3`,
+		},
+	}
+
+	for format, want := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, s); err != nil {
+			t.Fatalf("Write(%s) failed: %v", format, err)
+		}
+		for _, line := range want {
+			if !strings.Contains(buf.String(), line) {
+				t.Errorf("%s output missing %q:\n%s", format, line, buf.String())
+			}
+		}
+
+		switch format {
+		case "graphml":
+			var doc graphML
+			if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil || len(doc.Graph.Nodes) != 4 {
+				t.Errorf("invalid GraphML: %v", err)
+			}
+		case "cytoscape":
+			if !json.Valid(buf.Bytes()) {
+				t.Error("invalid Cytoscape JSON")
+			}
+		}
+	}
+
+	if err := Write(&bytes.Buffer{}, "svg", s); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,223 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{"dot", "graphml", "mermaid", "cytoscape"}
+
+// Write renders the subgraph in the named format.
+func Write(w io.Writer, format string, s *Subgraph) error {
+	switch format {
+	case "dot":
+		return WriteDOT(w, s)
+	case "graphml":
+		return WriteGraphML(w, s)
+	case "mermaid":
+		return WriteMermaid(w, s)
+	case "cytoscape":
+		return WriteCytoscape(w, s)
+	default:
+		return fmt.Errorf("unsupported graph format: %s (expected %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// importanceColors fills nodes by importance in DOT and Mermaid output.
+var importanceColors = map[string]string{
+	"high":   "#f4a582",
+	"medium": "#fddbc7",
+	"low":    "#f7f7f7",
+}
+
+// WriteDOT renders the subgraph as a Graphviz digraph.
+func WriteDOT(w io.Writer, s *Subgraph) error {
+	var b strings.Builder
+	b.WriteString("digraph repomap {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=filled, fontname=\"Helvetica\"];\n")
+	// "rank" is a Graphviz attribute, so the score goes by another name.
+	for _, n := range s.Nodes {
+		fmt.Fprintf(&b, "  %s [score=%s, importance=%s, issues=%d",
+			dotQuote(n.ID), strconv.FormatFloat(n.Rank, 'g', 4, 64), dotQuote(n.Importance), n.Issues)
+		if n.Intent != "" {
+			fmt.Fprintf(&b, ", intent=%s", dotQuote(n.Intent))
+		}
+		if color, ok := importanceColors[n.Importance]; ok {
+			fmt.Fprintf(&b, ", fillcolor=%s", dotQuote(color))
+		}
+		b.WriteString("];\n")
+	}
+	for _, e := range s.Edges {
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(e.From), dotQuote(e.To))
+		if e.Weight > 1 {
+			fmt.Fprintf(&b, " [weight=%d, label=\"%d\"]", e.Weight, e.Weight)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteMermaid renders the subgraph as a Mermaid flowchart. Nodes get short
+// generated IDs since Mermaid IDs cannot contain most path characters.
+func WriteMermaid(w io.Writer, s *Subgraph) error {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	ids := make(map[string]string, len(s.Nodes))
+	for i, n := range s.Nodes {
+		id := "n" + strconv.Itoa(i)
+		ids[n.ID] = id
+		fmt.Fprintf(&b, "  %s[\"%s\"]", id, mermaidEscape(n.ID))
+		if n.Importance != "" {
+			fmt.Fprintf(&b, ":::%s", n.Importance)
+		}
+		b.WriteString("\n")
+	}
+	for _, e := range s.Edges {
+		if e.Weight > 1 {
+			fmt.Fprintf(&b, "  %s -->|%d| %s\n", ids[e.From], e.Weight, ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+		}
+	}
+	for _, level := range []string{"high", "medium", "low"} {
+		fmt.Fprintf(&b, "  classDef %s fill:%s\n", level, importanceColors[level])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML renders the subgraph as a GraphML document.
+func WriteGraphML(w io.Writer, s *Subgraph) error {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "rank", For: "node", AttrName: "rank", AttrType: "double"},
+			{ID: "importance", For: "node", AttrName: "importance", AttrType: "string"},
+			{ID: "intent", For: "node", AttrName: "intent", AttrType: "string"},
+			{ID: "issues", For: "node", AttrName: "issues", AttrType: "int"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+		},
+		Graph: graphMLGraph{ID: "repomap", EdgeDefault: "directed"},
+	}
+	for _, n := range s.Nodes {
+		node := graphMLNode{ID: n.ID, Data: []graphMLData{
+			{Key: "rank", Value: strconv.FormatFloat(n.Rank, 'g', -1, 64)},
+			{Key: "importance", Value: n.Importance},
+			{Key: "issues", Value: strconv.Itoa(n.Issues)},
+		}}
+		if n.Intent != "" {
+			node.Data = append(node.Data, graphMLData{Key: "intent", Value: n.Intent})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for _, e := range s.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.From,
+			Target: e.To,
+			Data:   []graphMLData{{Key: "weight", Value: strconv.Itoa(e.Weight)}},
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+string(out)+"\n")
+	return err
+}
+
+type cytoscapeElements struct {
+	Elements struct {
+		Nodes []cytoscapeElement `json:"nodes"`
+		Edges []cytoscapeElement `json:"edges"`
+	} `json:"elements"`
+}
+
+type cytoscapeElement struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// WriteCytoscape renders the subgraph as Cytoscape.js elements JSON.
+func WriteCytoscape(w io.Writer, s *Subgraph) error {
+	var doc cytoscapeElements
+	doc.Elements.Nodes = make([]cytoscapeElement, 0, len(s.Nodes))
+	doc.Elements.Edges = make([]cytoscapeElement, 0, len(s.Edges))
+	for _, n := range s.Nodes {
+		data := map[string]interface{}{
+			"id":         n.ID,
+			"rank":       n.Rank,
+			"importance": n.Importance,
+			"issues":     n.Issues,
+		}
+		if n.Intent != "" {
+			data["intent"] = n.Intent
+		}
+		doc.Elements.Nodes = append(doc.Elements.Nodes, cytoscapeElement{Data: data})
+	}
+	for i, e := range s.Edges {
+		doc.Elements.Edges = append(doc.Elements.Edges, cytoscapeElement{Data: map[string]interface{}{
+			"id":     "e" + strconv.Itoa(i),
+			"source": e.From,
+			"target": e.To,
+			"weight": e.Weight,
+		}})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// Node is a file or package in an exported graph.
+type Node struct {
+	ID         string
+	Rank       float64
+	Importance string
+	Intent     string
+	Issues     int
+}
+
+// Edge is an import between two nodes. Weight counts the file-level imports
+// an edge between packages stands for, and is 1 between files.
+type Edge struct {
+	From   string
+	To     string
+	Weight int
+}
+
+// Subgraph is the part of the import graph selected for export.
+// Nodes are in rank order; edges are sorted by source, then destination.
+type Subgraph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Options selects the nodes to export.
+type Options struct {
+	// Prefixes keeps only nodes whose path starts with one of the prefixes.
+	// An empty list keeps every node.
+	Prefixes []string
+	// MaxNodes keeps at most this many of the highest-ranked nodes (0 for no cap).
+	MaxNodes int
+}
+
+// FromFiles builds a subgraph of files. files should be sorted by rank,
+// highest first; nodes of g without a file entry are left out.
+func FromFiles(g *graph.Graph, files []*output.FileNode, opts Options) *Subgraph {
+	nodes := make([]Node, 0, len(files))
+	for _, f := range files {
+		nodes = append(nodes, Node{
+			ID:         f.Path,
+			Rank:       f.Rank,
+			Importance: f.Importance,
+			Intent:     f.Intent,
+			Issues:     len(f.Issues),
+		})
+	}
+	return newSubgraph(g, nodes, opts)
+}
+
+// FromPackages builds a subgraph of packages from a collapsed graph.
+// pkgs should be sorted by rank, highest first.
+func FromPackages(g *graph.Graph, pkgs []*output.PackageNode, opts Options) *Subgraph {
+	nodes := make([]Node, 0, len(pkgs))
+	for _, p := range pkgs {
+		nodes = append(nodes, Node{
+			ID:         p.Path,
+			Rank:       p.Rank,
+			Importance: p.Importance,
+			Issues:     p.Issues,
+		})
+	}
+	return newSubgraph(g, nodes, opts)
+}
+
+func newSubgraph(g *graph.Graph, nodes []Node, opts Options) *Subgraph {
+	s := &Subgraph{}
+	kept := make(map[string]bool)
+	for _, n := range nodes {
+		if opts.MaxNodes > 0 && len(s.Nodes) >= opts.MaxNodes {
+			break
+		}
+		if !hasPrefix(n.ID, opts.Prefixes) {
+			continue
+		}
+		s.Nodes = append(s.Nodes, n)
+		kept[n.ID] = true
+	}
+
+	for from, dsts := range g.Edges {
+		if !kept[from] {
+			continue
+		}
+		for _, to := range dsts {
+			if kept[to] {
+				s.Edges = append(s.Edges, Edge{From: from, To: to, Weight: g.Weight(from, to)})
+			}
+		}
+	}
+	sort.Slice(s.Edges, func(i, j int) bool {
+		if s.Edges[i].From != s.Edges[j].From {
+			return s.Edges[i].From < s.Edges[j].From
+		}
+		return s.Edges[i].To < s.Edges[j].To
+	})
+	return s
+}
+
+func hasPrefix(p string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(p, strings.TrimPrefix(prefix, "./")) {
+			return true
+		}
+	}
+	return false
+}
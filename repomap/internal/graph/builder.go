@@ -1,8 +1,11 @@
 package graph
 
 import (
+	"path"
 	"strings"
 	"sync"
+
+	"github.com/spanexx/agents-cli/repomap/internal/modules"
 )
 
 // Graph represents a directed graph of file dependencies.
@@ -11,6 +14,37 @@ type Graph struct {
 	Nodes map[string]*Node
 	// Edges maps a source file path to a list of destination file paths (imports).
 	Edges map[string][]string
+	// External maps a source file path to imports of code outside the repository.
+	External map[string][]string
+	// Unresolved maps a source file path to imports that look local but match no file.
+	Unresolved map[string][]string
+	// Weights holds the multiplicity of edges in an aggregated graph
+	// (source -> destination -> count). A nil map means every edge has weight 1.
+	Weights map[string]map[string]int
+	// Via parallels Edges: Via[src][i] is the import, as written in the
+	// source, that resolved to Edges[src][i]. A nil map means the imports
+	// are unknown, as in an aggregated graph.
+	Via map[string][]string
+}
+
+// Import returns the import through which src imports dst, or "" if it is
+// not known.
+func (g *Graph) Import(src, dst string) string {
+	via := g.Via[src]
+	for i, d := range g.Edges[src] {
+		if d == dst && i < len(via) {
+			return via[i]
+		}
+	}
+	return ""
+}
+
+// Weight returns the weight of the edge from src to dst.
+func (g *Graph) Weight(src, dst string) int {
+	if g.Weights == nil {
+		return 1
+	}
+	return g.Weights[src][dst]
 }
 
 // Node represents a file in the import graph.
@@ -22,14 +56,45 @@ type Node struct {
 
 // Builder constructs a dependency graph.
 type Builder struct {
-	mu    sync.Mutex
-	files map[string][]string // file path -> list of raw imports
+	mu        sync.Mutex
+	files     map[string][]string // file path -> list of raw imports
+	resolvers map[string]Resolver // file extension -> resolver
 }
 
-// NewBuilder creates a new graph builder.
+// NewBuilder creates a new graph builder with resolvers for Go, JavaScript/
+// TypeScript, Python, Rust, C/C++ and Java. The Go resolver is bound to the
+// workspace passed to BuildWorkspace.
 func NewBuilder() *Builder {
-	return &Builder{
-		files: make(map[string][]string),
+	b := &Builder{
+		files:     make(map[string][]string),
+		resolvers: make(map[string]Resolver),
+	}
+	b.SetJSResolver(&JSResolver{})
+	b.RegisterResolver(".py", PythonResolver{})
+	b.RegisterResolver(".rs", RustResolver{})
+	for _, ext := range []string{".c", ".h", ".cc", ".cpp", ".hpp"} {
+		b.RegisterResolver(ext, CResolver{})
+	}
+	b.RegisterResolver(".java", JavaResolver{})
+	return b
+}
+
+// RegisterResolver sets the resolver used for files with the given extension.
+// Files without a registered resolver have their imports matched against
+// root-relative directories.
+func (b *Builder) RegisterResolver(ext string, r Resolver) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	b.resolvers[strings.ToLower(ext)] = r
+}
+
+// SetJSResolver registers r for every JavaScript/TypeScript extension.
+func (b *Builder) SetJSResolver(r *JSResolver) {
+	for _, ext := range []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts"} {
+		b.RegisterResolver(ext, r)
 	}
 }
 
@@ -42,66 +107,84 @@ func (b *Builder) AddFile(path string, imports []string) {
 	b.files[path] = imports
 }
 
-// Build constructs the final graph.
+// Build constructs the final graph for a repository holding a single module.
 func (b *Builder) Build(moduleName string) *Graph {
+	return b.BuildWorkspace(modules.Single(moduleName))
+}
+
+// BuildWorkspace constructs the final graph, dispatching each file's imports
+// to the resolver for its language. Go imports are resolved against every
+// module (and local replace directive) in the workspace.
+func (b *Builder) BuildWorkspace(ws *modules.Workspace) *Graph {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	g := &Graph{
-		Nodes: make(map[string]*Node),
-		Edges: make(map[string][]string),
+		Nodes:      make(map[string]*Node),
+		Edges:      make(map[string][]string),
+		External:   make(map[string][]string),
+		Unresolved: make(map[string][]string),
+		Via:        make(map[string][]string),
 	}
 
-	// Index files by directory (package path)
-	// Map: directory path -> list of files
-	pkgFiles := make(map[string][]string)
-
-	for path, imports := range b.files {
-		// Use slash for consistency in graph keys
-		normalizedPath := strings.ReplaceAll(path, "\\", "/")
-
-		dir := "."
-		lastSlash := strings.LastIndex(normalizedPath, "/")
-		if lastSlash != -1 {
-			dir = normalizedPath[:lastSlash]
-		}
-		pkgFiles[dir] = append(pkgFiles[dir], normalizedPath)
-
-		// Initialize node with normalized path
+	// Use slash for consistency in graph keys
+	paths := make([]string, 0, len(b.files))
+	imports := make(map[string][]string, len(b.files))
+	for file, imps := range b.files {
+		normalizedPath := strings.ReplaceAll(file, "\\", "/")
+		paths = append(paths, normalizedPath)
+		imports[normalizedPath] = imps
 		g.Nodes[normalizedPath] = &Node{
 			Path:    normalizedPath,
-			Imports: imports,
+			Imports: imps,
 		}
 	}
+	ix := newIndex(paths)
+
+	goResolver := b.resolvers[".go"]
+	if goResolver == nil {
+		goResolver = &GoResolver{Workspace: ws}
+	}
 
 	// Build edges
-	for srcFileRaw, imports := range b.files {
-		srcFile := strings.ReplaceAll(srcFileRaw, "\\", "/")
-
-		for _, imp := range imports {
-			// Normalize import: strip module name if present
-			targetPkg := imp
-			if moduleName != "" && strings.HasPrefix(imp, moduleName) {
-				targetPkg = strings.TrimPrefix(imp, moduleName)
-				targetPkg = strings.TrimPrefix(targetPkg, "/")
+	for _, srcFile := range paths {
+		resolver := b.resolvers[strings.ToLower(path.Ext(srcFile))]
+		if resolver == nil {
+			resolver = dirResolver{}
+		}
+		if strings.EqualFold(path.Ext(srcFile), ".go") {
+			resolver = goResolver
+		}
+
+		seen := make(map[string]bool)
+		var edges, via []string
+		for _, imp := range imports[srcFile] {
+			destFiles, kind := resolver.Resolve(ix, srcFile, imp)
+			switch kind {
+			case ImportExternal:
+				g.External[srcFile] = append(g.External[srcFile], imp)
+			case ImportUnresolved:
+				g.Unresolved[srcFile] = append(g.Unresolved[srcFile], imp)
 			}
 
-			// If targetPkg is a directory in our index, add edges
-			if destFiles, ok := pkgFiles[targetPkg]; ok {
-				for _, destFile := range destFiles {
-					// Avoid self-loops
-					if srcFile == destFile {
-						continue
-					}
-
-					g.Edges[srcFile] = append(g.Edges[srcFile], destFile)
-					// Ensure destination node exists before updating (should exist from init loop)
-					if node, ok := g.Nodes[destFile]; ok {
-						node.InDegree++
-					}
+			for _, destFile := range destFiles {
+				// Avoid self-loops and duplicate edges
+				if srcFile == destFile || seen[destFile] {
+					continue
+				}
+				seen[destFile] = true
+
+				edges = append(edges, destFile)
+				via = append(via, imp)
+				if node, ok := g.Nodes[destFile]; ok {
+					node.InDegree++
 				}
 			}
 		}
+		if len(edges) > 0 {
+			g.Edges[srcFile] = edges
+			g.Via[srcFile] = via
+		}
 	}
 
 	return g
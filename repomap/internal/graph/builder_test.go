@@ -1,7 +1,11 @@
 package graph
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/modules"
 )
 
 func TestGraphBuilder(t *testing.T) {
@@ -95,4 +99,58 @@ func TestGraphBuilder(t *testing.T) {
 			t.Errorf("main.go: unexpected edge to %s", edge)
 		}
 	}
+
+	if imp := g.Import("main.go", "pkg/config/config.go"); imp != "github.com/example/repo/pkg/config" {
+		t.Errorf("main.go: expected the edge to config.go via its import, got %q", imp)
+	}
+}
+
+func TestGraphBuilder_Vendor(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddFile("main.go", []string{"github.com/google/uuid"})
+	builder.AddFile("vendor/github.com/google/uuid/uuid.go", nil)
+
+	g := builder.Build("github.com/example/repo")
+
+	edges := g.Edges["main.go"]
+	if len(edges) != 1 || edges[0] != "vendor/github.com/google/uuid/uuid.go" {
+		t.Errorf("expected edge to vendored package, got %v", edges)
+	}
+}
+
+func TestGraphBuilder_Workspace(t *testing.T) {
+	root := t.TempDir()
+	var files []string
+	for name, content := range map[string]string{
+		"go.work":             "go 1.22\n\nuse (\n\t./app\n\t./lib\n)\n",
+		"app/go.mod":          "module example.com/app\n",
+		"lib/go.mod":          "module example.com/lib\n",
+		"lib/strings/util.go": "package strings\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	ws := modules.FromFiles(root, files)
+
+	builder := NewBuilder()
+	builder.AddFile("app/main.go", []string{"example.com/lib/strings", "example.com/app/internal/cfg"})
+	builder.AddFile("app/internal/cfg/cfg.go", nil)
+	builder.AddFile("lib/strings/util.go", nil)
+
+	g := builder.BuildWorkspace(ws)
+
+	edges := make(map[string]bool)
+	for _, e := range g.Edges["app/main.go"] {
+		edges[e] = true
+	}
+	if !edges["lib/strings/util.go"] || !edges["app/internal/cfg/cfg.go"] || len(edges) != 2 {
+		t.Errorf("expected cross-module and intra-module edges, got %v", g.Edges["app/main.go"])
+	}
 }
@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// PackageKey groups a file with the other files in its directory, which is
+// a package in Go and Java and a module directory in most other languages.
+func PackageKey(file string) string {
+	return path.Dir(file)
+}
+
+// DirKey returns a key function grouping files by their directory truncated
+// to depth path segments. Files at the root are grouped under ".".
+func DirKey(depth int) func(string) string {
+	return func(file string) string {
+		dir := path.Dir(file)
+		if dir == "." || depth <= 0 {
+			return "."
+		}
+		parts := strings.Split(dir, "/")
+		if len(parts) > depth {
+			parts = parts[:depth]
+		}
+		return strings.Join(parts, "/")
+	}
+}
+
+// Collapse aggregates the graph into one node per key. An edge between two
+// groups is weighted by the number of imports it aggregates: a file's import
+// counts once however many files of the destination group it resolves to,
+// and edges whose import is unknown, such as those of a graph collapsed
+// before, count with their weight. Collapsing a collapsed graph therefore
+// sums to the same weights as collapsing the files directly. A group's
+// InDegree is the number of distinct groups importing it, and edges inside a
+// group are dropped.
+func Collapse(g *Graph, key func(string) string) *Graph {
+	c := &Graph{
+		Nodes:      make(map[string]*Node),
+		Edges:      make(map[string][]string),
+		External:   make(map[string][]string),
+		Unresolved: make(map[string][]string),
+		Weights:    make(map[string]map[string]int),
+	}
+
+	for p := range g.Nodes {
+		k := key(p)
+		if _, ok := c.Nodes[k]; !ok {
+			c.Nodes[k] = &Node{Path: k}
+		}
+	}
+
+	type groupImport struct{ group, imp string }
+	for src, dsts := range g.Edges {
+		srcKey := key(src)
+		via := g.Via[src]
+		// A Go import has an edge to each file of the package it names.
+		counted := make(map[groupImport]bool)
+		for i, dst := range dsts {
+			dstKey := key(dst)
+			if srcKey == dstKey {
+				continue
+			}
+			w := g.Weight(src, dst)
+			if i < len(via) {
+				gi := groupImport{dstKey, via[i]}
+				if counted[gi] {
+					continue
+				}
+				counted[gi] = true
+				w = 1
+			}
+
+			if c.Weights[srcKey] == nil {
+				c.Weights[srcKey] = make(map[string]int)
+			}
+			if c.Weights[srcKey][dstKey] == 0 {
+				c.Edges[srcKey] = append(c.Edges[srcKey], dstKey)
+				if node, ok := c.Nodes[dstKey]; ok {
+					node.InDegree++
+				}
+			}
+			c.Weights[srcKey][dstKey] += w
+		}
+	}
+
+	for src, imps := range g.External {
+		c.External[key(src)] = append(c.External[key(src)], imps...)
+	}
+	for src, imps := range g.Unresolved {
+		c.Unresolved[key(src)] = append(c.Unresolved[key(src)], imps...)
+	}
+
+	for k := range c.Edges {
+		sort.Strings(c.Edges[k])
+	}
+	return c
+}
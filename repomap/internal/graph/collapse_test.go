@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollapse(t *testing.T) {
+	g := &Graph{
+		Nodes: map[string]*Node{
+			"main.go":                {Path: "main.go"},
+			"internal/a/a1.go":       {Path: "internal/a/a1.go"},
+			"internal/a/a2.go":       {Path: "internal/a/a2.go"},
+			"internal/b/b.go":        {Path: "internal/b/b.go"},
+			"internal/b/sub/deep.go": {Path: "internal/b/sub/deep.go"},
+		},
+		Edges: map[string][]string{
+			"main.go":          {"internal/a/a1.go", "internal/a/a2.go", "internal/b/b.go"},
+			"internal/a/a1.go": {"internal/a/a2.go", "internal/b/b.go"},
+			"internal/a/a2.go": {"internal/b/b.go", "internal/b/sub/deep.go"},
+		},
+		// a1.go's imports are unknown, so each of its edges counts.
+		Via: map[string][]string{
+			"main.go":          {"example.com/internal/a", "example.com/internal/a", "example.com/internal/b"},
+			"internal/a/a2.go": {"example.com/internal/b", "example.com/internal/b/sub"},
+		},
+	}
+
+	pkgs := Collapse(g, PackageKey)
+	if len(pkgs.Nodes) != 4 {
+		t.Errorf("expected 4 packages, got %d", len(pkgs.Nodes))
+	}
+	if !reflect.DeepEqual(pkgs.Edges["."], []string{"internal/a", "internal/b"}) {
+		t.Errorf("unexpected edges from root: %v", pkgs.Edges["."])
+	}
+	if w := pkgs.Weight(".", "internal/a"); w != 1 {
+		t.Errorf("expected weight 1 for . -> internal/a (one import of the package), got %d", w)
+	}
+	if w := pkgs.Weight("internal/a", "internal/b"); w != 2 {
+		t.Errorf("expected weight 2 for internal/a -> internal/b, got %d", w)
+	}
+	if in := pkgs.Nodes["internal/b"].InDegree; in != 2 {
+		t.Errorf("expected internal/b in-degree 2, got %d", in)
+	}
+
+	dirs := Collapse(g, DirKey(1))
+	if len(dirs.Nodes) != 2 {
+		t.Errorf("expected 2 top-level dirs, got %v", dirs.Nodes)
+	}
+	if w := dirs.Weight(".", "internal"); w != 2 {
+		t.Errorf("expected weight 2 for . -> internal, got %d", w)
+	}
+	if w := Collapse(g, DirKey(2)).Weight("internal/a", "internal/b"); w != 3 {
+		t.Errorf("expected weight 3 for internal/a -> internal/b at depth 2, got %d", w)
+	}
+	if len(dirs.Edges["internal"]) != 0 {
+		t.Errorf("edges inside a group should be dropped, got %v", dirs.Edges["internal"])
+	}
+
+	// Collapsing packages sums to the weights of collapsing files directly.
+	top := Collapse(pkgs, DirKey(1))
+	if !reflect.DeepEqual(top.Weights, dirs.Weights) {
+		t.Errorf("re-collapsed weights %v differ from %v", top.Weights, dirs.Weights)
+	}
+}
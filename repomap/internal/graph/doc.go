@@ -2,6 +2,9 @@
 Package graph provides data structures and algorithms for building a dependency graph of the repository.
 
 It allows constructing a graph where nodes are files and edges represent import dependencies.
+Imports are resolved to files by a per-language Resolver (Go, JavaScript/TypeScript, Python,
+Rust, C/C++ and Java are built in); imports of outside code and imports that cannot be
+matched are recorded separately.
 This graph is used to calculate the importance of files for the ranking phase.
 */
 package graph
@@ -0,0 +1,577 @@
+package graph
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// jsExtensions are probed, in order, when a specifier omits its extension.
+var jsExtensions = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts"}
+
+// jsSourceFor maps emitted-JS extensions to the TypeScript sources that produce them,
+// since TS projects using ESM write `import "./x.js"` for ./x.ts.
+var jsSourceFor = map[string][]string{
+	".js":  {".ts", ".tsx"},
+	".jsx": {".tsx"},
+	".mjs": {".mts"},
+	".cjs": {".cts"},
+}
+
+// IsJSFile reports whether path is a JavaScript or TypeScript source file.
+func IsJSFile(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts":
+		return true
+	}
+	return false
+}
+
+// JSResolver resolves JavaScript/TypeScript import specifiers to files the
+// way Node and the TypeScript compiler do: relative specifiers with extension
+// and index probing, tsconfig/jsconfig baseUrl and paths, and package.json
+// workspace packages. The zero value resolves relative specifiers only.
+// It implements Resolver.
+type JSResolver struct {
+	// configs maps a root-relative directory to the compiler configs that
+	// apply to files below it (a tsconfig.json plus its references).
+	configs map[string][]*tsConfig
+	// packages maps workspace package names to root-relative directories.
+	packages map[string]*jsPackage
+}
+
+// tsConfig is the resolution-related part of a tsconfig.json/jsconfig.json.
+type tsConfig struct {
+	// baseURL is root-relative; empty when unset.
+	baseURL string
+	// patterns holds the "paths" keys, most specific first.
+	patterns []string
+	// paths maps a "paths" key to root-relative substitutions.
+	paths map[string][]string
+}
+
+type jsPackage struct {
+	dir     string
+	entries []string // package.json entry points relative to dir
+}
+
+// NewJSResolver reads the tsconfig.json/jsconfig.json files and package.json
+// workspaces among the files found under root. Files in hidden directories
+// and node_modules are ignored, as are missing or malformed ones.
+func NewJSResolver(root string, files []string) *JSResolver {
+	r := &JSResolver{
+		configs:  make(map[string][]*tsConfig),
+		packages: make(map[string]*jsPackage),
+	}
+
+	var packageDirs []string
+	for _, p := range files {
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			continue
+		}
+		dir := filepath.ToSlash(rel)
+		if dir == ".." || strings.HasPrefix(dir, "../") || ignoredJSPath(dir) {
+			continue
+		}
+		switch name := filepath.Base(p); name {
+		case "tsconfig.json", "jsconfig.json":
+			r.configs[dir] = append(r.configs[dir], loadTSConfigTree(root, path.Join(dir, name))...)
+		case "package.json":
+			packageDirs = append(packageDirs, dir)
+		}
+	}
+
+	for _, pattern := range workspacePatterns(root) {
+		for _, dir := range packageDirs {
+			if matchWorkspace(pattern, dir) {
+				r.addPackage(root, dir)
+			}
+		}
+	}
+	return r
+}
+
+// ignoredJSDir reports whether directories named name are left out when
+// looking for configs and packages.
+func ignoredJSDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules"
+}
+
+// ignoredJSPath reports whether the root-relative directory dir lies in an
+// ignored directory.
+func ignoredJSPath(dir string) bool {
+	if dir == "." {
+		return false
+	}
+	for _, name := range strings.Split(dir, "/") {
+		if ignoredJSDir(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTSConfigTree loads a config, the configs it extends, and the configs it references.
+func loadTSConfigTree(root, relPath string) []*tsConfig {
+	cfg, refs := loadTSConfig(root, relPath, 0)
+	var configs []*tsConfig
+	if cfg != nil && (cfg.baseURL != "" || len(cfg.paths) > 0) {
+		configs = append(configs, cfg)
+	}
+	for _, ref := range refs {
+		if refCfg, _ := loadTSConfig(root, ref, 0); refCfg != nil && (refCfg.baseURL != "" || len(refCfg.paths) > 0) {
+			configs = append(configs, refCfg)
+		}
+	}
+	return configs
+}
+
+type rawTSConfig struct {
+	Extends         interface{} `json:"extends"`
+	CompilerOptions struct {
+		BaseURL *string             `json:"baseUrl"`
+		Paths   map[string][]string `json:"paths"`
+	} `json:"compilerOptions"`
+	References []struct {
+		Path string `json:"path"`
+	} `json:"references"`
+}
+
+// loadTSConfig loads one config file and merges the options it extends.
+// It returns the root-relative paths of referenced configs.
+func loadTSConfig(root, relPath string, depth int) (*tsConfig, []string) {
+	if depth > 8 {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(relPath)))
+	if err != nil {
+		return nil, nil
+	}
+	var raw rawTSConfig
+	if err := json.Unmarshal(stripJSONC(data), &raw); err != nil {
+		return nil, nil
+	}
+
+	dir := path.Dir(relPath)
+	cfg := &tsConfig{paths: make(map[string][]string)}
+
+	// Inherited options come first so local ones override them.
+	var extends []string
+	switch v := raw.Extends.(type) {
+	case string:
+		extends = []string{v}
+	case []interface{}:
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				extends = append(extends, s)
+			}
+		}
+	}
+	for _, e := range extends {
+		if !strings.HasPrefix(e, ".") {
+			continue // package-provided base configs live in node_modules
+		}
+		target := path.Join(dir, e)
+		if !strings.HasSuffix(target, ".json") {
+			target += ".json"
+		}
+		if base, _ := loadTSConfig(root, target, depth+1); base != nil {
+			cfg.baseURL = base.baseURL
+			for k, v := range base.paths {
+				cfg.paths[k] = v
+			}
+		}
+	}
+
+	if raw.CompilerOptions.BaseURL != nil {
+		cfg.baseURL = path.Join(dir, *raw.CompilerOptions.BaseURL)
+	}
+	if raw.CompilerOptions.Paths != nil {
+		// Paths are relative to baseUrl when set, otherwise to the config file.
+		base := dir
+		if cfg.baseURL != "" {
+			base = cfg.baseURL
+		}
+		cfg.paths = make(map[string][]string)
+		for pattern, targets := range raw.CompilerOptions.Paths {
+			for _, t := range targets {
+				cfg.paths[pattern] = append(cfg.paths[pattern], path.Join(base, t))
+			}
+		}
+	}
+
+	for pattern := range cfg.paths {
+		cfg.patterns = append(cfg.patterns, pattern)
+	}
+	// TypeScript prefers the pattern with the longest prefix before "*".
+	sort.Slice(cfg.patterns, func(i, j int) bool {
+		pi, pj := patternPrefix(cfg.patterns[i]), patternPrefix(cfg.patterns[j])
+		if len(pi) != len(pj) {
+			return len(pi) > len(pj)
+		}
+		return cfg.patterns[i] < cfg.patterns[j]
+	})
+
+	var refs []string
+	for _, ref := range raw.References {
+		target := path.Join(dir, ref.Path)
+		if !strings.HasSuffix(target, ".json") {
+			target = path.Join(target, "tsconfig.json")
+		}
+		refs = append(refs, target)
+	}
+	return cfg, refs
+}
+
+func patternPrefix(pattern string) string {
+	if i := strings.Index(pattern, "*"); i != -1 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// workspacePatterns returns the workspace globs from the root package.json
+// ("workspaces" as an array or {packages: [...]}) and pnpm-workspace.yaml.
+func workspacePatterns(root string) []string {
+	var patterns []string
+
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var pkg struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && len(pkg.Workspaces) > 0 {
+			var list []string
+			var obj struct {
+				Packages []string `json:"packages"`
+			}
+			if json.Unmarshal(pkg.Workspaces, &list) == nil {
+				patterns = append(patterns, list...)
+			} else if json.Unmarshal(pkg.Workspaces, &obj) == nil {
+				patterns = append(patterns, obj.Packages...)
+			}
+		}
+	}
+
+	// pnpm-workspace.yaml is a simple list; a line-based read avoids a YAML dependency.
+	if data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		inPackages := false
+		for _, line := range strings.Split(string(data), "\n") {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "packages:") {
+				inPackages = true
+				continue
+			}
+			if !inPackages || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if !strings.HasPrefix(trimmed, "-") {
+				inPackages = false
+				continue
+			}
+			item := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")), `"'`)
+			if item != "" && !strings.HasPrefix(item, "!") {
+				patterns = append(patterns, item)
+			}
+		}
+	}
+	return patterns
+}
+
+// matchWorkspace matches a root-relative directory against a workspace glob.
+// "*" matches one path segment and a trailing "/**" matches any depth.
+func matchWorkspace(pattern, dir string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return strings.HasPrefix(dir, prefix+"/")
+	}
+	ok, _ := path.Match(pattern, dir)
+	return ok
+}
+
+func (r *JSResolver) addPackage(root, dir string) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), "package.json"))
+	if err != nil {
+		return
+	}
+	var pkg struct {
+		Name    string      `json:"name"`
+		Source  string      `json:"source"`
+		Types   string      `json:"types"`
+		Module  string      `json:"module"`
+		Main    string      `json:"main"`
+		Exports interface{} `json:"exports"`
+	}
+	if json.Unmarshal(data, &pkg) != nil || pkg.Name == "" {
+		return
+	}
+
+	p := &jsPackage{dir: dir}
+	for _, entry := range []string{pkg.Source, pkg.Types, pkg.Module, pkg.Main, exportsEntry(pkg.Exports)} {
+		if entry != "" {
+			p.entries = append(p.entries, entry)
+		}
+	}
+	r.packages[pkg.Name] = p
+}
+
+// exportsEntry returns the string target of the "." export, if it has one.
+func exportsEntry(exports interface{}) string {
+	switch v := exports.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if dot, ok := v["."]; ok {
+			return exportsEntry(dot)
+		}
+		for _, cond := range []string{"source", "types", "import", "default", "require"} {
+			if s, ok := v[cond].(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// Resolve implements Resolver. Relative specifiers and tsconfig path aliases
+// that match no file are unresolved; other bare specifiers are external.
+func (r *JSResolver) Resolve(ix *Index, src, spec string) ([]string, ImportKind) {
+	if i := strings.IndexAny(spec, "?#"); i > 0 {
+		spec = spec[:i]
+	}
+	if spec == "" || strings.HasPrefix(spec, "node:") {
+		return nil, ImportExternal
+	}
+
+	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || spec == "." || spec == ".." {
+		files := probeJS(ix, path.Join(path.Dir(src), spec))
+		if files == nil && isAsset(spec) {
+			// Stylesheets, images and data files are not part of the code graph.
+			return nil, ImportExternal
+		}
+		return found(files)
+	}
+
+	aliased := false
+	for _, cfg := range r.configsFor(src) {
+		for _, pattern := range cfg.patterns {
+			wildcard, ok := matchPattern(pattern, spec)
+			if !ok {
+				continue
+			}
+			aliased = true
+			for _, target := range cfg.paths[pattern] {
+				if files := probeJS(ix, strings.Replace(target, "*", wildcard, 1)); files != nil {
+					return files, ImportLocal
+				}
+			}
+		}
+		if cfg.baseURL != "" {
+			if files := probeJS(ix, path.Join(cfg.baseURL, spec)); files != nil {
+				return files, ImportLocal
+			}
+		}
+	}
+
+	if files, ok := r.resolvePackage(ix, spec); ok {
+		return found(files)
+	}
+	if aliased {
+		return nil, ImportUnresolved
+	}
+	return nil, ImportExternal
+}
+
+// assetExtensions are non-code files commonly imported through bundlers.
+var assetExtensions = map[string]bool{
+	".css": true, ".scss": true, ".sass": true, ".less": true,
+	".svg": true, ".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".ico": true,
+	".json": true, ".html": true, ".md": true, ".wasm": true,
+	".woff": true, ".woff2": true, ".ttf": true,
+}
+
+// isAsset reports whether spec names a non-code file such as "./index.css".
+func isAsset(spec string) bool {
+	return assetExtensions[strings.ToLower(path.Ext(spec))]
+}
+
+// found classifies the result of probing a path that must be local.
+func found(files []string) ([]string, ImportKind) {
+	if files == nil {
+		return nil, ImportUnresolved
+	}
+	return files, ImportLocal
+}
+
+// configsFor returns the configs of the nearest directory above src that has any.
+func (r *JSResolver) configsFor(src string) []*tsConfig {
+	for dir := path.Dir(src); ; dir = path.Dir(dir) {
+		if cfgs, ok := r.configs[dir]; ok && len(cfgs) > 0 {
+			return cfgs
+		}
+		if dir == "." || dir == "/" {
+			return nil
+		}
+	}
+}
+
+// matchPattern matches a tsconfig "paths" key, returning the text matched by "*".
+func matchPattern(pattern, spec string) (string, bool) {
+	star := strings.Index(pattern, "*")
+	if star == -1 {
+		return "", pattern == spec
+	}
+	prefix, suffix := pattern[:star], pattern[star+1:]
+	if len(spec) < len(prefix)+len(suffix) || !strings.HasPrefix(spec, prefix) || !strings.HasSuffix(spec, suffix) {
+		return "", false
+	}
+	return spec[len(prefix) : len(spec)-len(suffix)], true
+}
+
+// resolvePackage resolves bare specifiers naming a workspace package, e.g.
+// "@acme/ui" or "@acme/ui/button". It reports false when spec names no
+// workspace package.
+func (r *JSResolver) resolvePackage(ix *Index, spec string) ([]string, bool) {
+	name, sub := spec, ""
+	parts := strings.SplitN(spec, "/", 3)
+	if strings.HasPrefix(spec, "@") && len(parts) >= 2 {
+		name = parts[0] + "/" + parts[1]
+		if len(parts) == 3 {
+			sub = parts[2]
+		}
+	} else if len(parts) >= 2 {
+		name, sub = parts[0], strings.Join(parts[1:], "/")
+	}
+
+	pkg, ok := r.packages[name]
+	if !ok {
+		return nil, false
+	}
+
+	if sub != "" {
+		for _, base := range []string{pkg.dir, path.Join(pkg.dir, "src")} {
+			if files := probeJS(ix, path.Join(base, sub)); files != nil {
+				return files, true
+			}
+		}
+		return nil, true
+	}
+
+	for _, entry := range pkg.entries {
+		if files := probeJS(ix, path.Join(pkg.dir, entry)); files != nil {
+			return files, true
+		}
+	}
+	// Entry points often name build output; fall back to the conventional sources.
+	for _, base := range []string{path.Join(pkg.dir, "src", "index"), path.Join(pkg.dir, "index")} {
+		if files := probeJS(ix, base); files != nil {
+			return files, true
+		}
+	}
+	return nil, true
+}
+
+// probeJS finds the file a module path refers to: the exact file, the
+// TypeScript source of an emitted .js path, the path plus an extension, or
+// an index file inside the directory.
+func probeJS(ix *Index, p string) []string {
+	p = path.Clean(p)
+	if ix.Has(p) {
+		return []string{p}
+	}
+
+	ext := path.Ext(p)
+	for _, srcExt := range jsSourceFor[ext] {
+		if candidate := strings.TrimSuffix(p, ext) + srcExt; ix.Has(candidate) {
+			return []string{candidate}
+		}
+	}
+	for _, e := range jsExtensions {
+		if ix.Has(p + e) {
+			return []string{p + e}
+		}
+	}
+	for _, e := range jsExtensions {
+		if candidate := path.Join(p, "index"+e); ix.Has(candidate) {
+			return []string{candidate}
+		}
+	}
+	return nil
+}
+
+// stripJSONC removes comments and trailing commas so tsconfig files can be
+// decoded with encoding/json.
+func stripJSONC(data []byte) []byte {
+	return stripTrailingCommas(stripComments(data))
+}
+
+func stripComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func stripTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		if c == ',' {
+			j := i + 1
+			for j < len(data) && (data[j] == ' ' || data[j] == '\t' || data[j] == '\n' || data[j] == '\r') {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
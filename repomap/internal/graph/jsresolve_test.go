@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestJSResolver(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"name": "monorepo", "private": true, "workspaces": ["packages/*", "apps/*"]}`,
+		"apps/web/tsconfig.json": `{
+	// comments and trailing commas are allowed
+	"extends": "./tsconfig.base",
+	"compilerOptions": {
+		"paths": {
+			"@/*": ["src/*"],
+			"@/hooks/*": ["src/lib/hooks/*",],
+		},
+	},
+}`,
+		"apps/web/tsconfig.base.json":                  `{"compilerOptions": {"baseUrl": "."}}`,
+		"apps/web/package.json":                        `{"name": "web"}`,
+		"apps/web/src/App.tsx":                         "",
+		"apps/web/src/components/Graph.tsx":            "",
+		"apps/web/src/components/index.ts":             "",
+		"apps/web/src/lib/hooks/useMap.ts":             "",
+		"apps/web/src/utils/format.ts":                 "",
+		"packages/ui/package.json":                     `{"name": "@acme/ui", "main": "dist/index.js"}`,
+		"packages/ui/src/index.ts":                     "",
+		"packages/ui/src/button.tsx":                   "",
+		"packages/config/package.json":                 `{"name": "@acme/config", "exports": {".": {"import": "./lib/main.mjs"}}}`,
+		"packages/config/lib/main.mjs":                 "",
+		"packages/not-a-workspace/nested/package.json": `{"name": "hidden"}`,
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	var known []string
+	for name := range files {
+		if IsJSFile(name) {
+			known = append(known, name)
+		}
+	}
+	ix := newIndex(known)
+
+	r := NewJSResolver(root, paths)
+	src := "apps/web/src/App.tsx"
+
+	tests := []struct {
+		spec string
+		want string
+		kind ImportKind
+	}{
+		{"./components/Graph", "apps/web/src/components/Graph.tsx", ImportLocal},
+		{"./components", "apps/web/src/components/index.ts", ImportLocal},
+		{"./utils/format.js", "apps/web/src/utils/format.ts", ImportLocal},
+		{"@/hooks/useMap", "apps/web/src/lib/hooks/useMap.ts", ImportLocal},
+		{"@/components/Graph", "apps/web/src/components/Graph.tsx", ImportLocal},
+		{"src/utils/format", "apps/web/src/utils/format.ts", ImportLocal}, // baseUrl
+		{"@acme/ui", "packages/ui/src/index.ts", ImportLocal},
+		{"@acme/ui/button", "packages/ui/src/button.tsx", ImportLocal},
+		{"@acme/config", "packages/config/lib/main.mjs", ImportLocal},
+		{"react", "", ImportExternal},
+		{"node:fs", "", ImportExternal},
+		{"hidden", "", ImportExternal},
+		{"./missing", "", ImportUnresolved},
+		{"./App.css", "", ImportExternal},
+		{"@/missing", "", ImportUnresolved},
+		{"@acme/ui/missing", "", ImportUnresolved},
+	}
+	for _, tt := range tests {
+		got, kind := r.Resolve(ix, src, tt.spec)
+		if kind != tt.kind {
+			t.Errorf("Resolve(%q) kind = %v, want %v", tt.spec, kind, tt.kind)
+		}
+		if tt.want == "" {
+			if len(got) != 0 {
+				t.Errorf("Resolve(%q) = %v, want no files", tt.spec, got)
+			}
+			continue
+		}
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("Resolve(%q) = %v, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestGraphBuilder_JSRelative(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddFile("src/App.tsx", []string{"./components/Graph", "./components/Graph", "react"})
+	builder.AddFile("src/components/Graph.tsx", []string{"../types"})
+	builder.AddFile("src/types.ts", nil)
+
+	g := builder.Build("")
+
+	if edges := g.Edges["src/App.tsx"]; len(edges) != 1 || edges[0] != "src/components/Graph.tsx" {
+		t.Errorf("expected a single deduplicated edge, got %v", edges)
+	}
+	edges := g.Edges["src/components/Graph.tsx"]
+	sort.Strings(edges)
+	if len(edges) != 1 || edges[0] != "src/types.ts" {
+		t.Errorf("expected edge to src/types.ts, got %v", edges)
+	}
+	if g.Nodes["src/types.ts"].InDegree != 1 {
+		t.Errorf("expected in-degree 1, got %d", g.Nodes["src/types.ts"].InDegree)
+	}
+}
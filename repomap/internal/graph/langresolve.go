@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"path"
+	"strings"
+)
+
+// PythonResolver resolves dotted module names to .py files or package
+// __init__.py files. Relative imports ("..utils") are resolved from the
+// importing file; absolute ones from its ancestor directories and "src".
+type PythonResolver struct{}
+
+func (PythonResolver) Resolve(ix *Index, src, imp string) ([]string, ImportKind) {
+	rest := strings.TrimLeft(imp, ".")
+	dots := len(imp) - len(rest)
+	modPath := strings.ReplaceAll(rest, ".", "/")
+
+	if dots > 0 {
+		base := path.Dir(src)
+		for i := 1; i < dots; i++ {
+			base = path.Dir(base)
+		}
+		return found(probePython(ix, path.Join(base, modPath)))
+	}
+
+	for _, root := range pythonRoots(src) {
+		if files := probePython(ix, path.Join(root, modPath)); files != nil {
+			return files, ImportLocal
+		}
+	}
+	return nil, ImportExternal
+}
+
+// pythonRoots lists the directories an absolute import may be relative to,
+// nearest first.
+func pythonRoots(src string) []string {
+	var roots []string
+	for dir := path.Dir(src); dir != "."; dir = path.Dir(dir) {
+		roots = append(roots, dir)
+	}
+	return append(roots, ".", "src")
+}
+
+func probePython(ix *Index, p string) []string {
+	for _, candidate := range []string{p + ".py", path.Join(p, "__init__.py")} {
+		if ix.Has(candidate) {
+			return []string{candidate}
+		}
+	}
+	return nil
+}
+
+// RustResolver resolves crate::, self:: and super:: paths to module files
+// (name.rs or name/mod.rs). Paths starting with any other crate are external.
+type RustResolver struct{}
+
+func (RustResolver) Resolve(ix *Index, src, imp string) ([]string, ImportKind) {
+	segments := strings.Split(imp, "::")
+
+	var start string
+	switch segments[0] {
+	case "crate":
+		start = rustCrateRoot(ix, src)
+		segments = segments[1:]
+	case "self", "super":
+		start = rustModuleDir(src)
+		for len(segments) > 0 && (segments[0] == "self" || segments[0] == "super") {
+			if segments[0] == "super" {
+				start = path.Dir(start)
+			}
+			segments = segments[1:]
+		}
+	default:
+		return nil, ImportExternal
+	}
+
+	// The longest prefix naming a module file wins; the rest are items inside it.
+	for n := len(segments); n > 0; n-- {
+		p := path.Join(start, path.Join(segments[:n]...))
+		for _, candidate := range []string{p + ".rs", path.Join(p, "mod.rs")} {
+			if ix.Has(candidate) {
+				return []string{candidate}, ImportLocal
+			}
+		}
+	}
+
+	// Items of the module itself live in its own file.
+	for _, candidate := range []string{path.Join(start, "mod.rs"), path.Join(start, "lib.rs"), path.Join(start, "main.rs"), start + ".rs"} {
+		if ix.Has(candidate) {
+			return []string{candidate}, ImportLocal
+		}
+	}
+	return nil, ImportUnresolved
+}
+
+// rustCrateRoot returns the directory of the crate root: the nearest
+// ancestor holding lib.rs or main.rs.
+func rustCrateRoot(ix *Index, src string) string {
+	for dir := path.Dir(src); ; dir = path.Dir(dir) {
+		if ix.Has(path.Join(dir, "lib.rs")) || ix.Has(path.Join(dir, "main.rs")) {
+			return dir
+		}
+		if dir == "." {
+			return path.Dir(src)
+		}
+	}
+}
+
+// rustModuleDir returns the directory holding the child modules of src.
+func rustModuleDir(src string) string {
+	switch path.Base(src) {
+	case "lib.rs", "main.rs", "mod.rs":
+		return path.Dir(src)
+	}
+	return strings.TrimSuffix(src, ".rs")
+}
+
+// CResolver resolves #include directives. Quoted includes are searched next
+// to the including file, at the root, under include/ and finally by path
+// suffix; angle-bracket includes are external unless found under include/.
+type CResolver struct{}
+
+func (CResolver) Resolve(ix *Index, src, imp string) ([]string, ImportKind) {
+	if system, ok := strings.CutPrefix(imp, "<"); ok {
+		system = strings.TrimSuffix(system, ">")
+		if p := path.Join("include", system); ix.Has(p) {
+			return []string{p}, ImportLocal
+		}
+		return nil, ImportExternal
+	}
+
+	for _, candidate := range []string{path.Join(path.Dir(src), imp), path.Clean(imp), path.Join("include", imp)} {
+		if ix.Has(candidate) {
+			return []string{candidate}, ImportLocal
+		}
+	}
+	if matches := ix.FilesWithSuffix(path.Clean(imp)); len(matches) == 1 {
+		return matches, ImportLocal
+	}
+	return nil, ImportUnresolved
+}
+
+// JavaResolver resolves fully qualified class names to .java files by path
+// suffix, so any source root layout (src/main/java, src, ...) works.
+type JavaResolver struct{}
+
+func (JavaResolver) Resolve(ix *Index, src, imp string) ([]string, ImportKind) {
+	parts := strings.Split(imp, ".")
+
+	if parts[len(parts)-1] == "*" {
+		pkgDir := path.Join(parts[:len(parts)-1]...)
+		var files []string
+		for _, dir := range ix.DirsWithSuffix(pkgDir) {
+			for _, f := range ix.FilesIn(dir) {
+				if strings.HasSuffix(f, ".java") {
+					files = append(files, f)
+				}
+			}
+		}
+		if files != nil {
+			return files, ImportLocal
+		}
+		return nil, javaMissKind(ix, pkgDir)
+	}
+
+	// Static imports name a member, so retry with the enclosing class.
+	for n := len(parts); n >= len(parts)-1 && n > 1; n-- {
+		if files := ix.FilesWithSuffix(path.Join(parts[:n]...) + ".java"); len(files) > 0 {
+			return files, ImportLocal
+		}
+	}
+	return nil, javaMissKind(ix, path.Join(parts[:len(parts)-1]...))
+}
+
+// javaMissKind reports an import as unresolved when its package exists locally.
+func javaMissKind(ix *Index, pkgDir string) ImportKind {
+	if len(ix.DirsWithSuffix(pkgDir)) > 0 {
+		return ImportUnresolved
+	}
+	return ImportExternal
+}
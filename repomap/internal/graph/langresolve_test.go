@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguageResolvers(t *testing.T) {
+	ix := newIndex([]string{
+		"app/__init__.py",
+		"app/views.py",
+		"app/utils.py",
+		"app/api/handlers.py",
+		"app/api/__init__.py",
+		"scripts/run.py",
+		"crate/src/lib.rs",
+		"crate/src/config.rs",
+		"crate/src/net/mod.rs",
+		"crate/src/net/client.rs",
+		"native/src/main.c",
+		"native/src/util.h",
+		"native/include/api.h",
+		"native/lib/deep/impl.h",
+		"java/src/main/java/com/acme/app/Main.java",
+		"java/src/main/java/com/acme/util/Strings.java",
+		"java/src/main/java/com/acme/model/User.java",
+		"java/src/main/java/com/acme/model/Order.java",
+	})
+
+	tests := []struct {
+		name     string
+		resolver Resolver
+		src      string
+		imp      string
+		want     []string
+		kind     ImportKind
+	}{
+		{"py relative parent", PythonResolver{}, "app/api/handlers.py", "..utils", []string{"app/utils.py"}, ImportLocal},
+		{"py relative sibling", PythonResolver{}, "app/views.py", ".utils", []string{"app/utils.py"}, ImportLocal},
+		{"py relative package", PythonResolver{}, "app/api/handlers.py", ".", []string{"app/api/__init__.py"}, ImportLocal},
+		{"py absolute", PythonResolver{}, "scripts/run.py", "app.api", []string{"app/api/__init__.py"}, ImportLocal},
+		{"py missing relative", PythonResolver{}, "app/views.py", ".nope", nil, ImportUnresolved},
+		{"py stdlib", PythonResolver{}, "app/views.py", "os.path", nil, ImportExternal},
+
+		{"rs crate", RustResolver{}, "crate/src/net/client.rs", "crate::config::Config", []string{"crate/src/config.rs"}, ImportLocal},
+		{"rs super", RustResolver{}, "crate/src/net/client.rs", "super::Pool", []string{"crate/src/net/mod.rs"}, ImportLocal},
+		{"rs mod decl", RustResolver{}, "crate/src/net/mod.rs", "self::client", []string{"crate/src/net/client.rs"}, ImportLocal},
+		{"rs lib mod decl", RustResolver{}, "crate/src/lib.rs", "self::net", []string{"crate/src/net/mod.rs"}, ImportLocal},
+		{"rs item in own module", RustResolver{}, "crate/src/lib.rs", "self::Thing", []string{"crate/src/lib.rs"}, ImportLocal},
+		{"rs std", RustResolver{}, "crate/src/lib.rs", "std::io", nil, ImportExternal},
+
+		{"c relative", CResolver{}, "native/src/main.c", "util.h", []string{"native/src/util.h"}, ImportLocal},
+		{"c parent", CResolver{}, "native/src/main.c", "../include/api.h", []string{"native/include/api.h"}, ImportLocal},
+		{"c suffix", CResolver{}, "native/src/main.c", "deep/impl.h", []string{"native/lib/deep/impl.h"}, ImportLocal},
+		{"c system", CResolver{}, "native/src/main.c", "<stdio.h>", nil, ImportExternal},
+		{"c missing", CResolver{}, "native/src/main.c", "gone.h", nil, ImportUnresolved},
+
+		{"java class", JavaResolver{}, "java/src/main/java/com/acme/app/Main.java", "com.acme.util.Strings", []string{"java/src/main/java/com/acme/util/Strings.java"}, ImportLocal},
+		{"java static", JavaResolver{}, "java/src/main/java/com/acme/app/Main.java", "com.acme.util.Strings.join", []string{"java/src/main/java/com/acme/util/Strings.java"}, ImportLocal},
+		{"java wildcard", JavaResolver{}, "java/src/main/java/com/acme/app/Main.java", "com.acme.model.*", []string{"java/src/main/java/com/acme/model/Order.java", "java/src/main/java/com/acme/model/User.java"}, ImportLocal},
+		{"java missing", JavaResolver{}, "java/src/main/java/com/acme/app/Main.java", "com.acme.util.Gone", nil, ImportUnresolved},
+		{"java jdk", JavaResolver{}, "java/src/main/java/com/acme/app/Main.java", "java.util.List", nil, ImportExternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, kind := tt.resolver.Resolve(ix, tt.src, tt.imp)
+			if kind != tt.kind {
+				t.Errorf("kind = %v, want %v", kind, tt.kind)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphBuilder_ImportKinds(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddFile("main.go", []string{"fmt", "github.com/example/repo/pkg/util", "github.com/example/repo/pkg/gone"})
+	builder.AddFile("pkg/util/util.go", nil)
+	builder.AddFile("tools/gen.py", []string{"os", ".missing"})
+
+	g := builder.Build("github.com/example/repo")
+
+	if !reflect.DeepEqual(g.External["main.go"], []string{"fmt"}) {
+		t.Errorf("unexpected external imports: %v", g.External["main.go"])
+	}
+	if !reflect.DeepEqual(g.Unresolved["main.go"], []string{"github.com/example/repo/pkg/gone"}) {
+		t.Errorf("unexpected unresolved imports: %v", g.Unresolved["main.go"])
+	}
+	if !reflect.DeepEqual(g.External["tools/gen.py"], []string{"os"}) || !reflect.DeepEqual(g.Unresolved["tools/gen.py"], []string{".missing"}) {
+		t.Errorf("unexpected python classification: external=%v unresolved=%v", g.External["tools/gen.py"], g.Unresolved["tools/gen.py"])
+	}
+}
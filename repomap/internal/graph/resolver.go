@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/modules"
+)
+
+// ImportKind classifies the outcome of resolving an import.
+type ImportKind int
+
+const (
+	// ImportLocal means the import resolved to files in the repository.
+	ImportLocal ImportKind = iota
+	// ImportExternal means the import names code outside the repository,
+	// such as the standard library or a third-party package.
+	ImportExternal
+	// ImportUnresolved means the import looks local (relative path, module
+	// prefix, crate path) but no matching file is known.
+	ImportUnresolved
+)
+
+// Resolver turns a raw import found in a source file into the files it refers to.
+// src is a root-relative, slash-separated path; imp is the import as extracted.
+type Resolver interface {
+	Resolve(ix *Index, src, imp string) ([]string, ImportKind)
+}
+
+// Index gives resolvers read access to the files known to the builder.
+type Index struct {
+	files  map[string]bool
+	dirs   map[string][]string
+	byName map[string][]string
+}
+
+func newIndex(paths []string) *Index {
+	ix := &Index{
+		files:  make(map[string]bool),
+		dirs:   make(map[string][]string),
+		byName: make(map[string][]string),
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		ix.files[p] = true
+		ix.dirs[path.Dir(p)] = append(ix.dirs[path.Dir(p)], p)
+		ix.byName[path.Base(p)] = append(ix.byName[path.Base(p)], p)
+	}
+	return ix
+}
+
+// Has reports whether the file exists in the index.
+func (ix *Index) Has(p string) bool {
+	return ix.files[p]
+}
+
+// FilesIn returns the files directly inside dir, sorted.
+func (ix *Index) FilesIn(dir string) []string {
+	return ix.dirs[dir]
+}
+
+// FilesWithSuffix returns files whose path equals suffix or ends with "/"+suffix.
+func (ix *Index) FilesWithSuffix(suffix string) []string {
+	var matches []string
+	for _, p := range ix.byName[path.Base(suffix)] {
+		if p == suffix || strings.HasSuffix(p, "/"+suffix) {
+			matches = append(matches, p)
+		}
+	}
+	return matches
+}
+
+// DirsWithSuffix returns directories whose path equals suffix or ends with "/"+suffix, sorted.
+func (ix *Index) DirsWithSuffix(suffix string) []string {
+	var matches []string
+	for dir := range ix.dirs {
+		if dir == suffix || strings.HasSuffix(dir, "/"+suffix) {
+			matches = append(matches, dir)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// GoResolver resolves Go import paths to every file of the imported package.
+type GoResolver struct {
+	Workspace *modules.Workspace
+}
+
+// Resolve implements Resolver. Imports under a workspace module that match no
+// directory are unresolved; everything else that is not found is external.
+func (r *GoResolver) Resolve(ix *Index, src, imp string) ([]string, ImportKind) {
+	if r.Workspace != nil {
+		if dir, ok := r.Workspace.ResolveImport(imp); ok {
+			if files := ix.FilesIn(dir); len(files) > 0 {
+				return files, ImportLocal
+			}
+			return nil, ImportUnresolved
+		}
+	}
+	return dirResolver{}.Resolve(ix, src, imp)
+}
+
+// dirResolver treats an import as a root-relative directory, falling back to
+// a vendored copy. It handles languages without a dedicated resolver.
+type dirResolver struct{}
+
+func (dirResolver) Resolve(ix *Index, src, imp string) ([]string, ImportKind) {
+	if files := ix.FilesIn(imp); len(files) > 0 {
+		return files, ImportLocal
+	}
+	if files := ix.FilesIn("vendor/" + imp); len(files) > 0 {
+		return files, ImportLocal
+	}
+	return nil, ImportExternal
+}
@@ -0,0 +1,143 @@
+package graph
+
+import "sort"
+
+// StronglyConnected returns the strongly connected components of the graph
+// that contain a cycle, found with Tarjan's algorithm. Members of each
+// component are sorted, and components are ordered by their first member.
+func (g *Graph) StronglyConnected() [][]string {
+	t := &tarjan{
+		g:       g,
+		index:   make(map[string]int),
+		lowlink: make(map[string]int),
+		onStack: make(map[string]bool),
+	}
+
+	nodes := make([]string, 0, len(g.Edges))
+	for n := range g.Edges {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	for _, n := range nodes {
+		if _, seen := t.index[n]; !seen {
+			t.connect(n)
+		}
+	}
+
+	sort.Slice(t.components, func(i, j int) bool {
+		return t.components[i][0] < t.components[j][0]
+	})
+	return t.components
+}
+
+type tarjan struct {
+	g          *Graph
+	next       int
+	index      map[string]int
+	lowlink    map[string]int
+	onStack    map[string]bool
+	stack      []string
+	components [][]string
+}
+
+func (t *tarjan) connect(v string) {
+	t.index[v] = t.next
+	t.lowlink[v] = t.next
+	t.next++
+	t.stack = append(t.stack, v)
+	t.onStack[v] = true
+
+	for _, w := range t.g.Edges[v] {
+		if _, seen := t.index[w]; !seen {
+			t.connect(w)
+			t.lowlink[v] = min(t.lowlink[v], t.lowlink[w])
+		} else if t.onStack[w] {
+			t.lowlink[v] = min(t.lowlink[v], t.index[w])
+		}
+	}
+
+	if t.lowlink[v] != t.index[v] {
+		return
+	}
+
+	var component []string
+	for {
+		w := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[w] = false
+		component = append(component, w)
+		if w == v {
+			break
+		}
+	}
+	if len(component) > 1 || t.g.hasEdge(v, v) {
+		sort.Strings(component)
+		t.components = append(t.components, component)
+	}
+}
+
+func (g *Graph) hasEdge(src, dst string) bool {
+	for _, d := range g.Edges[src] {
+		if d == dst {
+			return true
+		}
+	}
+	return false
+}
+
+// ShortestCycle returns a shortest cycle through the members of a strongly
+// connected component, starting and ending at the same node
+// (["a", "b", "a"]). Among cycles of equal length the one starting at the
+// lexically smallest node is chosen, so results are stable.
+func (g *Graph) ShortestCycle(component []string) []string {
+	members := make(map[string]bool, len(component))
+	for _, n := range component {
+		members[n] = true
+	}
+
+	var best []string
+	for _, start := range component {
+		if g.hasEdge(start, start) {
+			return []string{start, start}
+		}
+		if cycle := g.cycleFrom(start, members); cycle != nil && (best == nil || len(cycle) < len(best)) {
+			best = cycle
+		}
+	}
+	return best
+}
+
+// cycleFrom finds the shortest path from start back to itself within members
+// by breadth-first search.
+func (g *Graph) cycleFrom(start string, members map[string]bool) []string {
+	parent := map[string]string{}
+	frontier := []string{start}
+	for len(frontier) > 0 {
+		var next []string
+		for _, n := range frontier {
+			dsts := append([]string(nil), g.Edges[n]...)
+			sort.Strings(dsts)
+			for _, d := range dsts {
+				if !members[d] {
+					continue
+				}
+				if d == start {
+					path := []string{start}
+					for cur := n; cur != start; cur = parent[cur] {
+						path = append(path, cur)
+					}
+					for i, j := 1, len(path)-1; i < j; i, j = i+1, j-1 {
+						path[i], path[j] = path[j], path[i]
+					}
+					return append(path, start)
+				}
+				if _, seen := parent[d]; !seen {
+					parent[d] = n
+					next = append(next, d)
+				}
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
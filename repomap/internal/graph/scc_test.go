@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStronglyConnected(t *testing.T) {
+	g := &Graph{
+		Edges: map[string][]string{
+			// a -> b -> c -> a, plus a shortcut c -> b
+			"a": {"b"},
+			"b": {"c"},
+			"c": {"a", "b", "d"},
+			// d -> e -> d
+			"d": {"e"},
+			"e": {"d"},
+			// f is acyclic
+			"f": {"a"},
+			"s": {"s"},
+		},
+	}
+
+	sccs := g.StronglyConnected()
+	want := [][]string{{"a", "b", "c"}, {"d", "e"}, {"s"}}
+	if !reflect.DeepEqual(sccs, want) {
+		t.Fatalf("expected %v, got %v", want, sccs)
+	}
+
+	if cycle := g.ShortestCycle(sccs[0]); !reflect.DeepEqual(cycle, []string{"b", "c", "b"}) {
+		t.Errorf("expected minimal cycle b -> c -> b, got %v", cycle)
+	}
+	if cycle := g.ShortestCycle(sccs[1]); !reflect.DeepEqual(cycle, []string{"d", "e", "d"}) {
+		t.Errorf("unexpected cycle: %v", cycle)
+	}
+	if cycle := g.ShortestCycle(sccs[2]); !reflect.DeepEqual(cycle, []string{"s", "s"}) {
+		t.Errorf("unexpected self-loop cycle: %v", cycle)
+	}
+}
+
+func TestStronglyConnected_Acyclic(t *testing.T) {
+	g := &Graph{Edges: map[string][]string{"a": {"b"}, "b": {"c"}}}
+	if sccs := g.StronglyConnected(); len(sccs) != 0 {
+		t.Errorf("expected no components, got %v", sccs)
+	}
+}
@@ -0,0 +1,10 @@
+/*
+Package history reads per-file change history from a local git repository.
+
+Load runs git log offline and reports, for every file, its churn (the number
+of commits that changed it), the time it was last modified and the number of
+distinct authors. Outside a git work tree, or for files git does not track,
+the file system modification time stands in for the last change.
+Head reports the commit checked out and whether the work tree is dirty.
+*/
+package history
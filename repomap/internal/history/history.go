@@ -0,0 +1,194 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HalfLife is the age at which a file's recency drops to one half.
+const HalfLife = 30 * 24 * time.Hour
+
+// Stats is the change history of one file.
+type Stats struct {
+	// Churn is the number of commits that changed the file.
+	Churn int
+	// LastModified is the time of the latest commit changing the file, or
+	// its modification time when git has no record of it.
+	LastModified time.Time
+	// Authors is the number of distinct commit authors.
+	Authors int
+}
+
+// Recency maps the age of the last change to 0-1: 1 for a change made at
+// now, halving every HalfLife.
+func (s Stats) Recency(now time.Time) float64 {
+	if s.LastModified.IsZero() {
+		return 0
+	}
+	age := now.Sub(s.LastModified)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(HalfLife))
+}
+
+// History is the change history of a set of files.
+type History struct {
+	// Files maps a root-relative, slash-separated path to its stats.
+	Files map[string]Stats
+	// Git reports whether the history was read from git. When false, only
+	// modification times are known.
+	Git bool
+}
+
+// Load reads the history of the given root-relative paths from the git
+// work tree containing root, looking at no more than maxCommits commits
+// (0 for all). When root is not in a git work tree, or git is not
+// installed, it falls back to modification times.
+func Load(root string, paths []string, maxCommits int) (*History, error) {
+	h := &History{Files: make(map[string]Stats, len(paths))}
+
+	if isWorkTree(root) {
+		commits, err := gitLog(root, maxCommits)
+		if err != nil {
+			return nil, err
+		}
+		h.Git = true
+
+		wanted := make(map[string]bool, len(paths))
+		for _, p := range paths {
+			wanted[p] = true
+		}
+		authors := make(map[string]map[string]bool)
+		for _, c := range commits {
+			for _, p := range c.files {
+				if !wanted[p] {
+					continue
+				}
+				s := h.Files[p]
+				s.Churn++
+				if c.time.After(s.LastModified) {
+					s.LastModified = c.time
+				}
+				if authors[p] == nil {
+					authors[p] = make(map[string]bool)
+				}
+				authors[p][c.author] = true
+				s.Authors = len(authors[p])
+				h.Files[p] = s
+			}
+		}
+	}
+
+	// Files without commits (untracked, or no repository at all) fall back
+	// to their modification time.
+	for _, p := range paths {
+		if _, ok := h.Files[p]; ok {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(p))); err == nil {
+			h.Files[p] = Stats{LastModified: info.ModTime()}
+		}
+	}
+	return h, nil
+}
+
+// isWorkTree reports whether root is inside a git work tree.
+func isWorkTree(root string) bool {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// Revision identifies the checked-out state of a repository.
+type Revision struct {
+	// Commit is the full hash of HEAD, empty without a repository or commits.
+	Commit string
+	// Dirty reports uncommitted changes, untracked files included.
+	Dirty bool
+}
+
+// Head returns the commit checked out at root and whether the work tree has
+// uncommitted changes. Outside a git work tree it returns a zero Revision.
+func Head(root string) (Revision, error) {
+	var rev Revision
+	if !isWorkTree(root) {
+		return rev, nil
+	}
+	// A repository without commits has no HEAD yet.
+	if out, err := exec.Command("git", "-C", root, "rev-parse", "--verify", "-q", "HEAD").Output(); err == nil {
+		rev.Commit = strings.TrimSpace(string(out))
+	}
+	out, err := exec.Command("git", "-C", root, "status", "--porcelain", "--", ".").Output()
+	if err != nil {
+		return rev, fmt.Errorf("git status: %v", err)
+	}
+	rev.Dirty = len(bytes.TrimSpace(out)) > 0
+	return rev, nil
+}
+
+type commit struct {
+	time   time.Time
+	author string
+	files  []string
+}
+
+// commitMarker starts each commit header in the git log output.
+const commitMarker = "\x00commit "
+
+// gitLog lists the non-merge commits reachable from HEAD that changed
+// files under root, with those files relative to root. Renames are
+// reported as a deletion and an addition, so churn is not carried across
+// them.
+func gitLog(root string, maxCommits int) ([]commit, error) {
+	args := []string{"-C", root, "-c", "core.quotePath=false", "log", "--no-merges", "--no-renames", "--relative",
+		"--name-only", "--format=%x00commit %ct %aE"}
+	if maxCommits > 0 {
+		args = append(args, "-n", strconv.Itoa(maxCommits))
+	}
+	args = append(args, "--", ".")
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// A repository without commits has no history to read.
+		if strings.Contains(stderr.String(), "does not have any commits") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git log: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseLog(out)
+}
+
+// parseLog parses the output of gitLog.
+func parseLog(out []byte) ([]commit, error) {
+	var commits []commit
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, commitMarker); ok {
+			stamp, author, _ := strings.Cut(header, " ")
+			sec, err := strconv.ParseInt(stamp, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid commit time %q", stamp)
+			}
+			commits = append(commits, commit{time: time.Unix(sec, 0), author: strings.ToLower(author)})
+			continue
+		}
+		if line == "" || len(commits) == 0 {
+			continue
+		}
+		c := &commits[len(commits)-1]
+		c.files = append(c.files, line)
+	}
+	return commits, scanner.Err()
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLog(t *testing.T) {
+	out := []byte("\x00commit 1700000000 Alice@Example.com\n\na.go\nb.go\n\x00commit 1600000000 bob@example.com\n\na.go\n")
+	commits, err := parseLog(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].author != "alice@example.com" || len(commits[0].files) != 2 || !commits[0].time.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected first commit: %+v", commits[0])
+	}
+	if _, err := parseLog([]byte("\x00commit yesterday bob@example.com\n")); err == nil {
+		t.Error("expected an error for an invalid commit time")
+	}
+}
+
+func TestRecency(t *testing.T) {
+	now := time.Now()
+	if r := (Stats{LastModified: now}).Recency(now); r != 1 {
+		t.Errorf("expected 1 for a change made now, got %f", r)
+	}
+	if r := (Stats{LastModified: now.Add(-HalfLife)}).Recency(now); r < 0.499 || r > 0.501 {
+		t.Errorf("expected 0.5 after one half-life, got %f", r)
+	}
+	if r := (Stats{}).Recency(now); r != 0 {
+		t.Errorf("expected 0 without history, got %f", r)
+	}
+}
+
+func TestLoadGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(email, date string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=dev", "-c", "user.email=" + email, "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("", "", "init", "-q")
+	write("sub/a.go", "package sub")
+	write("sub/b.go", "package sub")
+	write("other.go", "package main")
+	git("alice@example.com", "2024-01-01T00:00:00Z", "add", "-A")
+	git("alice@example.com", "2024-01-01T00:00:00Z", "commit", "-q", "-m", "initial")
+	write("sub/a.go", "package sub // changed")
+	git("bob@example.com", "2024-02-01T00:00:00Z", "commit", "-q", "-am", "change a")
+	write("other.go", "package main // changed")
+	git("bob@example.com", "2024-03-01T00:00:00Z", "commit", "-q", "-am", "change other")
+	write("sub/new.go", "package sub")
+
+	h, err := Load(filepath.Join(dir, "sub"), []string{"a.go", "b.go", "new.go"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Git {
+		t.Fatal("expected history from git")
+	}
+	a := h.Files["a.go"]
+	if a.Churn != 2 || a.Authors != 2 || a.LastModified.UTC().Format("2006-01-02") != "2024-02-01" {
+		t.Errorf("unexpected stats for a.go: %+v", a)
+	}
+	if b := h.Files["b.go"]; b.Churn != 1 || b.Authors != 1 {
+		t.Errorf("unexpected stats for b.go: %+v", b)
+	}
+	if n := h.Files["new.go"]; n.Churn != 0 || n.LastModified.IsZero() {
+		t.Errorf("expected an untracked file to fall back to its modification time, got %+v", n)
+	}
+
+	// Only commits touching the root count towards maxCommits.
+	if h, err := Load(filepath.Join(dir, "sub"), []string{"a.go", "b.go"}, 1); err != nil || h.Files["a.go"].Churn != 1 || h.Files["b.go"].Churn != 0 {
+		t.Errorf("expected only the latest commit under sub/, got %+v (%v)", h.Files, err)
+	}
+
+	// sub/new.go is untracked, so the work tree is dirty.
+	rev, err := Head(dir)
+	if err != nil || len(rev.Commit) != 40 || !rev.Dirty {
+		t.Errorf("expected a dirty HEAD, got %+v (%v)", rev, err)
+	}
+	git("", "", "clean", "-fdq")
+	if rev, err := Head(dir); err != nil || rev.Dirty {
+		t.Errorf("expected a clean HEAD, got %+v (%v)", rev, err)
+	}
+}
+
+func TestLoadWithoutGit(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := Load(dir, []string{"a.go", "missing.go"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Git {
+		t.Error("expected no git history outside a repository")
+	}
+	if a := h.Files["a.go"]; a.LastModified.IsZero() || a.Churn != 0 {
+		t.Errorf("expected the modification time only, got %+v", a)
+	}
+	if _, ok := h.Files["missing.go"]; ok {
+		t.Error("expected no stats for a missing file")
+	}
+	if rev, err := Head(dir); err != nil || rev != (Revision{}) {
+		t.Errorf("expected no revision outside a repository, got %+v (%v)", rev, err)
+	}
+}
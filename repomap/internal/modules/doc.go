@@ -0,0 +1,8 @@
+/*
+Package modules discovers the Go modules that make up a repository.
+
+It includes:
+- A go.mod / go.work parser for module, require, use and replace directives, and a go.sum reader.
+- A Workspace that maps import paths to root-relative directories and files to their module.
+*/
+package modules
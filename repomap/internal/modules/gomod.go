@@ -0,0 +1,227 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// directive is a single statement from a go.mod or go.work file, with block
+// syntax flattened: `require ( a v1 )` becomes {Verb: "require", Args: [a v1]}.
+type directive struct {
+	Verb string
+	Args []string
+}
+
+// parseDirectives splits a go.mod or go.work file into directives.
+// Comments are stripped and quoted arguments are unquoted.
+func parseDirectives(data []byte) []directive {
+	var directives []directive
+	block := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		fields := splitFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			directives = append(directives, directive{Verb: block, Args: fields})
+			continue
+		}
+
+		if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		directives = append(directives, directive{Verb: fields[0], Args: fields[1:]})
+	}
+	return directives
+}
+
+// splitFields splits a line on whitespace, keeping quoted strings intact.
+func splitFields(line string) []string {
+	var fields []string
+	for {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return fields
+		}
+		if line[0] == '"' || line[0] == '`' {
+			end := strings.IndexByte(line[1:], line[0])
+			if end != -1 {
+				if s, err := strconv.Unquote(line[:end+2]); err == nil {
+					fields = append(fields, s)
+				} else {
+					fields = append(fields, line[1:end+1])
+				}
+				line = line[end+2:]
+				continue
+			}
+		}
+		end := strings.IndexAny(line, " \t")
+		if end == -1 {
+			return append(fields, line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+}
+
+// replacement is a replace directive that points at a local directory.
+type replacement struct {
+	// From is the replaced module path.
+	From string
+	// Dir is the replacement directory as written in the file.
+	Dir string
+}
+
+// goModFile holds the directives of a go.mod file that matter for resolution.
+type goModFile struct {
+	Module   string
+	Requires map[string]string
+	Replaces []replacement
+}
+
+// goWorkFile holds the directives of a go.work file that matter for resolution.
+type goWorkFile struct {
+	Uses     []string
+	Replaces []replacement
+}
+
+func parseGoMod(data []byte) goModFile {
+	f := goModFile{Requires: make(map[string]string)}
+	for _, d := range parseDirectives(data) {
+		switch d.Verb {
+		case "module":
+			if len(d.Args) > 0 {
+				f.Module = d.Args[0]
+			}
+		case "require":
+			if len(d.Args) >= 2 {
+				f.Requires[d.Args[0]] = d.Args[1]
+			}
+		case "replace":
+			if r, ok := parseReplace(d.Args); ok {
+				f.Replaces = append(f.Replaces, r)
+			}
+		}
+	}
+	return f
+}
+
+func parseGoWork(data []byte) goWorkFile {
+	var f goWorkFile
+	for _, d := range parseDirectives(data) {
+		switch d.Verb {
+		case "use":
+			if len(d.Args) > 0 {
+				f.Uses = append(f.Uses, d.Args[0])
+			}
+		case "replace":
+			if r, ok := parseReplace(d.Args); ok {
+				f.Replaces = append(f.Replaces, r)
+			}
+		}
+	}
+	return f
+}
+
+// parseReplace parses `old [version] => new [version]` and keeps it only when
+// new is a local path.
+func parseReplace(args []string) (replacement, bool) {
+	arrow := -1
+	for i, a := range args {
+		if a == "=>" {
+			arrow = i
+			break
+		}
+	}
+	if arrow < 1 || arrow+1 >= len(args) {
+		return replacement{}, false
+	}
+	target := args[arrow+1]
+	if !isLocalPath(target) {
+		return replacement{}, false
+	}
+	return replacement{From: args[0], Dir: target}, true
+}
+
+// isLocalPath reports whether a replace target is a filesystem path rather
+// than a module path, using the same rule as the go command.
+func isLocalPath(p string) bool {
+	return strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") ||
+		p == "." || p == ".." || strings.HasPrefix(p, "/") ||
+		strings.HasPrefix(p, `.\`) || strings.HasPrefix(p, `..\`) ||
+		(len(p) > 1 && p[1] == ':')
+}
+
+// ParseRequires returns the module paths and versions listed in the require
+// directives of a go.mod file.
+func ParseRequires(data []byte) map[string]string {
+	return parseGoMod(data).Requires
+}
+
+// ParseGoSum returns the highest version recorded for each module in a
+// go.sum file. Entries for go.mod files alone are included, since a module
+// may appear only that way when it contributes no packages.
+func ParseGoSum(data []byte) map[string]string {
+	versions := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		version := strings.TrimSuffix(fields[1], "/go.mod")
+		if current, ok := versions[fields[0]]; !ok || compareVersions(version, current) > 0 {
+			versions[fields[0]] = version
+		}
+	}
+	return versions
+}
+
+// compareVersions compares two semantic versions ("v1.2.3", "v0.0.0-2024...-abc")
+// and returns -1, 0 or +1. A release sorts after its pre-releases.
+func compareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(strings.TrimSuffix(aParts[i], "+incompatible"))
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(strings.TrimSuffix(bParts[i], "+incompatible"))
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	}
+	return 1
+}
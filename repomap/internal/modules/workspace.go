@@ -0,0 +1,190 @@
+package modules
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Module is a Go module found under the repository root.
+type Module struct {
+	// Path is the module path declared in go.mod.
+	Path string
+	// Dir is the module directory relative to the root ("." for the root module).
+	Dir string
+}
+
+// Workspace is the set of modules in a repository together with the local
+// replace directives that redirect import paths into it.
+type Workspace struct {
+	// Modules is sorted by Dir.
+	Modules []*Module
+	// replaces maps a module path to a root-relative directory.
+	replaces map[string]string
+}
+
+// Single returns a workspace holding one module rooted at ".".
+// It is used when only a module name is known.
+func Single(modulePath string) *Workspace {
+	ws := &Workspace{replaces: make(map[string]string)}
+	if modulePath != "" {
+		ws.Modules = append(ws.Modules, &Module{Path: modulePath, Dir: "."})
+	}
+	return ws
+}
+
+// RootModule returns the module path declared in the go.mod at root, or ""
+// if there is none.
+func RootModule(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return parseGoMod(data).Module
+}
+
+// FromFiles builds the workspace of root from the files found under it:
+// every go.mod among them, the modules listed in a root go.work file, and
+// the replace directives that point to local paths inside root. Files other
+// than go.mod are ignored, as are those in directories the go command
+// ignores (hidden, "_"-prefixed, testdata, vendor, node_modules).
+func FromFiles(root string, files []string) *Workspace {
+	ws := &Workspace{replaces: make(map[string]string)}
+	seen := make(map[string]bool)
+
+	addModule := func(dir string) {
+		if seen[dir] {
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), "go.mod"))
+		if err != nil {
+			return
+		}
+		seen[dir] = true
+		mod := parseGoMod(data)
+		if mod.Module != "" {
+			ws.Modules = append(ws.Modules, &Module{Path: mod.Module, Dir: dir})
+		}
+		for _, r := range mod.Replaces {
+			ws.addReplace(root, dir, r)
+		}
+	}
+
+	for _, p := range files {
+		if filepath.Base(p) != "go.mod" {
+			continue
+		}
+		if dir, ok := relDir(root, filepath.Dir(p)); ok && !ignoredPath(dir) {
+			addModule(dir)
+		}
+	}
+
+	// go.work may use modules in directories skipped above, and its replace
+	// directives apply to every module in the workspace.
+	if data, err := os.ReadFile(filepath.Join(root, "go.work")); err == nil {
+		work := parseGoWork(data)
+		for _, use := range work.Uses {
+			if dir, ok := relDir(root, filepath.Join(root, filepath.FromSlash(use))); ok {
+				addModule(dir)
+			}
+		}
+		for _, r := range work.Replaces {
+			ws.addReplace(root, ".", r)
+		}
+	}
+
+	sort.Slice(ws.Modules, func(i, j int) bool {
+		return ws.Modules[i].Dir < ws.Modules[j].Dir
+	})
+	return ws
+}
+
+// ignoredDir reports whether the go command ignores directories named name.
+func ignoredDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+		name == "testdata" || name == "vendor" || name == "node_modules"
+}
+
+// ignoredPath reports whether the root-relative directory dir lies in a
+// directory the go command ignores.
+func ignoredPath(dir string) bool {
+	if dir == "." {
+		return false
+	}
+	for _, name := range strings.Split(dir, "/") {
+		if ignoredDir(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// addReplace records a replace directive declared in the go.mod/go.work found in fromDir.
+func (ws *Workspace) addReplace(root, fromDir string, r replacement) {
+	target := filepath.Join(root, filepath.FromSlash(fromDir), filepath.FromSlash(r.Dir))
+	if filepath.IsAbs(r.Dir) {
+		target = r.Dir
+	}
+	if dir, ok := relDir(root, target); ok {
+		ws.replaces[r.From] = dir
+	}
+}
+
+// relDir returns dir relative to root in slash form, or false when dir is outside root.
+func relDir(root, dir string) (string, bool) {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
+
+// ModuleFor returns the module containing the root-relative file path, or nil.
+// Nested modules take precedence over the modules that enclose them.
+func (ws *Workspace) ModuleFor(relPath string) *Module {
+	relPath = filepath.ToSlash(relPath)
+	var best *Module
+	for _, m := range ws.Modules {
+		if m.Dir != "." && relPath != m.Dir && !strings.HasPrefix(relPath, m.Dir+"/") {
+			continue
+		}
+		if best == nil || len(m.Dir) > len(best.Dir) || best.Dir == "." {
+			best = m
+		}
+	}
+	return best
+}
+
+// ResolveImport maps an import path to the root-relative directory of the
+// package it names. The longest matching module path or replace directive wins.
+func (ws *Workspace) ResolveImport(importPath string) (string, bool) {
+	bestPrefix, bestDir := "", ""
+	consider := func(prefix, dir string) {
+		if prefix == "" || len(prefix) <= len(bestPrefix) {
+			return
+		}
+		if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+			bestPrefix, bestDir = prefix, dir
+		}
+	}
+
+	// Replace directives are explicit, so they win ties with module paths.
+	for from, dir := range ws.replaces {
+		consider(from, dir)
+	}
+	for _, m := range ws.Modules {
+		consider(m.Path, m.Dir)
+	}
+
+	if bestPrefix == "" {
+		return "", false
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(importPath, bestPrefix), "/")
+	return path.Join(bestDir, rest), true
+}
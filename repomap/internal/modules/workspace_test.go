@@ -0,0 +1,151 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles writes the files under root and returns their paths.
+func writeFiles(t *testing.T, root string, files map[string]string) []string {
+	t.Helper()
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestFromFiles(t *testing.T) {
+	root := t.TempDir()
+	files := writeFiles(t, root, map[string]string{
+		"go.work": `go 1.22
+
+use (
+	./services/api
+	./libs/core // shared code
+	./_tools
+)
+
+replace example.com/legacy => ./libs/legacy
+`,
+		"services/api/go.mod": `module "example.com/api"
+
+require example.com/core v0.0.0
+
+replace example.com/core v0.0.0 => ../../libs/core
+replace example.com/remote => example.com/fork v1.2.3
+`,
+		"libs/core/go.mod":       "module example.com/core\n",
+		"_tools/go.mod":          "module example.com/tools\n",
+		"testdata/mod/go.mod":    "module example.com/ignored\n",
+		"libs/core/sub/x/go.mod": "module example.com/core/sub/x\n",
+	})
+
+	ws := FromFiles(root, files)
+
+	dirs := make(map[string]string)
+	for _, m := range ws.Modules {
+		dirs[m.Path] = m.Dir
+	}
+	want := map[string]string{
+		"example.com/api":        "services/api",
+		"example.com/core":       "libs/core",
+		"example.com/tools":      "_tools",
+		"example.com/core/sub/x": "libs/core/sub/x",
+	}
+	if len(dirs) != len(want) {
+		t.Errorf("expected %d modules, got %v", len(want), dirs)
+	}
+	for path, dir := range want {
+		if dirs[path] != dir {
+			t.Errorf("module %s: expected dir %q, got %q", path, dir, dirs[path])
+		}
+	}
+
+	resolve := map[string]string{
+		"example.com/api/internal/handler": "services/api/internal/handler",
+		"example.com/core":                 "libs/core",
+		"example.com/core/sub/x/y":         "libs/core/sub/x/y",
+		"example.com/legacy/util":          "libs/legacy/util",
+	}
+	for imp, want := range resolve {
+		got, ok := ws.ResolveImport(imp)
+		if !ok || got != want {
+			t.Errorf("ResolveImport(%q) = %q, %v; want %q", imp, got, ok, want)
+		}
+	}
+	for _, imp := range []string{"fmt", "example.com/remote", "example.com/apix"} {
+		if got, ok := ws.ResolveImport(imp); ok {
+			t.Errorf("ResolveImport(%q) = %q, want unresolved", imp, got)
+		}
+	}
+
+	if m := ws.ModuleFor("libs/core/sub/x/y/file.go"); m == nil || m.Path != "example.com/core/sub/x" {
+		t.Errorf("ModuleFor picked %v, want nested module", m)
+	}
+	if m := ws.ModuleFor("libs/core/core.go"); m == nil || m.Path != "example.com/core" {
+		t.Errorf("ModuleFor picked %v, want example.com/core", m)
+	}
+	if m := ws.ModuleFor("scripts/run.go"); m != nil {
+		t.Errorf("ModuleFor picked %v for file outside any module", m)
+	}
+}
+
+func TestRootModule(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"go.mod": "module example.com/root\n"})
+	if m := RootModule(root); m != "example.com/root" {
+		t.Errorf("expected the root module, got %q", m)
+	}
+	if m := RootModule(t.TempDir()); m != "" {
+		t.Errorf("expected no module without a go.mod, got %q", m)
+	}
+}
+
+func TestSingle(t *testing.T) {
+	ws := Single("github.com/example/repo")
+	if got, ok := ws.ResolveImport("github.com/example/repo"); !ok || got != "." {
+		t.Errorf("expected root package to resolve to '.', got %q", got)
+	}
+	if m := ws.ModuleFor("main.go"); m == nil || m.Dir != "." {
+		t.Errorf("expected root module, got %v", m)
+	}
+	if len(Single("").Modules) != 0 {
+		t.Error("expected no modules for empty name")
+	}
+}
+
+func TestParseRequiresAndGoSum(t *testing.T) {
+	requires := ParseRequires([]byte(`module example.com/app
+
+require github.com/google/uuid v1.6.0
+
+require (
+	golang.org/x/net v0.38.0 // indirect
+)
+`))
+	if requires["github.com/google/uuid"] != "v1.6.0" || requires["golang.org/x/net"] != "v0.38.0" {
+		t.Errorf("unexpected requires: %v", requires)
+	}
+
+	sums := ParseGoSum([]byte(`github.com/google/uuid v1.5.0 h1:aaa=
+github.com/google/uuid v1.6.0 h1:bbb=
+github.com/google/uuid v1.6.0/go.mod h1:ccc=
+github.com/google/uuid v1.6.0-rc.1/go.mod h1:ddd=
+golang.org/x/net v0.0.0-20240101000000-abcdef/go.mod h1:eee=
+`))
+	if sums["github.com/google/uuid"] != "v1.6.0" {
+		t.Errorf("expected highest version v1.6.0, got %q", sums["github.com/google/uuid"])
+	}
+	if sums["golang.org/x/net"] != "v0.0.0-20240101000000-abcdef" {
+		t.Errorf("expected pseudo-version from go.mod-only entry, got %q", sums["golang.org/x/net"])
+	}
+}
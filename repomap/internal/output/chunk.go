@@ -0,0 +1,295 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Chunk places one chunk of a map split by Split among its siblings.
+type Chunk struct {
+	Number int `json:"number" xml:"number,attr"`
+	Total  int `json:"total" xml:"total,attr"`
+	// Index lists the chunk holding each package of the whole map, in rank
+	// order. A package too large for one chunk has an entry per chunk.
+	Index []ChunkEntry `json:"index" xml:"entry"`
+}
+
+// ChunkEntry locates the files of a package in a chunk.
+type ChunkEntry struct {
+	Package string `json:"package" xml:"package,attr"`
+	Chunk   int    `json:"chunk" xml:"chunk,attr"`
+	Files   int    `json:"files" xml:"files,attr"`
+}
+
+// chunkUnit is a file or package node placed in a chunk, with the package
+// it is grouped under.
+type chunkUnit struct {
+	pkg  string
+	file *FileNode
+	node *PackageNode
+}
+
+// Split partitions the whole map into chunks that each encode within
+// chunkTokens in the given format. Files are grouped by package (their
+// directory), packages ordered by their highest-ranked file, and a package
+// starts a new chunk rather than being split unless it is too large for one
+// chunk by itself. Maps at package or directory granularity are split
+// between packages. Every chunk repeats the metadata and carries the index
+// of all chunks; dependencies go to the first one.
+//
+// The chunks are measured once encoded, and packed again with less room
+// when one exceeds chunkTokens. A file or package too large for a chunk of
+// its own is reduced to fit, as Render does.
+func Split(m *RepoMap, format string, opts Options, chunkTokens int) ([]*Rendered, error) {
+	if IsReport(format) {
+		return nil, fmt.Errorf("the %s report covers the whole map and cannot be split", format)
+	}
+	opts.MaxTokens = chunkTokens
+	encode, err := encoder(format, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	units := chunkUnits(m)
+	empty, err := encodeCounted(encode, &RepoMap{})
+	if err != nil {
+		return nil, err
+	}
+	costs := make([]int, len(units))
+	all := make([]int, len(units))
+	for i := range units {
+		alone := chunkMap(m, units[i:i+1], nil)
+		alone.Meta = nil
+		r, err := encodeCounted(encode, alone)
+		if err != nil {
+			return nil, err
+		}
+		costs[i] = max(r.Tokens-empty.Tokens, 1)
+		all[i] = i
+	}
+
+	// The header and index are measured with every package in a chunk
+	// numbered as high as the chunks can go.
+	worst := chunkIndex(units, [][]int{all})
+	for i := range worst {
+		worst[i].Chunk = len(units)
+	}
+	header, err := encodeCounted(encode, stamp(chunkMap(m, nil, &Chunk{Number: len(units), Total: len(units), Index: worst})))
+	if err != nil {
+		return nil, err
+	}
+	budget := chunkTokens - header.Tokens
+	if budget <= 0 {
+		return nil, fmt.Errorf("chunks of %d tokens leave no room after the chunk header and index (%d tokens)", chunkTokens, header.Tokens)
+	}
+	// The first chunk keeps room for the dependencies.
+	reserve := 0
+	if len(m.Dependencies) > 0 {
+		deps, err := encodeCounted(encode, &RepoMap{Dependencies: m.Dependencies})
+		if err != nil {
+			return nil, err
+		}
+		reserve = deps.Tokens - empty.Tokens
+	}
+
+	var chunks []*RepoMap
+	for attempt := 0; attempt < repackAttempts && budget > 0; attempt++ {
+		chunks = buildChunks(m, units, assignChunks(units, costs, budget, reserve))
+		rendered := make([]*Rendered, len(chunks))
+		excess := 0
+		for i, c := range chunks {
+			if rendered[i], err = encodeCounted(encode, stamp(c)); err != nil {
+				return nil, err
+			}
+			if over := rendered[i].Tokens - chunkTokens; over > 0 && len(c.Files)+len(c.Packages) > 1 {
+				excess = max(excess, over)
+			}
+		}
+		if excess == 0 {
+			return reduceOversized(chunks, rendered, format, opts)
+		}
+		budget -= excess
+	}
+
+	// Packing did not settle; reduce whatever still exceeds the budget.
+	rendered := make([]*Rendered, len(chunks))
+	for i, c := range chunks {
+		if rendered[i], err = encodeCounted(encode, stamp(c)); err != nil {
+			return nil, err
+		}
+	}
+	return reduceOversized(chunks, rendered, format, opts)
+}
+
+// reduceOversized renders the chunks that exceed opts.MaxTokens again
+// within it.
+func reduceOversized(chunks []*RepoMap, rendered []*Rendered, format string, opts Options) ([]*Rendered, error) {
+	for i, r := range rendered {
+		if r.Tokens <= opts.MaxTokens {
+			continue
+		}
+		reduced, err := Render(chunks[i], format, opts)
+		if err != nil {
+			return nil, err
+		}
+		rendered[i] = reduced
+	}
+	return rendered, nil
+}
+
+// chunkUnits lists the files of the map grouped by package, or its
+// packages, in rank order.
+func chunkUnits(m *RepoMap) []chunkUnit {
+	units := make([]chunkUnit, 0, len(m.Files)+len(m.Packages))
+	for _, p := range m.Packages {
+		units = append(units, chunkUnit{pkg: p.Path, node: p})
+	}
+
+	var order []string
+	byPkg := make(map[string][]*FileNode)
+	for _, f := range m.Files {
+		dir := path.Dir(f.Path)
+		if _, ok := byPkg[dir]; !ok {
+			order = append(order, dir)
+		}
+		byPkg[dir] = append(byPkg[dir], f)
+	}
+	for _, dir := range order {
+		for _, f := range byPkg[dir] {
+			units = append(units, chunkUnit{pkg: dir, file: f})
+		}
+	}
+	return units
+}
+
+// assignChunks packs the units, given their costs, into chunks holding at
+// most budget tokens, the first of which already holds reserve tokens, and
+// returns the units of each chunk by position.
+func assignChunks(units []chunkUnit, costs []int, budget, reserve int) [][]int {
+	var chunks [][]int
+	var current []int
+	used := reserve
+	flush := func() {
+		if len(current) > 0 || used > 0 {
+			chunks = append(chunks, current)
+		}
+		current, used = nil, 0
+	}
+
+	for start := 0; start < len(units); {
+		end, groupCost := start, 0
+		for end < len(units) && units[end].pkg == units[start].pkg {
+			groupCost += costs[end]
+			end++
+		}
+		// A package that fits a chunk is not split across two.
+		if used+groupCost > budget && groupCost <= budget {
+			flush()
+		}
+		for i := start; i < end; i++ {
+			if (len(current) > 0 || used > 0) && used+costs[i] > budget {
+				flush()
+			}
+			current = append(current, i)
+			used += costs[i]
+		}
+		start = end
+	}
+	flush()
+	if len(chunks) == 0 {
+		// An empty map still makes one chunk.
+		chunks = append(chunks, nil)
+	}
+	return chunks
+}
+
+// chunkIndex builds the index of the assigned chunks, numbered from 1.
+func chunkIndex(units []chunkUnit, assigned [][]int) []ChunkEntry {
+	var index []ChunkEntry
+	for n, positions := range assigned {
+		number := n + 1
+		for _, i := range positions {
+			u := units[i]
+			if last := len(index) - 1; last >= 0 && index[last].Package == u.pkg && index[last].Chunk == number {
+				index[last].Files += u.files()
+				continue
+			}
+			index = append(index, ChunkEntry{Package: u.pkg, Chunk: number, Files: u.files()})
+		}
+	}
+	return index
+}
+
+// files counts the files the unit stands for.
+func (u chunkUnit) files() int {
+	if u.node != nil {
+		return u.node.Files
+	}
+	return 1
+}
+
+// buildChunks returns the maps of the assigned chunks.
+func buildChunks(m *RepoMap, units []chunkUnit, assigned [][]int) []*RepoMap {
+	index := chunkIndex(units, assigned)
+	chunks := make([]*RepoMap, len(assigned))
+	for n, positions := range assigned {
+		members := make([]chunkUnit, len(positions))
+		for i, p := range positions {
+			members[i] = units[p]
+		}
+		chunks[n] = chunkMap(m, members, &Chunk{Number: n + 1, Total: len(assigned), Index: index})
+	}
+	return chunks
+}
+
+// chunkMap returns the map of one chunk holding the units. The first chunk
+// also lists the dependencies of the map.
+func chunkMap(m *RepoMap, units []chunkUnit, chunk *Chunk) *RepoMap {
+	c := &RepoMap{Meta: m.Meta, Name: m.Name, Chunk: chunk}
+	inChunk := make(map[string]bool)
+	for _, u := range units {
+		if u.file != nil {
+			c.Files = append(c.Files, u.file)
+			inChunk[u.file.Path] = true
+		} else {
+			c.Packages = append(c.Packages, u.node)
+		}
+	}
+	c.Edges = filterEdges(m.Edges, func(e *Edge) bool { return inChunk[e.From] })
+	if chunk != nil && chunk.Number == 1 {
+		c.Dependencies = m.Dependencies
+	}
+	return c
+}
+
+// JoinJSON joins chunks rendered as JSON into one JSON array.
+func JoinJSON(chunks []*Rendered) (*Rendered, error) {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i, c := range chunks {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  ")
+		if err := json.Indent(&b, bytes.TrimSpace(c.Data), "  ", "  "); err != nil {
+			return nil, fmt.Errorf("chunk %d is not JSON: %w", i+1, err)
+		}
+	}
+	b.WriteString("\n]\n")
+	return &Rendered{Data: b.Bytes(), Tokens: CountTokens(b.String())}, nil
+}
+
+// writeTextChunk writes the position of a chunk and the chunk index as
+// comment lines.
+func writeTextChunk(b *strings.Builder, c *Chunk) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(b, "# chunk %d of %d; packages by chunk:\n", c.Number, c.Total)
+	for _, e := range c.Index {
+		fmt.Fprintf(b, "#   %s: chunk %d (%d files)\n", e.Package, e.Chunk, e.Files)
+	}
+}
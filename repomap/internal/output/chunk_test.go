@@ -0,0 +1,142 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+)
+
+// chunkFixture returns a map of three packages of four files each, ranked
+// so that the files of each package are interleaved.
+func chunkFixture() *RepoMap {
+	m := &RepoMap{
+		Meta:         &Metadata{Version: "dev", Root: ".", Tokenizer: "heuristic"},
+		Dependencies: []*Dependency{{Name: "example.com/dep", Ecosystem: "go", Files: []string{"a/file0.go"}}},
+	}
+	for i := 0; i < 4; i++ {
+		for _, pkg := range []string{"a", "b", "c"} {
+			m.Files = append(m.Files, &FileNode{
+				Path: fmt.Sprintf("%s/file%d.go", pkg, i), Language: "go", Importance: "medium",
+				Rank:        1 - float64(len(m.Files))/20,
+				Definitions: []string{fmt.Sprintf("func %s%d(context.Context, string) error", strings.ToUpper(pkg), i)},
+			})
+		}
+	}
+	return m
+}
+
+func TestSplit(t *testing.T) {
+	const budget = 600
+	chunks, err := Split(chunkFixture(), "json", Options{}, budget)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected the map to span several chunks, got %d", len(chunks))
+	}
+
+	seen := make(map[string]int)
+	for n, c := range chunks {
+		if c.Tokens > budget {
+			t.Errorf("chunk %d has %d tokens, over the budget of %d", n+1, c.Tokens, budget)
+		}
+		var m RepoMap
+		if err := json.Unmarshal(c.Data, &m); err != nil {
+			t.Fatalf("chunk %d is not JSON: %v", n+1, err)
+		}
+		if m.Chunk == nil || m.Chunk.Number != n+1 || m.Chunk.Total != len(chunks) {
+			t.Fatalf("chunk %d has the wrong position: %+v", n+1, m.Chunk)
+		}
+		if m.Meta == nil || m.SchemaVersion != SchemaVersion {
+			t.Errorf("chunk %d should repeat the header", n+1)
+		}
+		if (len(m.Dependencies) > 0) != (n == 0) {
+			t.Errorf("only the first chunk should list dependencies, chunk %d has %d", n+1, len(m.Dependencies))
+		}
+		for _, f := range m.Files {
+			if f.Detail != "" {
+				t.Errorf("%s should be shown in full", f.Path)
+			}
+			seen[f.Path] = n + 1
+		}
+
+		// The index says where every file is.
+		for _, f := range m.Files {
+			if !hasEntry(m.Chunk.Index, path.Dir(f.Path), n+1) {
+				t.Errorf("index does not place %s in chunk %d: %+v", f.Path, n+1, m.Chunk.Index)
+			}
+		}
+		files := 0
+		for _, e := range m.Chunk.Index {
+			files += e.Files
+		}
+		if files != 12 {
+			t.Errorf("index of chunk %d counts %d files, want 12", n+1, files)
+		}
+	}
+	if len(seen) != 12 {
+		t.Errorf("expected every file in exactly one chunk, got %d files", len(seen))
+	}
+	// Packages are ordered by their best file and not split when they fit.
+	for _, pkg := range []string{"a", "b", "c"} {
+		if seen[pkg+"/file0.go"] != seen[pkg+"/file3.go"] {
+			t.Errorf("package %s is split across chunks: %v", pkg, seen)
+		}
+	}
+	if seen["a/file0.go"] > seen["c/file0.go"] {
+		t.Errorf("package a should come before package c: %v", seen)
+	}
+
+	joined, err := JoinJSON(chunks)
+	if err != nil {
+		t.Fatalf("JoinJSON failed: %v", err)
+	}
+	var all []RepoMap
+	if err := json.Unmarshal(joined.Data, &all); err != nil || len(all) != len(chunks) {
+		t.Errorf("expected a JSON array of %d chunks, got %d (%v)", len(chunks), len(all), err)
+	}
+}
+
+func hasEntry(index []ChunkEntry, pkg string, chunk int) bool {
+	for _, e := range index {
+		if e.Package == pkg && e.Chunk == chunk {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSplit_LargePackage(t *testing.T) {
+	m := chunkFixture()
+	for _, f := range m.Files {
+		f.Path = "pkg/" + strings.ReplaceAll(f.Path, "/", "_")
+	}
+	chunks, err := Split(m, "text", Options{}, 150)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected the package to continue in another chunk, got %d chunks", len(chunks))
+	}
+	for n, c := range chunks {
+		if c.Tokens > 150 {
+			t.Errorf("chunk %d has %d tokens, over the budget", n+1, c.Tokens)
+		}
+	}
+	first := string(chunks[0].Data)
+	if !strings.Contains(first, fmt.Sprintf("# chunk 1 of %d; packages by chunk:\n#   pkg: chunk 1 (", len(chunks))) {
+		t.Errorf("text chunk should start with its position and the index:\n%s", first)
+	}
+	if !strings.Contains(first, fmt.Sprintf("#   pkg: chunk %d (", len(chunks))) {
+		t.Errorf("index should list every chunk holding the package:\n%s", first)
+	}
+
+	if _, err := Split(m, "text", Options{}, 10); err == nil {
+		t.Error("expected error for chunks smaller than their header")
+	}
+	if _, err := Split(m, "html", Options{}, 150); err == nil {
+		t.Error("expected error for splitting a report")
+	}
+}
@@ -4,6 +4,17 @@ Package output handles the rendering of the repository map into various formats.
 Supported formats:
 - XML: A structured XML representation suitable for LLM consumption.
 - JSON: A standard JSON representation.
+- Text: An indented listing for human inspection.
+- Tree: A folded view of each file's definition lines and enclosing scopes.
+- Markdown: A prompt-oriented document, in a detailed or compact layout.
+- Pack: A markdown overview followed by the source of the top files and a manifest.
+- SARIF: The analysis issues as a SARIF 2.1.0 log for code scanning.
+- HTML: A self-contained report with the dependency graph, issues and plan status.
+- Templates: User-defined or built-in text/template renderings of the map.
+
+Every format goes through Render, which fits the map into the token budget;
+the SARIF and HTML reports always cover the whole map.
+Split instead spreads the whole map over chunks that each fit a budget.
 
 It also includes token counting utilities to ensure the output respects a given token budget.
 */
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+)
+
+//go:embed report
+var reportFiles embed.FS
+
+// reportTemplate is the page of the html format. Its stylesheet and script
+// are inlined so the report opens offline, without a server.
+var reportTemplate = template.Must(template.ParseFS(reportFiles, "report/report.html"))
+
+// reportPage fills reportTemplate.
+type reportPage struct {
+	Title string
+	CSS   template.CSS
+	JS    template.JS
+	// Data is the JSON of the map, read by the script.
+	Data template.JS
+}
+
+// reportData is the map embedded in an HTML report.
+type reportData struct {
+	Name         string         `json:"name,omitempty"`
+	Meta         *Metadata      `json:"metadata,omitempty"`
+	Files        []*FileNode    `json:"files"`
+	Packages     []*PackageNode `json:"packages"`
+	Edges        []reportEdge   `json:"edges"`
+	Dependencies []*Dependency  `json:"dependencies"`
+}
+
+type reportEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Weight int    `json:"weight"`
+}
+
+// encodeHTML renders the map as a single HTML page holding the map, its
+// dependency graph drawn with a force layout, the analysis issues and the
+// plan status of the files.
+func encodeHTML(m *RepoMap) ([]byte, error) {
+	report := reportData{
+		Name:         m.Name,
+		Meta:         m.Meta,
+		Files:        m.Files,
+		Packages:     m.Packages,
+		Dependencies: m.Dependencies,
+	}
+	for _, e := range m.Edges {
+		report.Edges = append(report.Edges, reportEdge{From: e.From, To: e.To, Weight: e.Weight})
+	}
+	// json escapes <, > and &, so the data cannot close its script element.
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal report data: %w", err)
+	}
+	css, err := reportFiles.ReadFile("report/report.css")
+	if err != nil {
+		return nil, err
+	}
+	js, err := reportFiles.ReadFile("report/report.js")
+	if err != nil {
+		return nil, err
+	}
+
+	title := "Repository map"
+	if m.Name != "" {
+		title += " of " + m.Name
+	}
+	var b bytes.Buffer
+	page := reportPage{Title: title, CSS: template.CSS(css), JS: template.JS(js), Data: template.JS(data)}
+	if err := reportTemplate.Execute(&b, page); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeHTML(t *testing.T) {
+	m := renderFixture(3)
+	m.Name = "<demo>"
+	m.Edges = []*Edge{{From: "pkg/file01.go", To: "pkg/file00.go", Weight: 1}}
+	m.Files[0].Status = "planned"
+	m.Files[1].Issues = []Issue{{
+		Type: "duplication", Severity: "medium", Description: "copied </script><script>alert(1)</script>",
+		Locations: []Location{{Path: "pkg/file01.go", Line: 4, EndLine: 9}},
+	}}
+
+	r, err := Render(m, "html", Options{MaxTokens: 10})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	page := string(r.Data)
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") || !strings.Contains(page, "<title>Repository map of &lt;demo&gt;</title>") {
+		t.Errorf("expected an HTML page titled after the escaped name:\n%.300s", page)
+	}
+	for _, external := range []string{"src=", "href=", "http://", "https://", "@import"} {
+		if strings.Contains(page, external) {
+			t.Errorf("report should not load anything, found %q", external)
+		}
+	}
+	if strings.Count(page, "</script>") != 2 {
+		t.Errorf("expected the data and the script as the only script elements, got %d", strings.Count(page, "</script>"))
+	}
+
+	const open = `<script type="application/json" id="repomap-data">`
+	start := strings.Index(page, open) + len(open)
+	end := start + strings.Index(page[start:], "</script>")
+	var data reportData
+	if err := json.Unmarshal([]byte(page[start:end]), &data); err != nil {
+		t.Fatalf("embedded data is not JSON: %v", err)
+	}
+	// The whole map is embedded whatever the budget.
+	if len(data.Files) != 3 || data.Files[0].Status != "planned" || len(data.Edges) != 1 {
+		t.Errorf("unexpected embedded map: %+v", data)
+	}
+	if issues := data.Files[1].Issues; len(issues) != 1 || issues[0].Locations[0].Line != 4 {
+		t.Errorf("expected the issue with its location, got %+v", issues)
+	}
+}
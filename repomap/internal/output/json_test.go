@@ -50,21 +50,28 @@ func TestRenderJSON(t *testing.T) {
 	}
 
 	// Case 2: Budget limit
-	// Wrapper ~3 tokens. Node 1 cost 100. Total 103.
-	// We set limit 110. Node 2 cost 50. 103 + 50 = 153 > 110.
+	// TokenCount sets the packing estimate: 150 for both files in full, so
+	// at 136 pkg/utils.go is reduced to names. The encoded JSON must still
+	// fit the budget itself.
 
-	jsonOutputLimited, err := RenderJSON(nodes, 110)
+	jsonOutputLimited, err := RenderJSON(nodes, 136)
 	if err != nil {
 		t.Fatalf("RenderJSON limited failed: %v", err)
 	}
+	if got := CountTokens(jsonOutputLimited); got > 136 {
+		t.Errorf("Limited JSON exceeds the budget: %d tokens", got)
+	}
 
-	if !strings.Contains(jsonOutputLimited, "main.go") {
-		t.Error("Limited JSON should contain main.go")
+	if !strings.Contains(jsonOutputLimited, `"func main()"`) {
+		t.Error("Limited JSON should keep the signature of main.go")
+	}
+	if !strings.Contains(jsonOutputLimited, `"detail": "names"`) || !strings.Contains(jsonOutputLimited, `"Helper"`) {
+		t.Errorf("Limited JSON should reduce pkg/utils.go to names:\n%s", jsonOutputLimited)
 	}
-	if strings.Contains(jsonOutputLimited, "pkg/utils.go") {
-		t.Error("Limited JSON should NOT contain pkg/utils.go")
+	if strings.Contains(jsonOutputLimited, `"func Helper()"`) {
+		t.Error("Limited JSON should NOT contain the signature of pkg/utils.go")
 	}
-	if !strings.Contains(jsonOutputLimited, "Output truncated") {
-		t.Error("Limited JSON should contain truncation notice")
+	if !strings.Contains(jsonOutputLimited, `"level": "names"`) {
+		t.Errorf("Limited JSON should report the elision:\n%s", jsonOutputLimited)
 	}
 }
@@ -0,0 +1,293 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Markdown layouts.
+const (
+	// LayoutDetailed gives every file its own section with its intent,
+	// importance, rank, definitions and issues.
+	LayoutDetailed = "detailed"
+	// LayoutCompact lists each file on one line above its definitions and
+	// leaves out intents and the directory tree.
+	LayoutCompact = "compact"
+)
+
+// Layouts lists the markdown layouts accepted in Options.
+var Layouts = []string{LayoutDetailed, LayoutCompact}
+
+// tokenPasses bounds the re-renders needed for the header to report the
+// token count of the document that contains it.
+const tokenPasses = 3
+
+func markdownEncoder(opts Options) (func(*RepoMap) ([]byte, error), error) {
+	layout := opts.Layout
+	if layout == "" {
+		layout = LayoutDetailed
+	}
+	if layout != LayoutDetailed && layout != LayoutCompact {
+		return nil, fmt.Errorf("unsupported markdown layout: %s", layout)
+	}
+	return func(m *RepoMap) ([]byte, error) {
+		return encodeMarkdown(m, layout, opts.MaxTokens), nil
+	}, nil
+}
+
+// encodeMarkdown renders the map for a chat prompt: a header, a directory
+// tree of the files shown, then the files in rank order.
+func encodeMarkdown(m *RepoMap, layout string, maxTokens int) []byte {
+	var body strings.Builder
+	writeMarkdownChunk(&body, m.Chunk)
+	if layout == LayoutDetailed && len(m.Files) > 0 {
+		body.WriteString("## Directory tree\n\n```\n")
+		writeDirectoryTree(&body, m.Files)
+		body.WriteString("```\n\n")
+	}
+	if len(m.Files) > 0 {
+		body.WriteString("## Files\n\n")
+	}
+	for _, f := range m.Files {
+		if layout == LayoutCompact {
+			writeCompactFile(&body, f)
+		} else {
+			writeDetailedFile(&body, f)
+		}
+	}
+	writeMarkdownPackages(&body, m.Packages)
+	writeMarkdownSummary(&body, m)
+
+	return []byte(withTokenHeader(body.String(), func(tokens int) string {
+		return markdownHeader(m, "Repository map", tokens, maxTokens) + "\n"
+	}))
+}
+
+// withTokenHeader prepends the header to body. The header reports the
+// tokens of the whole document, itself included, so it is rendered until
+// the count settles.
+func withTokenHeader(body string, header func(tokens int) string) string {
+	tokens := CountTokens(body)
+	var doc string
+	for pass := 0; pass < tokenPasses; pass++ {
+		doc = header(tokens) + body
+		if n := CountTokens(doc); n != tokens {
+			tokens = n
+			continue
+		}
+		break
+	}
+	return doc
+}
+
+// markdownHeader writes the title and the list of facts about the map
+// that opens a markdown document.
+func markdownHeader(m *RepoMap, title string, tokens, maxTokens int) string {
+	var b strings.Builder
+	if m.Name != "" {
+		fmt.Fprintf(&b, "# %s: %s\n\n", title, m.Name)
+	} else {
+		fmt.Fprintf(&b, "# %s\n\n", title)
+	}
+
+	if meta := m.Meta; meta != nil && meta.Commit != "" {
+		fmt.Fprintf(&b, "- Commit: `%s`", shortCommit(meta.Commit))
+		if meta.Dirty {
+			b.WriteString(" (with uncommitted changes)")
+		}
+		b.WriteString("\n")
+	}
+	if modules := mapModules(m); len(modules) == 1 {
+		fmt.Fprintf(&b, "- Module: `%s`\n", modules[0])
+	} else if len(modules) > 1 {
+		fmt.Fprintf(&b, "- Modules: `%s`\n", strings.Join(modules, "`, `"))
+	}
+	if c := m.Chunk; c != nil {
+		fmt.Fprintf(&b, "- Chunk: %d of %d\n", c.Number, c.Total)
+	}
+	if len(m.Packages) > 0 {
+		fmt.Fprintf(&b, "- Packages: %d\n", len(m.Packages))
+	} else {
+		fmt.Fprintf(&b, "- Files: %d of %d shown\n", len(m.Files), mapStats(m).Files)
+	}
+	if maxTokens > 0 {
+		fmt.Fprintf(&b, "- Tokens: %d of %d\n", tokens, maxTokens)
+	} else {
+		fmt.Fprintf(&b, "- Tokens: %d\n", tokens)
+	}
+	return b.String()
+}
+
+// mapModules returns the sorted module paths of the map, from its metadata
+// or else from its files or packages.
+func mapModules(m *RepoMap) []string {
+	if m.Meta != nil && len(m.Meta.Modules) > 0 {
+		return m.Meta.Modules
+	}
+	seen := make(map[string]bool)
+	for _, f := range m.Files {
+		if f.Module != "" {
+			seen[f.Module] = true
+		}
+	}
+	for _, p := range m.Packages {
+		if p.Module != "" {
+			seen[p.Module] = true
+		}
+	}
+	modules := make([]string, 0, len(seen))
+	for mod := range seen {
+		modules = append(modules, mod)
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// writeDirectoryTree writes the paths of the files as an indented tree,
+// directories first at each level.
+func writeDirectoryTree(b *strings.Builder, files []*FileNode) {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	sort.Slice(paths, func(i, j int) bool { return treeLess(paths[i], paths[j]) })
+
+	var open []string
+	for _, p := range paths {
+		dirs := strings.Split(p, "/")
+		name := dirs[len(dirs)-1]
+		dirs = dirs[:len(dirs)-1]
+
+		common := 0
+		for common < len(open) && common < len(dirs) && open[common] == dirs[common] {
+			common++
+		}
+		open = open[:common]
+		for _, dir := range dirs[common:] {
+			fmt.Fprintf(b, "%s%s/\n", strings.Repeat("  ", len(open)), dir)
+			open = append(open, dir)
+		}
+		fmt.Fprintf(b, "%s%s\n", strings.Repeat("  ", len(open)), name)
+	}
+}
+
+// treeLess orders paths as in a directory tree: by component, with the
+// subdirectories of a directory before its files.
+func treeLess(a, b string) bool {
+	pa, pb := strings.Split(a, "/"), strings.Split(b, "/")
+	for k := 0; k < len(pa) && k < len(pb); k++ {
+		if pa[k] == pb[k] {
+			continue
+		}
+		if dirA, dirB := k < len(pa)-1, k < len(pb)-1; dirA != dirB {
+			return dirA
+		}
+		return pa[k] < pb[k]
+	}
+	return len(pa) < len(pb)
+}
+
+func writeDetailedFile(b *strings.Builder, f *FileNode) {
+	fmt.Fprintf(b, "### `%s`\n\n", f.Path)
+	attrs := []string{"importance: " + f.Importance, fmt.Sprintf("rank %.2f", f.Rank)}
+	if f.Intent != "" {
+		attrs = append([]string{"Intent: " + f.Intent}, attrs...)
+	}
+	if f.Status != "" {
+		attrs = append(attrs, "status: "+f.Status)
+	}
+	if f.Detail != "" {
+		attrs = append(attrs, f.Detail+" only")
+	}
+	fmt.Fprintf(b, "%s\n\n", strings.Join(attrs, " · "))
+	writeFence(b, f.Language, f.Definitions)
+	writeIssues(b, f.Issues)
+}
+
+func writeCompactFile(b *strings.Builder, f *FileNode) {
+	fmt.Fprintf(b, "**`%s`** (%s", f.Path, f.Importance)
+	if f.Detail != "" {
+		fmt.Fprintf(b, ", %s only", f.Detail)
+	}
+	b.WriteString(")\n")
+	writeFence(b, f.Language, f.Definitions)
+	writeIssues(b, f.Issues)
+}
+
+// writeFence writes lines as a fenced code block tagged with the language.
+func writeFence(b *strings.Builder, language string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "```%s\n", language)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s\n", line)
+	}
+	b.WriteString("```\n\n")
+}
+
+func writeIssues(b *strings.Builder, issues []Issue) {
+	for _, issue := range issues {
+		fmt.Fprintf(b, "- **%s** %s: %s\n", issue.Severity, issue.Type, issue.Description)
+	}
+	if len(issues) > 0 {
+		b.WriteString("\n")
+	}
+}
+
+func writeMarkdownPackages(b *strings.Builder, pkgs []*PackageNode) {
+	if len(pkgs) == 0 {
+		return
+	}
+	b.WriteString("## Packages\n\n")
+	for _, p := range pkgs {
+		fmt.Fprintf(b, "### `%s/`\n\n", p.Path)
+		fmt.Fprintf(b, "Importance: %s · rank %.2f · %d files · %d definitions\n\n", p.Importance, p.Rank, p.Files, p.Definitions)
+		writeFence(b, "", p.Symbols)
+		for _, d := range p.Dependencies {
+			fmt.Fprintf(b, "- depends on `%s/` (%d)\n", d.Path, d.Weight)
+		}
+		if len(p.Dependencies) > 0 {
+			b.WriteString("\n")
+		}
+	}
+}
+
+// writeMarkdownChunk writes the chunk index of a split map.
+func writeMarkdownChunk(b *strings.Builder, c *Chunk) {
+	if c == nil {
+		return
+	}
+	b.WriteString("## Chunk index\n\n")
+	for _, e := range c.Index {
+		fmt.Fprintf(b, "- `%s/`: chunk %d (%d files)\n", e.Package, e.Chunk, e.Files)
+	}
+	b.WriteString("\n")
+}
+
+// writeMarkdownSummary writes what was left out to fit the budget and the
+// third-party dependencies.
+func writeMarkdownSummary(b *strings.Builder, m *RepoMap) {
+	if len(m.Rollups) > 0 || len(m.Elided) > 0 {
+		b.WriteString("## Not shown in full\n\n")
+		for _, r := range m.Rollups {
+			fmt.Fprintf(b, "- `%s/`: %d files, %d symbols\n", r.Path, r.Files, r.Symbols)
+		}
+		for _, e := range m.Elided {
+			fmt.Fprintf(b, "- %d files (%d symbols) reduced to %s\n", e.Files, e.Symbols, e.Level)
+		}
+		b.WriteString("\n")
+	}
+	if len(m.Dependencies) > 0 {
+		b.WriteString("## Dependencies\n\n")
+		for _, d := range m.Dependencies {
+			version := d.Version
+			if version == "" {
+				version = "unknown version"
+			}
+			fmt.Fprintf(b, "- `%s` %s (%s, %d files)\n", d.Name, version, d.Ecosystem, len(d.Files))
+		}
+		b.WriteString("\n")
+	}
+}
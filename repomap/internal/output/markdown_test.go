@@ -0,0 +1,69 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func markdownFixture() *RepoMap {
+	return &RepoMap{
+		Name: "repo",
+		Files: []*FileNode{
+			{
+				Path: "internal/server/server.go", Language: "go", Importance: "high", Rank: 1,
+				Module: "example.com/repo", Intent: "Infrastructure",
+				Definitions: []string{"type Server struct", "func (*Server) Start() error"},
+				Issues:      []Issue{{Type: "duplication", Severity: "medium", Description: "Similar to client.go"}},
+			},
+			{Path: "main.go", Language: "go", Importance: "low", Rank: 0.2, Module: "example.com/repo", Definitions: []string{"func main()"}},
+			{Path: "internal/config.go", Language: "go", Importance: "low", Rank: 0.1, Module: "example.com/repo"},
+		},
+	}
+}
+
+func TestRender_Markdown(t *testing.T) {
+	r, err := Render(markdownFixture(), "markdown", Options{})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := string(r.Data)
+	for _, s := range []string{
+		"# Repository map: repo\n",
+		"- Module: `example.com/repo`\n",
+		"- Files: 3 of 3 shown\n",
+		fmt.Sprintf("- Tokens: %d\n", r.Tokens),
+		"```\ninternal/\n  server/\n    server.go\n  config.go\nmain.go\n```\n",
+		"### `internal/server/server.go`\n\nIntent: Infrastructure · importance: high · rank 1.00\n",
+		"```go\ntype Server struct\nfunc (*Server) Start() error\n```\n",
+		"- **medium** duplication: Similar to client.go\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("markdown output missing %q:\n%s", s, out)
+		}
+	}
+	if strings.Index(out, "server.go`") > strings.Index(out, "main.go`") {
+		t.Error("expected files in rank order")
+	}
+}
+
+func TestRender_MarkdownCompact(t *testing.T) {
+	r, err := Render(markdownFixture(), "markdown", Options{Layout: LayoutCompact, MaxTokens: 1000})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := string(r.Data)
+	if strings.Contains(out, "## Directory tree") || strings.Contains(out, "Infrastructure") {
+		t.Errorf("compact layout should leave out the tree and intents:\n%s", out)
+	}
+	if !strings.Contains(out, "**`main.go`** (low)\n```go\nfunc main()\n```\n") {
+		t.Errorf("unexpected compact file entry:\n%s", out)
+	}
+	if !strings.Contains(out, fmt.Sprintf("- Tokens: %d of 1000\n", r.Tokens)) {
+		t.Errorf("expected the header to report %d tokens:\n%s", r.Tokens, out)
+	}
+
+	if _, err := Render(markdownFixture(), "markdown", Options{Layout: "wide"}); err == nil {
+		t.Error("expected error for an unknown layout")
+	}
+}
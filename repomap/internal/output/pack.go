@@ -0,0 +1,283 @@
+package output
+
+import (
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Detail levels of a file in a packed map, from most to least detailed.
+const (
+	// DetailFull keeps every definition signature.
+	DetailFull = "full"
+	// DetailNames keeps only the names of the definitions.
+	DetailNames = "names"
+	// DetailPath keeps only the file path.
+	DetailPath = "path"
+	// DetailRollup counts the file in a roll-up of its directory.
+	DetailRollup = "rollup"
+	// DetailOmitted leaves the file out entirely.
+	DetailOmitted = "omitted"
+)
+
+// detailLevels lists the levels in the order files are degraded.
+var detailLevels = []string{DetailFull, DetailNames, DetailPath, DetailRollup, DetailOmitted}
+
+// Rollup summarizes the files of a directory that did not fit in the
+// token budget individually.
+type Rollup struct {
+	Path    string `json:"path" xml:"path,attr"`
+	Files   int    `json:"files" xml:"files,attr"`
+	Symbols int    `json:"symbols" xml:"symbols,attr"`
+}
+
+// Elision reports how many files, and how many of their symbols, were
+// reduced to a detail level to fit the token budget.
+type Elision struct {
+	Level   string `json:"level" xml:"level,attr"`
+	Files   int    `json:"files" xml:"files,attr"`
+	Symbols int    `json:"symbols" xml:"symbols,attr"`
+}
+
+// Packing is the result of fitting files into a token budget.
+type Packing struct {
+	Files   []*FileNode
+	Rollups []*Rollup
+	Elided  []Elision
+}
+
+// elisionCost is the cost of one entry of the elision report.
+const elisionCost = 10
+
+// PackFiles fits files, sorted by rank, into maxTokens, spending overhead
+// tokens on the document wrapper. Instead of stopping at the first file
+// that does not fit, detail is degraded from the lowest-ranked file up:
+// signatures are reduced to names, then to the path, then the file is
+// rolled up into its directory. Files are dropped only when even the
+// roll-ups do not fit. Left-over budget is then spent on restoring detail
+// in rank order, skipping files that do not fit. Degraded files are
+// copies; the input is not modified. If maxTokens is 0, no limit is applied.
+func PackFiles(files []*FileNode, maxTokens, overhead int) Packing {
+	if maxTokens <= 0 {
+		return Packing{Files: files}
+	}
+
+	p := newPacker(files, overhead)
+
+	// Degrade from the tail until the map fits.
+	for i := len(files) - 1; i >= 0 && p.total > maxTokens; i-- {
+		for p.level[i] < 3 && p.total > maxTokens {
+			p.set(i, p.level[i]+1)
+		}
+	}
+	for i := len(files) - 1; i >= 0 && p.total > maxTokens; i-- {
+		p.set(i, 4)
+	}
+
+	// Restore detail in rank order where the budget allows.
+	for i := range files {
+		for level := 0; level < p.level[i]; level++ {
+			if p.total+p.delta(i, level) <= maxTokens {
+				p.set(i, level)
+				break
+			}
+		}
+	}
+	return p.result()
+}
+
+// packer tracks the detail level of every file and the total cost.
+type packer struct {
+	files []*FileNode
+	level []int
+	// costs caches the cost of each file at the first three levels.
+	costs [][3]int
+	// rolled counts the rolled-up files per directory.
+	rolled map[string]int
+	// counts is the number of files at each level.
+	counts [5]int
+	total  int
+}
+
+func newPacker(files []*FileNode, overhead int) *packer {
+	p := &packer{
+		files:  files,
+		level:  make([]int, len(files)),
+		costs:  make([][3]int, len(files)),
+		rolled: make(map[string]int),
+		total:  overhead,
+	}
+	for i, f := range files {
+		p.costs[i] = [3]int{fileCost(f), namesCost(f), pathCost(f)}
+		p.total += p.costs[i][0]
+	}
+	p.counts[0] = len(files)
+	return p
+}
+
+// cost returns the tokens file i adds at a level. A roll-up costs nothing
+// once its directory is already rolled up.
+func (p *packer) cost(i, level int) int {
+	switch level {
+	case 0, 1, 2:
+		return p.costs[i][level]
+	case 3:
+		dir := path.Dir(p.files[i].Path)
+		n := p.rolled[dir]
+		if p.level[i] == 3 {
+			n--
+		}
+		if n > 0 {
+			return 0
+		}
+		return rollupCost(dir)
+	}
+	return 0
+}
+
+// delta returns the change in total cost of moving file i to level,
+// including elision report entries that appear or disappear.
+func (p *packer) delta(i, level int) int {
+	from := p.level[i]
+	d := p.cost(i, level) - p.cost(i, from)
+	if from > 0 && p.counts[from] == 1 {
+		d -= elisionCost
+	}
+	if level > 0 && p.counts[level] == 0 {
+		d += elisionCost
+	}
+	return d
+}
+
+func (p *packer) set(i, level int) {
+	p.total += p.delta(i, level)
+	p.counts[p.level[i]]--
+	p.counts[level]++
+	dir := path.Dir(p.files[i].Path)
+	if p.level[i] == 3 {
+		p.rolled[dir]--
+	}
+	if level == 3 {
+		p.rolled[dir]++
+	}
+	p.level[i] = level
+}
+
+func (p *packer) result() Packing {
+	var packed Packing
+	counts := make([]Elision, len(detailLevels))
+	rollups := make(map[string]*Rollup)
+
+	for i, f := range p.files {
+		level := detailLevels[p.level[i]]
+		counts[p.level[i]].Files++
+		counts[p.level[i]].Symbols += len(f.Definitions)
+
+		switch level {
+		case DetailFull:
+			packed.Files = append(packed.Files, f)
+		case DetailNames, DetailPath:
+			degraded := *f
+			degraded.Detail = level
+			degraded.Definitions, degraded.Outline, degraded.Source = nil, nil, nil
+			if level == DetailNames {
+				for _, def := range f.Definitions {
+					degraded.Definitions = append(degraded.Definitions, DefinitionName(f.Language, def))
+				}
+			}
+			packed.Files = append(packed.Files, &degraded)
+		case DetailRollup:
+			dir := path.Dir(f.Path)
+			r, ok := rollups[dir]
+			if !ok {
+				r = &Rollup{Path: dir}
+				rollups[dir] = r
+				packed.Rollups = append(packed.Rollups, r)
+			}
+			r.Files++
+			r.Symbols += len(f.Definitions)
+		}
+	}
+
+	for i, c := range counts[1:] {
+		if c.Files > 0 {
+			c.Level = detailLevels[i+1]
+			packed.Elided = append(packed.Elided, c)
+		}
+	}
+	return packed
+}
+
+// fileCost estimates the tokens a file takes with full detail, which is
+// its outline when one was extracted. A known TokenCount takes precedence.
+func fileCost(f *FileNode) int {
+	if f.TokenCount > 0 {
+		return f.TokenCount
+	}
+	cost := pathCost(f)
+	if len(f.Outline) > 0 {
+		for _, l := range f.Outline {
+			cost += CountTokens(l.Text) + 2 // +2 for the line number
+		}
+		return cost
+	}
+	for _, def := range f.Definitions {
+		cost += CountTokens(def)
+	}
+	return cost
+}
+
+func namesCost(f *FileNode) int {
+	cost := pathCost(f)
+	for _, def := range f.Definitions {
+		cost += CountTokens(DefinitionName(f.Language, def)) + 1
+	}
+	return cost
+}
+
+func pathCost(f *FileNode) int {
+	return CountTokens(f.Path) + 5 // +5 for attributes overhead
+}
+
+func rollupCost(dir string) int {
+	return CountTokens(dir) + 8
+}
+
+// definitionKeywords are the modifiers and keywords that precede the name
+// in definitions of languages other than Go.
+var definitionKeywords = map[string]bool{
+	"export": true, "default": true, "declare": true, "async": true, "function": true, "function*": true,
+	"def": true, "class": true, "interface": true, "type": true, "enum": true, "struct": true,
+	"fn": true, "pub": true, "pub(crate)": true, "impl": true, "trait": true, "mod": true,
+	"const": true, "let": true, "var": true, "static": true, "public": true, "private": true,
+	"protected": true, "abstract": true, "final": true, "readonly": true, "func": true,
+}
+
+// DefinitionName returns the declared name of a definition line, such as
+// "Start" for "func (*Server) Start() error" or "render" for
+// "export function render(props)". The definition itself is returned when
+// no name is found.
+func DefinitionName(language, def string) string {
+	if language == "go" {
+		if name := goDefinitionName(def); name != "" {
+			return name
+		}
+		return def
+	}
+	for _, field := range strings.Fields(def) {
+		if definitionKeywords[field] {
+			continue
+		}
+		end := strings.IndexFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '$'
+		})
+		if end == 0 {
+			break
+		}
+		if end > 0 {
+			field = field[:end]
+		}
+		return field
+	}
+	return def
+}
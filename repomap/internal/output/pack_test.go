@@ -0,0 +1,128 @@
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestPackFilesDegradesTailFirst(t *testing.T) {
+	files := []*FileNode{
+		{Path: "core/engine.go", Language: "go", Definitions: []string{"func (*Engine) Run(ctx context.Context) error", "type Engine struct"}},
+	}
+	for i := 0; i < 6; i++ {
+		files = append(files, &FileNode{
+			Path:        fmt.Sprintf("util/helper%d.go", i),
+			Language:    "go",
+			Definitions: []string{fmt.Sprintf("func Helper%d(input string, options ...Option) (string, error)", i)},
+		})
+	}
+
+	full := 0
+	for _, f := range files {
+		full += fileCost(f)
+	}
+	packed := PackFiles(files, full-3, 0)
+
+	if packed.Files[0] != files[0] {
+		t.Errorf("expected the top file to keep full detail, got %+v", packed.Files[0])
+	}
+	last := packed.Files[len(packed.Files)-1]
+	if last.Path != "util/helper5.go" || last.Detail != DetailNames || !reflect.DeepEqual(last.Definitions, []string{"Helper5"}) {
+		t.Errorf("expected the last file reduced to names, got %+v", last)
+	}
+	if files[6].Detail != "" || len(files[6].Definitions[0]) < 10 {
+		t.Error("PackFiles must not modify its input")
+	}
+	if len(packed.Elided) == 0 || packed.Elided[0].Level != DetailNames {
+		t.Errorf("expected names elisions to be reported, got %+v", packed.Elided)
+	}
+
+	// A small budget rolls the tail up by directory.
+	packed = PackFiles(files, 40, 0)
+	if len(packed.Rollups) != 1 || packed.Rollups[0].Path != "util" {
+		t.Fatalf("expected util/ to be rolled up, got %+v", packed.Rollups)
+	}
+	elided := map[string]Elision{}
+	listed := len(packed.Files)
+	for _, e := range packed.Elided {
+		elided[e.Level] = e
+	}
+	if r := elided[DetailRollup]; r.Files != packed.Rollups[0].Files || r.Symbols != packed.Rollups[0].Symbols {
+		t.Errorf("rollup report %+v does not match %+v", r, packed.Rollups[0])
+	}
+	if listed+packed.Rollups[0].Files+elided[DetailOmitted].Files != len(files) {
+		t.Errorf("every file should be listed, rolled up or omitted: %+v", packed)
+	}
+	if cost := packedCost(packed); cost > 40 {
+		t.Errorf("packed map costs %d, over the budget of 40", cost)
+	}
+}
+
+func TestPackFilesSkipsWhatDoesNotFit(t *testing.T) {
+	files := []*FileNode{
+		{Path: "big.go", Language: "go", Definitions: []string{"func Big()"}, TokenCount: 500},
+		{Path: "a.go", Language: "go", Definitions: []string{"func A()"}},
+		{Path: "b.go", Language: "go", Definitions: []string{"func B()"}},
+	}
+	packed := PackFiles(files, 60, 0)
+
+	if len(packed.Files) != 3 {
+		t.Fatalf("expected all files to be listed, got %d", len(packed.Files))
+	}
+	if packed.Files[0].Detail == "" {
+		t.Error("expected the oversized file to be degraded")
+	}
+	for _, f := range packed.Files[1:] {
+		if f.Detail != "" {
+			t.Errorf("expected %s to keep full detail, got %s", f.Path, f.Detail)
+		}
+	}
+
+	if packed := PackFiles(files, 0, 0); len(packed.Files) != 3 || packed.Elided != nil {
+		t.Errorf("expected no packing without a budget, got %+v", packed)
+	}
+}
+
+// packedCost recomputes the cost of a packing with the packer's estimates.
+func packedCost(p Packing) int {
+	cost := elisionCost * len(p.Elided)
+	for _, f := range p.Files {
+		switch f.Detail {
+		case DetailNames:
+			cost += pathCost(f)
+			for _, name := range f.Definitions {
+				cost += CountTokens(name) + 1
+			}
+		case DetailPath:
+			cost += pathCost(f)
+		default:
+			cost += fileCost(f)
+		}
+	}
+	for _, r := range p.Rollups {
+		cost += rollupCost(r.Path)
+	}
+	return cost
+}
+
+func TestDefinitionName(t *testing.T) {
+	tests := []struct {
+		lang, def, want string
+	}{
+		{"go", "func (*Server) Start() error", "Start"},
+		{"go", "type Config struct", "Config"},
+		{"ts", "export function render(props: Props)", "render"},
+		{"ts", "export default class App", "App"},
+		{"py", "def _parse(self, text)", "_parse"},
+		{"py", "class Index:", "Index"},
+		{"rs", "pub fn resolve(ix: &Index) -> Vec<String>", "resolve"},
+		{"java", "public static void main(String[] args)", "void"},
+		{"c", "#define MAX 10", "#define MAX 10"},
+	}
+	for _, tt := range tests {
+		if got := DefinitionName(tt.lang, tt.def); got != tt.want {
+			t.Errorf("DefinitionName(%q, %q) = %q, want %q", tt.lang, tt.def, got, tt.want)
+		}
+	}
+}
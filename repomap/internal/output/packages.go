@@ -0,0 +1,180 @@
+package output
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PackageNode is an aggregate of the files in one package or directory.
+type PackageNode struct {
+	Path       string  `json:"path" xml:"path,attr"`
+	Importance string  `json:"importance" xml:"importance,attr"`
+	Rank       float64 `json:"rank" xml:"rank,attr"`
+	// Files is the number of files in the package.
+	Files int `json:"files" xml:"files,attr"`
+	// Definitions is the total number of definitions across those files.
+	Definitions int `json:"definitions" xml:"definitions,attr"`
+	// Module is set when every file belongs to the same Go module.
+	Module string `json:"module,omitempty" xml:"module,attr,omitempty"`
+	// External marks packages made up only of vendored files.
+	External bool `json:"external,omitempty" xml:"external,attr,omitempty"`
+	// Issues is the number of analysis issues found in the package's files.
+	Issues int `json:"issues,omitempty" xml:"issues,attr,omitempty"`
+	// Symbols lists the top exported definitions, taken from the
+	// highest-ranked files first.
+	Symbols      []string      `json:"symbols,omitempty" xml:"symbol,omitempty"`
+	Dependencies []PackageEdge `json:"dependencies,omitempty" xml:"dependency,omitempty"`
+}
+
+// PackageEdge is a dependency on another package. Weight is the number of
+// file-level imports it aggregates.
+type PackageEdge struct {
+	Path   string `json:"path" xml:"path,attr"`
+	Weight int    `json:"weight" xml:"weight,attr"`
+}
+
+// CollapseFiles groups files by key into package nodes, summing their
+// definitions and keeping up to symbolLimit exported symbols per package.
+// Files should be sorted by rank so the best symbols are kept. Rank,
+// Importance and Dependencies are left for the caller, which knows the
+// collapsed graph.
+func CollapseFiles(files []*FileNode, key func(string) string, symbolLimit int) []*PackageNode {
+	byKey := make(map[string]*PackageNode)
+	var order []*PackageNode
+	modules := make(map[string]map[string]bool)
+	external := make(map[string]bool)
+
+	for _, f := range files {
+		k := key(f.Path)
+		pkg, ok := byKey[k]
+		if !ok {
+			pkg = &PackageNode{Path: k}
+			byKey[k] = pkg
+			order = append(order, pkg)
+			modules[k] = make(map[string]bool)
+			external[k] = true
+		}
+		pkg.Files++
+		pkg.Definitions += len(f.Definitions)
+		pkg.Issues += len(f.Issues)
+		modules[k][f.Module] = true
+		external[k] = external[k] && f.External
+
+		// Test files do not contribute to a package's API.
+		if strings.HasSuffix(f.Path, "_test.go") {
+			continue
+		}
+		for _, def := range f.Definitions {
+			if len(pkg.Symbols) >= symbolLimit {
+				break
+			}
+			if IsExportedDefinition(f.Language, def) {
+				pkg.Symbols = append(pkg.Symbols, def)
+			}
+		}
+	}
+
+	for _, pkg := range order {
+		if len(modules[pkg.Path]) == 1 {
+			for m := range modules[pkg.Path] {
+				pkg.Module = m
+			}
+		}
+		pkg.External = external[pkg.Path]
+	}
+	return order
+}
+
+// IsExportedDefinition reports whether a definition line declares a symbol
+// visible outside its package. Go uses capitalization; JavaScript and
+// TypeScript require an export keyword; other languages treat names with a
+// leading underscore as private.
+func IsExportedDefinition(language, def string) bool {
+	switch language {
+	case "go":
+		r, _ := utf8.DecodeRuneInString(goDefinitionName(def))
+		return unicode.IsUpper(r)
+	case "js", "jsx", "ts", "tsx":
+		return strings.HasPrefix(def, "export ")
+	}
+	fields := strings.Fields(def)
+	if len(fields) < 2 {
+		return false
+	}
+	return !strings.HasPrefix(fields[1], "_")
+}
+
+// goDefinitionName returns the declared name from a Go definition such as
+// "func (*Server) Start() error" or "type Config struct".
+func goDefinitionName(def string) string {
+	_, rest, ok := strings.Cut(def, " ")
+	if !ok {
+		return ""
+	}
+	if strings.HasPrefix(rest, "(") {
+		if i := strings.Index(rest, ") "); i >= 0 {
+			rest = rest[i+2:]
+		}
+	}
+	if i := strings.IndexAny(rest, " ([[="); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+// SortPackages orders packages by rank, highest first, then by path.
+func SortPackages(pkgs []*PackageNode) {
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if pkgs[i].Rank != pkgs[j].Rank {
+			return pkgs[i].Rank > pkgs[j].Rank
+		}
+		return pkgs[i].Path < pkgs[j].Path
+	})
+}
+
+// FitPackages returns the packages, sorted by rank, that fit in
+// maxTokens after overhead tokens for the document wrapper. Packages that
+// do not fit are skipped and reported as omitted, with their file and
+// definition counts. If maxTokens is 0, no limit is applied.
+func FitPackages(pkgs []*PackageNode, maxTokens, overhead int) ([]*PackageNode, []Elision) {
+	if maxTokens <= 0 {
+		return pkgs, nil
+	}
+
+	total := overhead
+	for _, pkg := range pkgs {
+		total += packageCost(pkg)
+	}
+	if total <= maxTokens {
+		return pkgs, nil
+	}
+
+	var included []*PackageNode
+	omitted := Elision{Level: DetailOmitted}
+	currentTokens := overhead + elisionCost
+	for _, pkg := range pkgs {
+		cost := packageCost(pkg)
+		if currentTokens+cost > maxTokens {
+			omitted.Files += pkg.Files
+			omitted.Symbols += pkg.Definitions
+			continue
+		}
+		included = append(included, pkg)
+		currentTokens += cost
+	}
+	return included, []Elision{omitted}
+}
+
+// packageCost estimates the tokens a package node takes to render.
+func packageCost(pkg *PackageNode) int {
+	cost := CountTokens(pkg.Path) + 10 // +10 for attributes overhead
+	for _, s := range pkg.Symbols {
+		cost += CountTokens(s) + 2
+	}
+	for _, d := range pkg.Dependencies {
+		cost += CountTokens(d.Path) + 4
+	}
+	return cost
+}
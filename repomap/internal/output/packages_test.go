@@ -0,0 +1,96 @@
+package output
+
+import (
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestCollapseFiles(t *testing.T) {
+	files := []*FileNode{
+		{Path: "pkg/auth/auth.go", Language: "go", Module: "example.com/m", Definitions: []string{"func Login(string) error", "func hash(string) string", "type Session struct"}},
+		{Path: "pkg/auth/token.go", Language: "go", Module: "example.com/m", Definitions: []string{"func (*Token) Valid() bool", "var defaultTTL"}, Issues: []Issue{{Type: "duplication"}}},
+		{Path: "web/app.ts", Language: "ts", Definitions: []string{"export function render(", "function helper("}},
+	}
+
+	pkgs := CollapseFiles(files, path.Dir, 2)
+	if len(pkgs) != 2 {
+		t.Fatalf("expected 2 packages, got %d", len(pkgs))
+	}
+
+	auth := pkgs[0]
+	if auth.Path != "pkg/auth" || auth.Files != 2 || auth.Definitions != 5 || auth.Issues != 1 {
+		t.Errorf("unexpected auth package: %+v", auth)
+	}
+	if auth.Module != "example.com/m" {
+		t.Errorf("expected module to carry over, got %q", auth.Module)
+	}
+	want := []string{"func Login(string) error", "type Session struct"}
+	if strings.Join(auth.Symbols, "|") != strings.Join(want, "|") {
+		t.Errorf("expected symbols %v, got %v", want, auth.Symbols)
+	}
+
+	web := pkgs[1]
+	if len(web.Symbols) != 1 || web.Symbols[0] != "export function render(" {
+		t.Errorf("expected only the exported TS function, got %v", web.Symbols)
+	}
+}
+
+func TestIsExportedDefinition(t *testing.T) {
+	tests := []struct {
+		lang, def string
+		want      bool
+	}{
+		{"go", "func (*Server) Start() error", true},
+		{"go", "func (s *server) start()", false},
+		{"go", "type Config struct", true},
+		{"go", "func newConfig() *Config", false},
+		{"go", "const MaxSize", true},
+		{"ts", "export const api = {", true},
+		{"ts", "const local = 1", false},
+		{"py", "def _private(self):", false},
+		{"py", "class Handler:", true},
+	}
+	for _, tt := range tests {
+		if got := IsExportedDefinition(tt.lang, tt.def); got != tt.want {
+			t.Errorf("IsExportedDefinition(%q, %q) = %v, want %v", tt.lang, tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestRenderPackagesXML(t *testing.T) {
+	pkgs := []*PackageNode{
+		{Path: "internal/graph", Importance: "high", Rank: 1, Files: 4, Definitions: 20,
+			Symbols:      []string{"func Collapse(*Graph, func(string) string) *Graph"},
+			Dependencies: []PackageEdge{{Path: "internal/modules", Weight: 3}}},
+		{Path: "internal/modules", Importance: "medium", Rank: 0.5, Files: 2, Definitions: 8},
+	}
+
+	out, err := RenderPackagesXML(pkgs, 0)
+	if err != nil {
+		t.Fatalf("RenderPackagesXML failed: %v", err)
+	}
+	for _, s := range []string{
+		`<package path="internal/graph" importance="high" rank="1" files="4" definitions="20">`,
+		`<symbol>func Collapse(*Graph, func(string) string) *Graph</symbol>`,
+		`<dependency path="internal/modules" weight="3"></dependency>`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("XML output missing %q:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "<file") {
+		t.Error("package output should not contain file nodes")
+	}
+
+	limited, err := RenderPackagesJSON(pkgs, 121)
+	if err != nil {
+		t.Fatalf("RenderPackagesJSON failed: %v", err)
+	}
+	if !strings.Contains(limited, "internal/graph") || strings.Contains(limited, `"importance": "medium"`) {
+		t.Errorf("expected only the first package within budget:\n%s", limited)
+	}
+	if !strings.Contains(limited, `"level": "omitted"`) || !strings.Contains(limited, `"symbols": 8`) {
+		t.Errorf("limited output should report the omitted package:\n%s", limited)
+	}
+}
@@ -0,0 +1,409 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Formats lists the map formats accepted by Render.
+var Formats = []string{"xml", "json", "text", "tree", "markdown", "pack", "sarif", "html"}
+
+// reports are the formats that cover the whole map for people and CI
+// systems rather than for a model, and are never fitted to the budget.
+var reports = map[string]bool{"sarif": true, "html": true}
+
+// Options control how Render fits and encodes a map.
+type Options struct {
+	// MaxTokens is the token budget of the rendered map; 0 means no limit.
+	MaxTokens int
+	// Layout selects the markdown layout, LayoutDetailed by default.
+	Layout string
+	// Template is the source of the text/template executed by the
+	// "template" format.
+	Template string
+}
+
+// repackAttempts bounds the number of budgets Render tries in each
+// direction when the rendered map misses maxTokens, since packing works on
+// estimates.
+const repackAttempts = 12
+
+// underfill is the share of maxTokens a rendering may leave unused before
+// Render looks for a larger packing budget.
+const underfill = 10 // percent
+
+// Rendered is an encoded map and the number of tokens it holds.
+type Rendered struct {
+	Data   []byte
+	Tokens int
+}
+
+// Render fits the map into opts.MaxTokens and encodes it in the given format.
+// Files are packed with PackFiles, or PackSources for the pack format, and
+// packages with FitPackages, within the budget left after the document
+// wrapper and dependencies. Packing relies on format-agnostic estimates, so
+// Render then searches for the largest packing budget whose encoding fits:
+// downwards when the map exceeds maxTokens, and upwards, doubling the
+// budget until it overflows, when a compact format leaves much of maxTokens
+// unused while detail was left out. If maxTokens is 0, or the format is a
+// report, no limit is applied.
+func Render(m *RepoMap, format string, opts Options) (*Rendered, error) {
+	encode, err := encoder(format, opts)
+	if err != nil {
+		return nil, err
+	}
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 || reports[format] {
+		return encodeCounted(encode, stamp(m))
+	}
+
+	wrapper, err := encodeCounted(encode, stamp(&RepoMap{Meta: m.Meta, Dependencies: m.Dependencies}))
+	if err != nil {
+		return nil, err
+	}
+	sources := format == "pack"
+	// render also reports whether the packing left anything out, that is,
+	// whether a larger budget could add to the map.
+	render := func(budget int) (*Rendered, bool, error) {
+		fitted := fit(m, budget, wrapper.Tokens, sources)
+		r, err := encodeCounted(encode, fitted)
+		return r, trimmed(fitted), err
+	}
+
+	// fits holds the best rendering within maxTokens; budgets up to lo are
+	// known to fit and budgets from hi up are known not to.
+	var fits *Rendered
+	lo, hi := 0, maxTokens
+	r, more, err := render(maxTokens)
+	if err != nil {
+		return nil, err
+	}
+	if r.Tokens <= maxTokens {
+		fits, lo, hi = r, maxTokens, 0
+		for attempt := 0; attempt < repackAttempts && more && r.Tokens < maxTokens-maxTokens*underfill/100; attempt++ {
+			budget := 2 * lo
+			if r, more, err = render(budget); err != nil {
+				return nil, err
+			}
+			if r.Tokens > maxTokens {
+				hi = budget
+				break
+			}
+			fits, lo = r, budget
+		}
+		if hi == 0 {
+			return fits, nil
+		}
+	}
+
+	for attempt := 0; attempt < repackAttempts && hi-lo > 1; attempt++ {
+		mid := lo + (hi-lo)/2
+		if r, _, err = render(mid); err != nil {
+			return nil, err
+		}
+		if r.Tokens <= maxTokens {
+			lo, fits = mid, r
+		} else {
+			hi = mid
+		}
+	}
+	if fits == nil {
+		// Not even the smallest packing fits; return it anyway.
+		r, _, err := render(1)
+		return r, err
+	}
+	return fits, nil
+}
+
+// trimmed reports whether fitting left detail, files, packages or sources
+// out of m.
+func trimmed(m *RepoMap) bool {
+	if len(m.Elided) > 0 || len(m.Rollups) > 0 {
+		return true
+	}
+	if m.Manifest != nil {
+		for _, e := range m.Manifest.Omitted {
+			if e.Reason == reasonBudget {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fit returns a copy of m with its files or packages fitted into budget.
+// With sources, the budget is shared with the file sources of a pack.
+func fit(m *RepoMap, budget, overhead int, sources bool) *RepoMap {
+	fitted := *m
+	if len(m.Files) > 0 {
+		var packed Packing
+		if sources {
+			packed, fitted.Manifest = PackSources(m.Files, budget, overhead)
+		} else {
+			packed = PackFiles(m.Files, budget, overhead)
+		}
+		fitted.Files, fitted.Rollups, fitted.Elided = packed.Files, packed.Rollups, packed.Elided
+	}
+	if len(m.Packages) > 0 {
+		fitted.Packages, fitted.Elided = FitPackages(m.Packages, budget, overhead)
+	}
+	return stamp(&fitted)
+}
+
+// stamp returns a copy of m carrying the schema version, with the content
+// hash of its metadata filled in.
+func stamp(m *RepoMap) *RepoMap {
+	stamped := *m
+	stamped.SchemaVersion = SchemaVersion
+	if m.Meta != nil {
+		meta := *m.Meta
+		meta.ContentHash = ContentHash(m)
+		stamped.Meta = &meta
+	}
+	return &stamped
+}
+
+// hashedContent is what ContentHash covers: the map without its metadata,
+// and the outlines and sources of its files, which the JSON encoding of
+// the map leaves out although the tree and pack formats emit them.
+type hashedContent struct {
+	Map   *RepoMap     `json:"map"`
+	Files []hashedFile `json:"files,omitempty"`
+}
+
+type hashedFile struct {
+	Path    string  `json:"path"`
+	Outline []Line  `json:"outline,omitempty"`
+	Source  *Source `json:"source,omitempty"`
+}
+
+// ContentHash returns the SHA-256 of the map content, metadata excluded,
+// so maps of the same tree and detail compare equal whatever their format
+// and generation time.
+func ContentHash(m *RepoMap) string {
+	content := *m
+	content.Meta = nil
+	content.SchemaVersion = ""
+	hashed := hashedContent{Map: &content}
+	for _, f := range m.Files {
+		if f.Outline != nil || f.Source != nil {
+			hashed.Files = append(hashed.Files, hashedFile{Path: f.Path, Outline: f.Outline, Source: f.Source})
+		}
+	}
+	data, _ := json.Marshal(&hashed)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// IsReport reports whether the format is a report, which covers the whole
+// map and is neither fitted to a budget nor split into chunks.
+func IsReport(format string) bool {
+	return reports[format]
+}
+
+// CheckFormat reports whether Render accepts the format and options,
+// parsing the template of a template format.
+func CheckFormat(format string, opts Options) error {
+	_, err := encoder(format, opts)
+	return err
+}
+
+func encodeCounted(encode func(*RepoMap) ([]byte, error), m *RepoMap) (*Rendered, error) {
+	data, err := encode(m)
+	if err != nil {
+		return nil, err
+	}
+	return &Rendered{Data: data, Tokens: CountTokens(string(data))}, nil
+}
+
+func encoder(format string, opts Options) (func(*RepoMap) ([]byte, error), error) {
+	switch format {
+	case "xml":
+		return encodeXML, nil
+	case "json":
+		return encodeJSON, nil
+	case "text":
+		return encodeText, nil
+	case "tree":
+		return encodeTree, nil
+	case "markdown":
+		return markdownEncoder(opts)
+	case "pack":
+		return func(m *RepoMap) ([]byte, error) {
+			return encodePack(m, opts.MaxTokens), nil
+		}, nil
+	case "sarif":
+		return encodeSARIF, nil
+	case "html":
+		return encodeHTML, nil
+	}
+	if format == "template" || strings.HasPrefix(format, templatePrefix) {
+		return templateEncoder(format, opts)
+	}
+	return nil, fmt.Errorf("unsupported output format: %s", format)
+}
+
+func encodeXML(m *RepoMap) ([]byte, error) {
+	data, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal XML: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func encodeJSON(m *RepoMap) ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// encodeText renders the map as an indented listing: one line per file or
+// package followed by its definitions, then roll-ups, elisions and
+// dependencies.
+func encodeText(m *RepoMap) ([]byte, error) {
+	var b strings.Builder
+	writeTextHeader(&b, m.Meta)
+	writeTextChunk(&b, m.Chunk)
+	for _, f := range m.Files {
+		attrs := []string{f.Language, f.Importance, fmt.Sprintf("rank %.2f", f.Rank)}
+		if f.Detail != "" {
+			attrs = append(attrs, f.Detail+" only")
+		}
+		fmt.Fprintf(&b, "%s (%s)\n", f.Path, strings.Join(attrs, ", "))
+		for _, def := range f.Definitions {
+			fmt.Fprintf(&b, "  %s\n", def)
+		}
+	}
+	writeTextSummary(&b, m)
+	return []byte(b.String()), nil
+}
+
+// encodeTree renders each file as a folded view of its source: the lines
+// of its outline with their numbers in the gutter, and elided code between
+// them collapsed into ⋮... markers. Files without an outline, or reduced to
+// fit the budget, list their definitions instead.
+func encodeTree(m *RepoMap) ([]byte, error) {
+	var b strings.Builder
+	writeTextHeader(&b, m.Meta)
+	writeTextChunk(&b, m.Chunk)
+	for i, f := range m.Files {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if f.Detail != "" {
+			fmt.Fprintf(&b, "%s: (%s only)\n", f.Path, f.Detail)
+		} else {
+			fmt.Fprintf(&b, "%s:\n", f.Path)
+		}
+		if f.Detail != "" || len(f.Outline) == 0 {
+			for _, def := range f.Definitions {
+				fmt.Fprintf(&b, "  %s\n", def)
+			}
+			continue
+		}
+
+		width := len(fmt.Sprint(f.Outline[len(f.Outline)-1].Number))
+		next := 1
+		for _, l := range f.Outline {
+			if l.Number > next {
+				fmt.Fprintf(&b, "%*s⋮...\n", width, "")
+			}
+			fmt.Fprintf(&b, "%*d│%s\n", width, l.Number, l.Text)
+			next = l.Number + 1
+		}
+		fmt.Fprintf(&b, "%*s⋮...\n", width, "")
+	}
+	if len(m.Files) > 0 && (len(m.Packages) > 0 || len(m.Rollups) > 0 || len(m.Elided) > 0 || len(m.Dependencies) > 0) {
+		b.WriteString("\n")
+	}
+	writeTextSummary(&b, m)
+	return []byte(b.String()), nil
+}
+
+// writeTextHeader writes a comment line describing how the map was made.
+func writeTextHeader(b *strings.Builder, meta *Metadata) {
+	if meta == nil {
+		return
+	}
+	fmt.Fprintf(b, "# repomap %s, root %s", meta.Version, meta.Root)
+	if meta.Commit != "" {
+		fmt.Fprintf(b, " at %s", shortCommit(meta.Commit))
+		if meta.Dirty {
+			b.WriteString(" (dirty)")
+		}
+	}
+	if meta.Generated != "" {
+		fmt.Fprintf(b, ", generated %s", meta.Generated)
+	}
+	fmt.Fprintf(b, ", %s\n", meta.ContentHash)
+}
+
+// shortCommit abbreviates a commit hash for display.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// writeTextSummary writes the packages, roll-ups, elisions and
+// dependencies of a text or tree rendering.
+func writeTextSummary(b *strings.Builder, m *RepoMap) {
+	for _, p := range m.Packages {
+		fmt.Fprintf(b, "%s/ (%s, rank %.2f, %d files, %d definitions)\n", p.Path, p.Importance, p.Rank, p.Files, p.Definitions)
+		for _, s := range p.Symbols {
+			fmt.Fprintf(b, "  %s\n", s)
+		}
+		for _, d := range p.Dependencies {
+			fmt.Fprintf(b, "  -> %s (%d)\n", d.Path, d.Weight)
+		}
+	}
+	for _, r := range m.Rollups {
+		fmt.Fprintf(b, "%s/ (%d more files, %d symbols)\n", r.Path, r.Files, r.Symbols)
+	}
+	for _, e := range m.Elided {
+		fmt.Fprintf(b, "elided: %d files (%d symbols) reduced to %s\n", e.Files, e.Symbols, e.Level)
+	}
+	for _, d := range m.Dependencies {
+		version := d.Version
+		if version == "" {
+			version = "unknown version"
+		}
+		fmt.Fprintf(b, "dependency %s %s (%s, %d files)\n", d.Name, version, d.Ecosystem, len(d.Files))
+	}
+}
+
+// WriteFile writes data to path atomically: it is written to a temporary
+// file in the same directory, which then replaces path, so readers never
+// see a partial map.
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,213 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func renderFixture(n int) *RepoMap {
+	m := &RepoMap{}
+	for i := 0; i < n; i++ {
+		m.Files = append(m.Files, &FileNode{
+			Path:       fmt.Sprintf("pkg/file%02d.go", i),
+			Language:   "go",
+			Importance: "low",
+			Rank:       1 - float64(i)/float64(n),
+			Definitions: []string{
+				fmt.Sprintf("func Handle%d(ctx context.Context, req *Request) (*Response, error)", i),
+				fmt.Sprintf("type Handler%d struct", i),
+			},
+		})
+	}
+	return m
+}
+
+func TestRender_Formats(t *testing.T) {
+	for _, format := range Formats {
+		if format == "sarif" {
+			continue // lists issues only; see TestEncodeSARIF
+		}
+		t.Run(format, func(t *testing.T) {
+			r, err := Render(renderFixture(3), format, Options{})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if !strings.Contains(string(r.Data), "pkg/file00.go") {
+				t.Errorf("expected the files in the output:\n%s", r.Data)
+			}
+			if r.Tokens != CountTokens(string(r.Data)) {
+				t.Errorf("expected Tokens to count the output, got %d", r.Tokens)
+			}
+		})
+	}
+
+	if _, err := Render(renderFixture(1), "yaml", Options{}); err == nil {
+		t.Error("expected error for an unknown format")
+	}
+}
+
+func TestRender_Budget(t *testing.T) {
+	full, err := Render(renderFixture(40), "json", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range Formats {
+		if reports[format] {
+			continue // never fitted
+		}
+		for _, budget := range []int{full.Tokens / 2, full.Tokens / 5, 40} {
+			r, err := Render(renderFixture(40), format, Options{MaxTokens: budget})
+			if err != nil {
+				t.Fatalf("Render(%s, %d) failed: %v", format, budget, err)
+			}
+			if r.Tokens > budget {
+				t.Errorf("Render(%s, %d) emitted %d tokens", format, budget, r.Tokens)
+			}
+		}
+	}
+}
+
+func TestRender_FillsBudget(t *testing.T) {
+	// Packing estimates suit XML; a CSV row costs far less than estimated,
+	// so Render has to look for a larger packing budget.
+	r, err := Render(renderFixture(400), "template:csv", Options{MaxTokens: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Tokens > 2000 || r.Tokens < 2000*(100-underfill)/100 {
+		t.Errorf("expected the csv to fill most of the budget, got %d tokens", r.Tokens)
+	}
+
+	// A map that fits whole is not padded.
+	small, err := Render(renderFixture(3), "template:csv", Options{MaxTokens: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full, _ := Render(renderFixture(3), "template:csv", Options{}); string(small.Data) != string(full.Data) {
+		t.Errorf("expected the whole map, got:\n%s", small.Data)
+	}
+}
+
+func TestRender_Text(t *testing.T) {
+	m := renderFixture(2)
+	m.Files[1].Detail = DetailNames
+	m.Files[1].Definitions = []string{"Handle1"}
+	m.Elided = []Elision{{Level: DetailNames, Files: 1, Symbols: 2}}
+
+	r, err := Render(m, "text", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pkg/file00.go (go, low, rank 1.00)\n" +
+		"  func Handle0(ctx context.Context, req *Request) (*Response, error)\n" +
+		"  type Handler0 struct\n" +
+		"pkg/file01.go (go, low, rank 0.50, names only)\n" +
+		"  Handle1\n" +
+		"elided: 1 files (2 symbols) reduced to names\n"
+	if string(r.Data) != want {
+		t.Errorf("unexpected text output:\n%s", r.Data)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.xml")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("new")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("expected the file to be replaced, got %q (%v)", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestRender_Tree(t *testing.T) {
+	m := renderFixture(2)
+	m.Files[0].Outline = []Line{
+		{Number: 1, Text: "package pkg"},
+		{Number: 9, Text: "type Handler0 struct {"},
+		{Number: 10, Text: "func Handle0(ctx context.Context, req *Request) (*Response, error) {"},
+	}
+
+	r, err := Render(m, "tree", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pkg/file00.go:\n" +
+		" 1│package pkg\n" +
+		"  ⋮...\n" +
+		" 9│type Handler0 struct {\n" +
+		"10│func Handle0(ctx context.Context, req *Request) (*Response, error) {\n" +
+		"  ⋮...\n" +
+		"\n" +
+		"pkg/file01.go:\n" +
+		"  func Handle1(ctx context.Context, req *Request) (*Response, error)\n" +
+		"  type Handler1 struct\n"
+	if string(r.Data) != want {
+		t.Errorf("unexpected tree output:\n%s", r.Data)
+	}
+}
+
+func TestRender_Metadata(t *testing.T) {
+	m := renderFixture(3)
+	m.Meta = &Metadata{Version: "1.0.0", Root: ".", Commit: "0123456789abcdef0123", Tokenizer: "heuristic",
+		Options: []Option{{Name: "output", Value: "xml"}}}
+
+	r, err := Render(m, "xml", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := ContentHash(m)
+	if !strings.HasPrefix(hash, "sha256:") {
+		t.Fatalf("unexpected content hash %q", hash)
+	}
+	want := `<metadata version="1.0.0" root="." commit="0123456789abcdef0123" tokenizer="heuristic" content_hash="` + hash + `">`
+	if !strings.Contains(string(r.Data), want) || !strings.Contains(string(r.Data), `<option name="output" value="xml"></option>`) {
+		t.Errorf("expected the metadata in the XML:\n%s", r.Data)
+	}
+	if m.Meta.ContentHash != "" {
+		t.Error("Render should not modify the map")
+	}
+
+	// The hash covers the content only.
+	other := renderFixture(3)
+	other.Meta = &Metadata{Version: "2.0.0", Generated: "2024-01-01T00:00:00Z"}
+	if ContentHash(other) != hash {
+		t.Error("expected the content hash to ignore metadata")
+	}
+	other.Files[0].Rank = 0.5
+	if ContentHash(other) == hash {
+		t.Error("expected the content hash to change with the content")
+	}
+
+	// Outlines and sources are content too, though JSON leaves them out.
+	other = renderFixture(3)
+	other.Files[0].Outline = []Line{{Number: 1, Text: "package main"}}
+	outlined := ContentHash(other)
+	if outlined == hash {
+		t.Error("expected the content hash to cover outlines")
+	}
+	other.Files[0].Source = &Source{Text: "package main\n"}
+	if ContentHash(other) == outlined {
+		t.Error("expected the content hash to cover sources")
+	}
+
+	r, err = Render(m, "text", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(r.Data), "# repomap 1.0.0, root . at 0123456789ab, "+hash+"\n") {
+		t.Errorf("expected a metadata header line:\n%s", r.Data)
+	}
+}
@@ -0,0 +1,377 @@
+// Renders the map embedded in the report: a force-directed dependency
+// graph, the analysis issues, the files with their plan status, and the
+// details of the selected file or package. Everything runs offline.
+(function () {
+  "use strict";
+
+  var data = JSON.parse(document.getElementById("repomap-data").textContent);
+  var files = data.files || [];
+  var packages = data.packages || [];
+  var items = files.length ? files : packages;
+  var kind = files.length ? "file" : "package";
+
+  var COLORS = { high: "#ff7b72", medium: "#d29922", low: "#3fb950" };
+  var SEVERITIES = ["high", "medium", "low"];
+  // The graph keeps the highest-ranked nodes so the layout stays fast.
+  var MAX_NODES = 400;
+
+  var byPath = {};
+  items.forEach(function (item) { byPath[item.path] = item; });
+
+  // Imports and importers by path, from file edges or package dependencies.
+  var imports = {}, importers = {};
+  function link(from, to, weight) {
+    if (!byPath[from] || !byPath[to] || from === to) return;
+    (imports[from] = imports[from] || []).push({ path: to, weight: weight });
+    (importers[to] = importers[to] || []).push({ path: from, weight: weight });
+  }
+  if (kind === "file") {
+    (data.edges || []).forEach(function (e) { link(e.from, e.to, e.weight || 1); });
+  } else {
+    packages.forEach(function (p) {
+      (p.dependencies || []).forEach(function (d) { link(p.path, d.path, d.weight); });
+    });
+  }
+
+  function el(tag, props, children) {
+    var node = document.createElement(tag);
+    Object.keys(props || {}).forEach(function (k) {
+      if (k === "text") node.textContent = props[k];
+      else if (k === "onclick") node.addEventListener("click", props[k]);
+      else node.setAttribute(k, props[k]);
+    });
+    (children || []).forEach(function (c) {
+      if (c != null) node.appendChild(typeof c === "string" ? document.createTextNode(c) : c);
+    });
+    return node;
+  }
+
+  function chip(label, value) {
+    return el("span", { class: "chip" }, [label + " ", el("b", { text: String(value) })]);
+  }
+
+  function sevBadge(severity) {
+    return el("span", { class: "sev sev-" + severity, text: severity });
+  }
+
+  function pathLink(path, line) {
+    var label = line ? path + ":" + line : path;
+    if (!byPath[path]) return el("code", { text: label });
+    return el("a", { class: "mono", text: label, onclick: function (ev) { ev.stopPropagation(); select(path); } });
+  }
+
+  // --- Issues, deduplicated across the files they are attached to ---
+
+  var issues = [], seenIssues = {};
+  files.forEach(function (f) {
+    (f.issues || []).forEach(function (issue) {
+      var key = issue.type + "\u0000" + issue.description;
+      if (seenIssues[key]) return;
+      seenIssues[key] = true;
+      var locations = issue.locations && issue.locations.length ? issue.locations : [{ path: f.path }];
+      issues.push({ issue: issue, locations: locations });
+    });
+  });
+  issues.sort(function (a, b) {
+    return SEVERITIES.indexOf(a.issue.severity) - SEVERITIES.indexOf(b.issue.severity);
+  });
+
+  // --- Summary ---
+
+  var summary = document.getElementById("summary");
+  var meta = data.metadata || {};
+  if (meta.version) summary.appendChild(chip("repomap", meta.version));
+  if (meta.commit) summary.appendChild(chip("commit", meta.commit.slice(0, 12) + (meta.dirty ? " (dirty)" : "")));
+  if (meta.generated) summary.appendChild(chip("generated", meta.generated));
+  summary.appendChild(chip(kind === "file" ? "files" : "packages", items.length));
+  var edgeCount = 0;
+  Object.keys(imports).forEach(function (p) { edgeCount += imports[p].length; });
+  summary.appendChild(chip("imports", edgeCount));
+  SEVERITIES.forEach(function (s) {
+    var n = issues.filter(function (i) { return i.issue.severity === s; }).length;
+    if (n) summary.appendChild(chip(s + " issues", n));
+  });
+  var statuses = {};
+  files.forEach(function (f) { if (f.status) statuses[f.status] = (statuses[f.status] || 0) + 1; });
+  Object.keys(statuses).sort().forEach(function (s) { summary.appendChild(chip(s, statuses[s])); });
+
+  // --- Issues table ---
+
+  var issuesPanel = document.getElementById("issues");
+  if (!issues.length) {
+    issuesPanel.appendChild(el("p", { class: "note", text: "No issues were found. Run repomap with --analyze to look for them." }));
+  } else {
+    var issueRows = issues.map(function (entry) {
+      var where = el("td", {});
+      entry.locations.forEach(function (l, i) {
+        if (i) where.appendChild(document.createTextNode(", "));
+        where.appendChild(pathLink(l.path, l.line));
+      });
+      var first = entry.locations[0].path;
+      return el("tr", { onclick: function () { if (byPath[first]) select(first); } }, [
+        el("td", {}, [sevBadge(entry.issue.severity)]),
+        el("td", { class: "mono", text: entry.issue.type }),
+        el("td", { text: entry.issue.description }),
+        where,
+      ]);
+    });
+    issuesPanel.appendChild(el("table", {}, [
+      el("thead", {}, [el("tr", {}, [el("th", { text: "Severity" }), el("th", { text: "Type" }), el("th", { text: "Description" }), el("th", { text: "Location" })])]),
+      el("tbody", {}, issueRows),
+    ]));
+  }
+
+  // --- Files or packages table ---
+
+  var rows = {};
+  var tbody = el("tbody", {});
+  document.getElementById("files-title").textContent = kind === "file" ? "Files" : "Packages";
+  items.forEach(function (item) {
+    var count = kind === "file" ? (item.issues || []).length : item.issues || 0;
+    var row = el("tr", { onclick: function () { select(item.path); } }, [
+      el("td", { class: "mono", text: item.path }),
+      el("td", {}, [sevBadge(item.importance)]),
+      el("td", { class: "num", text: item.rank.toFixed(3) }),
+      el("td", { class: "status", text: item.status || "" }),
+      el("td", { class: "num", text: count ? String(count) : "" }),
+      el("td", { class: "num", text: String(kind === "file" ? (item.definitions || []).length : item.definitions) }),
+    ]);
+    row.dataset.search = [item.path, item.intent || "", item.status || ""].join(" ").toLowerCase();
+    rows[item.path] = row;
+    tbody.appendChild(row);
+  });
+  document.getElementById("files").appendChild(el("table", {}, [
+    el("thead", {}, [el("tr", {}, [
+      el("th", { text: "Path" }), el("th", { text: "Importance" }), el("th", { class: "num", text: "Rank" }),
+      el("th", { text: "Status" }), el("th", { class: "num", text: "Issues" }), el("th", { class: "num", text: "Definitions" }),
+    ])]),
+    tbody,
+  ]));
+  document.getElementById("filter").addEventListener("input", function (ev) {
+    var q = ev.target.value.toLowerCase();
+    Object.keys(rows).forEach(function (p) {
+      rows[p].style.display = rows[p].dataset.search.indexOf(q) >= 0 ? "" : "none";
+    });
+  });
+
+  // --- Details ---
+
+  var details = document.getElementById("details");
+  var selected = null;
+
+  function section(title, nodes) {
+    if (!nodes.length) return [];
+    return [el("h3", { text: title })].concat(nodes);
+  }
+
+  function linkList(entries) {
+    if (!entries || !entries.length) return [];
+    return [el("ul", {}, entries.map(function (e) { return el("li", {}, [pathLink(e.path)]); }))];
+  }
+
+  function select(path) {
+    if (rows[selected]) rows[selected].classList.remove("selected");
+    selected = path;
+    if (rows[path]) {
+      rows[path].classList.add("selected");
+    }
+    var item = byPath[path];
+    details.textContent = "";
+    details.appendChild(el("h2", { class: "mono", text: path }));
+
+    var facts = [item.importance + " importance", "rank " + item.rank.toFixed(3)];
+    if (item.language) facts.push(item.language);
+    if (item.files) facts.push(item.files + " files");
+    if (item.status) facts.push("status: " + item.status);
+    if (item.intent) facts.push("intent: " + item.intent);
+    details.appendChild(el("p", { class: "note", text: facts.join(" · ") }));
+
+    var defs = kind === "file" ? item.definitions || [] : item.symbols || [];
+    section("Definitions", defs.length ? [el("pre", { text: defs.join("\n") })] : []).forEach(function (n) { details.appendChild(n); });
+    section("Issues", (item.issues && item.issues.map ? item.issues : []).map(function (issue) {
+      var here = (issue.locations || []).filter(function (l) { return l.path === path; })[0];
+      return el("p", {}, [sevBadge(issue.severity), " ", el("code", { text: issue.type }), here && here.line ? " line " + here.line : "", el("br"), issue.description]);
+    })).forEach(function (n) { details.appendChild(n); });
+    section("Imports", linkList(imports[path])).forEach(function (n) { details.appendChild(n); });
+    section("Imported by", linkList(importers[path])).forEach(function (n) { details.appendChild(n); });
+    section("Comments", (item.comments || []).map(function (c) {
+      return el("p", {}, [el("b", { text: c.user + ": " }), c.text]);
+    })).forEach(function (n) { details.appendChild(n); });
+    draw();
+  }
+
+  // --- Graph ---
+
+  var canvas = document.getElementById("graph");
+  var ctx = canvas.getContext("2d");
+  var nodes = items.slice(0, MAX_NODES).map(function (item, i) {
+    // Seed a spiral so the layout is the same on every load.
+    var angle = i * 2.399963, radius = 12 * Math.sqrt(i + 1);
+    var issueCount = kind === "file" ? (item.issues || []).length : item.issues || 0;
+    return {
+      path: item.path, item: item, x: radius * Math.cos(angle), y: radius * Math.sin(angle), dx: 0, dy: 0,
+      r: 4 + 10 * Math.sqrt(Math.max(item.rank, 0)), issues: issueCount, planned: item.status === "planned",
+    };
+  });
+  var nodeIndex = {};
+  nodes.forEach(function (n, i) { nodeIndex[n.path] = i; });
+  var links = [];
+  Object.keys(imports).forEach(function (from) {
+    imports[from].forEach(function (e) {
+      if (from in nodeIndex && e.path in nodeIndex) links.push({ s: nodeIndex[from], t: nodeIndex[e.path] });
+    });
+  });
+  document.getElementById("graph-note").textContent = items.length > MAX_NODES
+    ? "Showing the " + MAX_NODES + " highest-ranked of " + items.length + " " + kind + "s. Drag to pan, scroll to zoom, click a node for details."
+    : "Drag to pan, scroll to zoom, click a node for details.";
+
+  // Fruchterman-Reingold: nodes repel, links attract, and the temperature
+  // bounding each move cools until the layout settles.
+  var K = 40, temperature = 60, TICKS = 300, tick = 0;
+  function step() {
+    var i, j, a, b, dx, dy, d, f;
+    for (i = 0; i < nodes.length; i++) { nodes[i].dx = -nodes[i].x * 0.01; nodes[i].dy = -nodes[i].y * 0.01; }
+    for (i = 0; i < nodes.length; i++) {
+      a = nodes[i];
+      for (j = i + 1; j < nodes.length; j++) {
+        b = nodes[j];
+        dx = a.x - b.x; dy = a.y - b.y;
+        d = Math.max(Math.sqrt(dx * dx + dy * dy), 0.01);
+        f = K * K / d;
+        a.dx += dx / d * f; a.dy += dy / d * f;
+        b.dx -= dx / d * f; b.dy -= dy / d * f;
+      }
+    }
+    links.forEach(function (l) {
+      a = nodes[l.s]; b = nodes[l.t];
+      dx = a.x - b.x; dy = a.y - b.y;
+      d = Math.max(Math.sqrt(dx * dx + dy * dy), 0.01);
+      f = d * d / K;
+      a.dx -= dx / d * f; a.dy -= dy / d * f;
+      b.dx += dx / d * f; b.dy += dy / d * f;
+    });
+    nodes.forEach(function (n) {
+      d = Math.max(Math.sqrt(n.dx * n.dx + n.dy * n.dy), 0.01);
+      n.x += n.dx / d * Math.min(d, temperature);
+      n.y += n.dy / d * Math.min(d, temperature);
+    });
+    temperature *= 0.985;
+    tick++;
+  }
+
+  // The view follows the layout until the user pans or zooms.
+  var view = { x: 0, y: 0, scale: 1 }, autoFit = true;
+  function fit() {
+    var w = canvas.clientWidth, h = canvas.clientHeight;
+    if (!nodes.length) return;
+    var minX = Infinity, minY = Infinity, maxX = -Infinity, maxY = -Infinity;
+    nodes.forEach(function (n) {
+      minX = Math.min(minX, n.x); maxX = Math.max(maxX, n.x);
+      minY = Math.min(minY, n.y); maxY = Math.max(maxY, n.y);
+    });
+    view.scale = Math.min(w / (maxX - minX + 80), h / (maxY - minY + 80), 2);
+    view.x = w / 2 - (minX + maxX) / 2 * view.scale;
+    view.y = h / 2 - (minY + maxY) / 2 * view.scale;
+  }
+
+  function draw() {
+    var ratio = window.devicePixelRatio || 1;
+    var w = canvas.clientWidth, h = canvas.clientHeight;
+    if (canvas.width !== w * ratio || canvas.height !== h * ratio) {
+      canvas.width = w * ratio; canvas.height = h * ratio;
+    }
+    ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+    ctx.clearRect(0, 0, w, h);
+    ctx.translate(view.x, view.y);
+    ctx.scale(view.scale, view.scale);
+
+    var neighbors = {};
+    if (selected) {
+      (imports[selected] || []).concat(importers[selected] || []).forEach(function (e) { neighbors[e.path] = true; });
+    }
+    ctx.lineWidth = 1 / view.scale;
+    links.forEach(function (l) {
+      var a = nodes[l.s], b = nodes[l.t];
+      var active = selected && (a.path === selected || b.path === selected);
+      ctx.strokeStyle = active ? "#58a6ff" : "rgba(139, 148, 158, 0.25)";
+      ctx.beginPath(); ctx.moveTo(a.x, a.y); ctx.lineTo(b.x, b.y); ctx.stroke();
+    });
+    nodes.forEach(function (n) {
+      var dim = selected && n.path !== selected && !neighbors[n.path];
+      ctx.globalAlpha = dim ? 0.3 : 1;
+      ctx.beginPath();
+      ctx.arc(n.x, n.y, n.r, 0, 2 * Math.PI);
+      ctx.fillStyle = COLORS[n.item.importance] || "#8b949e";
+      ctx.fill();
+      if (n.issues) {
+        ctx.lineWidth = 3 / view.scale; ctx.strokeStyle = "#f85149"; ctx.setLineDash([]); ctx.stroke();
+      }
+      if (n.planned) {
+        ctx.beginPath(); ctx.arc(n.x, n.y, n.r + 3 / view.scale, 0, 2 * Math.PI);
+        ctx.lineWidth = 2 / view.scale; ctx.strokeStyle = "#58a6ff"; ctx.setLineDash([3 / view.scale, 3 / view.scale]); ctx.stroke();
+        ctx.setLineDash([]);
+      }
+      if (n.path === selected || neighbors[n.path] || n.item.importance === "high") {
+        ctx.fillStyle = "#c9d1d9";
+        ctx.font = 11 / view.scale + "px sans-serif";
+        ctx.fillText(n.path.split("/").pop(), n.x + n.r + 3 / view.scale, n.y + 4 / view.scale);
+      }
+    });
+    ctx.globalAlpha = 1;
+  }
+
+  function animate() {
+    for (var i = 0; i < 5 && tick < TICKS; i++) step();
+    if (autoFit) fit();
+    draw();
+    if (tick < TICKS) window.requestAnimationFrame(animate);
+  }
+
+  function toWorld(ev) {
+    var rect = canvas.getBoundingClientRect();
+    return { x: (ev.clientX - rect.left - view.x) / view.scale, y: (ev.clientY - rect.top - view.y) / view.scale };
+  }
+
+  var drag = null;
+  canvas.addEventListener("mousedown", function (ev) {
+    drag = { x: ev.clientX, y: ev.clientY, moved: false };
+    canvas.classList.add("dragging");
+  });
+  window.addEventListener("mousemove", function (ev) {
+    if (!drag) return;
+    var dx = ev.clientX - drag.x, dy = ev.clientY - drag.y;
+    if (Math.abs(dx) + Math.abs(dy) > 2) drag.moved = true;
+    if (!drag.moved) return;
+    view.x += dx; view.y += dy;
+    drag.x = ev.clientX; drag.y = ev.clientY;
+    autoFit = false;
+    draw();
+  });
+  window.addEventListener("mouseup", function (ev) {
+    if (!drag) return;
+    var moved = drag.moved;
+    drag = null;
+    canvas.classList.remove("dragging");
+    if (moved || ev.target !== canvas) return;
+    var p = toWorld(ev), best = null, bestD = Infinity;
+    nodes.forEach(function (n) {
+      var d = Math.hypot(n.x - p.x, n.y - p.y);
+      if (d < n.r + 4 / view.scale && d < bestD) { best = n; bestD = d; }
+    });
+    if (best) select(best.path);
+  });
+  canvas.addEventListener("wheel", function (ev) {
+    ev.preventDefault();
+    var rect = canvas.getBoundingClientRect();
+    var mx = ev.clientX - rect.left, my = ev.clientY - rect.top;
+    var factor = ev.deltaY < 0 ? 1.1 : 1 / 1.1;
+    view.x = mx - (mx - view.x) * factor;
+    view.y = my - (my - view.y) * factor;
+    view.scale *= factor;
+    autoFit = false;
+    draw();
+  }, { passive: false });
+  window.addEventListener("resize", draw);
+
+  animate();
+})();
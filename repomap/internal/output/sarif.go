@@ -0,0 +1,225 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// The SARIF version written by the sarif format, and its schema.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://docs.oasis-open.org/sarif/sarif/v2.1.0/errata01/os/schemas/sarif-schema-2.1.0.json"
+)
+
+// sarifFingerprint names the fingerprint identifying an issue across runs.
+const sarifFingerprint = "repomapIssue/v1"
+
+// sarifRules describes the issue types found by the analyzers. Issues of
+// other types, such as those recorded in a plan, get a rule named after
+// their type.
+var sarifRules = []sarifRule{
+	{
+		ID:   "duplication",
+		Name: "DuplicateCode",
+		Short: sarifMessage{
+			Text: "Duplicate code block",
+		},
+		Full: sarifMessage{
+			Text: "The same sequence of tokens appears in several files. Extract it into a shared function so the copies cannot drift apart.",
+		},
+	},
+	{
+		ID:   "circular_dependency",
+		Name: "CircularDependency",
+		Short: sarifMessage{
+			Text: "Circular dependency",
+		},
+		Full: sarifMessage{
+			Text: "Files or packages import each other in a cycle, so none of them can be understood, tested or changed in isolation.",
+		},
+	},
+	{
+		ID:   "architectural_violation",
+		Name: "ArchitecturalViolation",
+		Short: sarifMessage{
+			Text: "Architectural layer violation",
+		},
+		Full: sarifMessage{
+			Text: "A file of an inner layer, such as domain or use case code, imports an outer layer such as infrastructure, delivery or commands.",
+		},
+	},
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID    string       `json:"id"`
+	Name  string       `json:"name,omitempty"`
+	Short sarifMessage `json:"shortDescription"`
+	Full  sarifMessage `json:"fullDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	RelatedLocations    []sarifLocation   `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]string `json:"properties"`
+}
+
+type sarifLocation struct {
+	ID               *int                  `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// encodeSARIF renders the issues of the files as a SARIF 2.1.0 log for
+// code scanning. An issue involving several files is reported once in each,
+// located there, with its locations in the other files as related
+// locations.
+func encodeSARIF(m *RepoMap) ([]byte, error) {
+	rules := append([]sarifRule(nil), sarifRules...)
+	ruleIndex := make(map[string]int, len(rules))
+	for i, r := range rules {
+		ruleIndex[r.ID] = i
+	}
+
+	results := []sarifResult{}
+	for _, f := range m.Files {
+		for _, issue := range f.Issues {
+			i, ok := ruleIndex[issue.Type]
+			if !ok {
+				i = len(rules)
+				rules = append(rules, sarifRule{
+					ID:    issue.Type,
+					Short: sarifMessage{Text: issue.Type},
+					Full:  sarifMessage{Text: fmt.Sprintf("Issues of type %s.", issue.Type)},
+				})
+				ruleIndex[issue.Type] = i
+			}
+			results = append(results, sarifIssue(f.Path, issue, i))
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "repomap", Rules: rules}},
+			Results: results,
+		}},
+	}
+	if m.Meta != nil {
+		log.Runs[0].Tool.Driver.Version = m.Meta.Version
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SARIF: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// sarifIssue returns the result reporting the issue in the file at path.
+func sarifIssue(path string, issue Issue, ruleIndex int) sarifResult {
+	primary, ok := issue.In(path)
+	if !ok {
+		primary = Location{Path: path}
+	}
+	result := sarifResult{
+		RuleID:    issue.Type,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(issue.Severity),
+		Message:   sarifMessage{Text: issue.Description},
+		Locations: []sarifLocation{sarifLocationOf(primary)},
+		// The fingerprint leaves lines out, so an issue keeps its identity
+		// when code above it moves.
+		PartialFingerprints: map[string]string{sarifFingerprint: fingerprint(issue.Type, path, issue.Description)},
+		Properties:          map[string]string{"severity": issue.Severity},
+	}
+	for _, l := range issue.Locations {
+		if l.Path == path {
+			continue
+		}
+		related := sarifLocationOf(l)
+		id := len(result.RelatedLocations) + 1
+		related.ID = &id
+		result.RelatedLocations = append(result.RelatedLocations, related)
+	}
+	return result
+}
+
+// sarifLocationOf converts a location. Code scanning needs a line to show
+// a result, so a location known only by its file points at the first line.
+func sarifLocationOf(l Location) sarifLocation {
+	region := sarifRegion{StartLine: max(l.Line, 1)}
+	if l.EndLine > region.StartLine {
+		region.EndLine = l.EndLine
+	}
+	return sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: (&url.URL{Path: l.Path}).String(), URIBaseID: "%SRCROOT%"},
+		Region:           region,
+	}}
+}
+
+// sarifLevel maps an issue severity to a SARIF level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "high":
+		return "error"
+	case "low":
+		return "note"
+	}
+	return "warning"
+}
+
+func fingerprint(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEncodeSARIF(t *testing.T) {
+	dup := Issue{
+		Type: "duplication", Severity: "medium", Description: "Found duplicate code block (50 tokens) across 2 files: a.go, b.go",
+		Locations: []Location{{Path: "a.go", Line: 3, EndLine: 12}, {Path: "b.go", Line: 20, EndLine: 29}},
+	}
+	m := renderFixture(1)
+	m.Meta = &Metadata{Version: "1.2.3"}
+	m.Files = append(m.Files,
+		&FileNode{Path: "a.go", Issues: []Issue{dup}},
+		&FileNode{Path: "b.go", Issues: []Issue{dup, {Type: "todo", Severity: "low", Description: "planned"}}},
+		&FileNode{Path: "dir with space/c.go", Issues: []Issue{{Type: "circular_dependency", Severity: "high", Description: "cycle"}}},
+	)
+
+	r, err := Render(m, "sarif", Options{MaxTokens: 10})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(r.Data, &log); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "repomap" || run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("unexpected driver: %+v", run.Tool.Driver)
+	}
+	if len(run.Results) != 4 {
+		t.Fatalf("expected a result per file and issue whatever the budget, got %d", len(run.Results))
+	}
+
+	// The duplication is reported in each file, with the other as related.
+	a, b := run.Results[0], run.Results[1]
+	if a.RuleID != "duplication" || a.Level != "warning" || run.Tool.Driver.Rules[a.RuleIndex].ID != "duplication" {
+		t.Errorf("unexpected rule or level: %+v", a)
+	}
+	if region := a.Locations[0].PhysicalLocation.Region; region.StartLine != 3 || region.EndLine != 12 {
+		t.Errorf("expected the block in a.go, got %+v", region)
+	}
+	if b.Locations[0].PhysicalLocation.ArtifactLocation.URI != "b.go" || b.RelatedLocations[0].PhysicalLocation.ArtifactLocation.URI != "a.go" {
+		t.Errorf("expected b.go located with a.go related: %+v", b)
+	}
+	if a.PartialFingerprints[sarifFingerprint] == b.PartialFingerprints[sarifFingerprint] {
+		t.Error("results in different files should have different fingerprints")
+	}
+
+	// Unknown types get a rule of their own; unlocated issues the first line.
+	todo, cycle := run.Results[2], run.Results[3]
+	if todo.Level != "note" || run.Tool.Driver.Rules[todo.RuleIndex].ID != "todo" {
+		t.Errorf("unexpected result for a plan issue: %+v", todo)
+	}
+	loc := cycle.Locations[0].PhysicalLocation
+	if cycle.Level != "error" || loc.Region.StartLine != 1 || loc.ArtifactLocation.URI != "dir%20with%20space/c.go" {
+		t.Errorf("unexpected result for an unlocated issue: %+v", cycle)
+	}
+}
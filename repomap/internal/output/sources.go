@@ -0,0 +1,183 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mapShare is the fraction of the budget kept for the map overview of a
+// pack while sources are chosen: a quarter, or less if the whole map
+// needs less.
+const mapShare = 4
+
+// Manifest records which of the file sources considered for a pack were
+// included and which were left out.
+type Manifest struct {
+	Included []ManifestEntry
+	Omitted  []ManifestEntry
+}
+
+// ManifestEntry is a file whose source was considered for a pack.
+type ManifestEntry struct {
+	File *FileNode
+	// Tokens is the cost of the source in the pack.
+	Tokens int
+	// Reason says why an omitted source was left out.
+	Reason string
+}
+
+// reasonBudget is why a source that does not fit is left out. Sources
+// that could not be read give their Skipped reason instead.
+const reasonBudget = "does not fit the budget"
+
+// PackSources chooses the sources to include in a pack within maxTokens,
+// spending overhead tokens on the document wrapper, and packs the map
+// overview into what they leave. Files with a Source are considered in
+// rank order, each included whole if it still fits; a source that does not
+// fit is left out and smaller ones further down may still be included.
+// The overview keeps a quarter of the budget, or what the whole map needs
+// if that is less, and is then packed with PackFiles. If maxTokens is 0,
+// every source is included.
+func PackSources(files []*FileNode, maxTokens, overhead int) (Packing, *Manifest) {
+	manifest := &Manifest{}
+	var candidates []*FileNode
+	for _, f := range files {
+		if f.Source == nil {
+			continue
+		}
+		if f.Source.Skipped != "" {
+			manifest.Omitted = append(manifest.Omitted, ManifestEntry{File: f, Reason: f.Source.Skipped})
+			continue
+		}
+		candidates = append(candidates, f)
+	}
+
+	if maxTokens <= 0 {
+		for _, f := range candidates {
+			manifest.Included = append(manifest.Included, ManifestEntry{File: f, Tokens: sourceCost(f)})
+		}
+		return Packing{Files: files}, manifest
+	}
+
+	// Every candidate takes a manifest line, included or not.
+	spent := overhead
+	for _, f := range files {
+		if f.Source != nil {
+			spent += manifestCost(f)
+		}
+	}
+	mapCost := 0
+	for _, f := range files {
+		mapCost += fileCost(f)
+	}
+	room := maxTokens - spent - min(mapCost, (maxTokens-overhead)/mapShare)
+
+	for _, f := range candidates {
+		cost := sourceCost(f)
+		if cost > room {
+			manifest.Omitted = append(manifest.Omitted, ManifestEntry{File: f, Tokens: cost, Reason: reasonBudget})
+			continue
+		}
+		room -= cost
+		spent += cost
+		manifest.Included = append(manifest.Included, ManifestEntry{File: f, Tokens: cost})
+	}
+	return PackFiles(files, maxTokens, spent), manifest
+}
+
+// sourceCost estimates the tokens the source of a file takes in a pack,
+// with its heading, notes and code fence.
+func sourceCost(f *FileNode) int {
+	return CountTokens(f.Source.Text) + CountTokens(f.Path) + 16
+}
+
+func manifestCost(f *FileNode) int {
+	return CountTokens(f.Path) + 8
+}
+
+// encodePack renders a context pack: the header, a compact map overview,
+// the included sources in rank order and the manifest.
+func encodePack(m *RepoMap, maxTokens int) []byte {
+	manifest := m.Manifest
+	if manifest == nil {
+		_, manifest = PackSources(m.Files, 0, 0)
+	}
+
+	var body strings.Builder
+	writeMarkdownChunk(&body, m.Chunk)
+	if len(m.Files) > 0 {
+		body.WriteString("## Map\n\n")
+	}
+	for _, f := range m.Files {
+		writeCompactFile(&body, f)
+	}
+	writeMarkdownPackages(&body, m.Packages)
+	writeMarkdownSummary(&body, m)
+
+	if len(manifest.Included) > 0 {
+		body.WriteString("## Sources\n\n")
+	}
+	for _, e := range manifest.Included {
+		writeSource(&body, e.File)
+	}
+	writeManifest(&body, manifest)
+
+	return []byte(withTokenHeader(body.String(), func(tokens int) string {
+		return markdownHeader(m, "Context pack", tokens, maxTokens) +
+			fmt.Sprintf("- Sources: %d of %d files considered\n\n", len(manifest.Included), len(manifest.Included)+len(manifest.Omitted))
+	}))
+}
+
+// writeSource writes the source of a file under its heading, with a note
+// of what was removed from it.
+func writeSource(b *strings.Builder, f *FileNode) {
+	src := f.Source
+	fmt.Fprintf(b, "### `%s`\n\n", f.Path)
+	var notes []string
+	if src.Stripped > 0 {
+		notes = append(notes, fmt.Sprintf("%d comment lines stripped", src.Stripped))
+	}
+	if src.Redacted > 0 {
+		notes = append(notes, fmt.Sprintf("%d secrets redacted", src.Redacted))
+	}
+	if src.Elided > 0 {
+		notes = append(notes, fmt.Sprintf("%d lines elided", src.Elided))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(b, "%s\n\n", strings.Join(notes, " · "))
+	}
+
+	text := strings.TrimSuffix(src.Text, "\n")
+	if src.Elided > 0 {
+		text += fmt.Sprintf("\n⋮... %d more lines", src.Elided)
+	}
+	// The fence is longer than any run of backquotes in the source.
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, f.Language, text, fence)
+}
+
+// writeManifest lists the included and omitted sources.
+func writeManifest(b *strings.Builder, manifest *Manifest) {
+	if len(manifest.Included) == 0 && len(manifest.Omitted) == 0 {
+		return
+	}
+	b.WriteString("## Manifest\n\n")
+	for _, e := range manifest.Included {
+		state := "full"
+		if e.File.Source.Elided > 0 {
+			state = "capped"
+		}
+		fmt.Fprintf(b, "- included `%s` (%s, %d tokens)\n", e.File.Path, state, e.Tokens)
+	}
+	for _, e := range manifest.Omitted {
+		if e.Tokens > 0 {
+			fmt.Fprintf(b, "- omitted `%s`: %s (%d tokens)\n", e.File.Path, e.Reason, e.Tokens)
+		} else {
+			fmt.Fprintf(b, "- omitted `%s`: %s\n", e.File.Path, e.Reason)
+		}
+	}
+	b.WriteString("\n")
+}
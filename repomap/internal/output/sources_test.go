@@ -0,0 +1,73 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func packFixture() *RepoMap {
+	m := markdownFixture()
+	m.Files[0].Source = &Source{Text: "package server\n\ntype Server struct{}\n\nfunc (*Server) Start() error { return nil }\n", Stripped: 2}
+	m.Files[1].Source = &Source{Text: strings.Repeat("// filler line of a large file\n", 200)}
+	m.Files[2].Source = &Source{Text: "package internal\n\nvar doc = \"```\"\n", Redacted: 1, Elided: 3}
+	return m
+}
+
+func TestPackSources(t *testing.T) {
+	m := packFixture()
+	_, manifest := PackSources(m.Files, 0, 0)
+	if len(manifest.Included) != 3 || len(manifest.Omitted) != 0 {
+		t.Errorf("without a budget every source should be included: %+v", manifest)
+	}
+
+	// main.go does not fit, but the smaller internal/config.go after it does.
+	packed, manifest := PackSources(m.Files, 700, 20)
+	var included []string
+	for _, e := range manifest.Included {
+		included = append(included, e.File.Path)
+	}
+	if strings.Join(included, " ") != "internal/server/server.go internal/config.go" {
+		t.Errorf("unexpected included sources: %v", included)
+	}
+	if len(manifest.Omitted) != 1 || manifest.Omitted[0].File.Path != "main.go" || manifest.Omitted[0].Reason != reasonBudget {
+		t.Errorf("expected main.go to be omitted for the budget: %+v", manifest.Omitted)
+	}
+	if len(packed.Files) != 3 {
+		t.Errorf("the overview should keep every file, got %d", len(packed.Files))
+	}
+
+	m.Files[1].Source = &Source{Skipped: "binary file"}
+	_, manifest = PackSources(m.Files, 700, 20)
+	if len(manifest.Omitted) != 1 || manifest.Omitted[0].Reason != "binary file" {
+		t.Errorf("expected the unreadable source to be reported: %+v", manifest.Omitted)
+	}
+}
+
+func TestRender_Pack(t *testing.T) {
+	r, err := Render(packFixture(), "pack", Options{MaxTokens: 700})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if r.Tokens > 700 {
+		t.Errorf("pack exceeds the budget: %d tokens", r.Tokens)
+	}
+	out := string(r.Data)
+	for _, s := range []string{
+		"# Context pack: repo\n",
+		"- Sources: 2 of 3 files considered\n",
+		"## Map\n\n**`internal/server/server.go`** (high)\n",
+		"## Sources\n\n### `internal/server/server.go`\n\n2 comment lines stripped\n\n```go\npackage server\n",
+		// The fence outgrows the backquotes of the source.
+		"1 secrets redacted · 3 lines elided\n\n````go\npackage internal\n\nvar doc = \"```\"\n⋮... 3 more lines\n````\n",
+		"## Manifest\n\n- included `internal/server/server.go` (full, ",
+		"- included `internal/config.go` (capped, ",
+		"- omitted `main.go`: does not fit the budget (",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("pack missing %q:\n%s", s, out)
+		}
+	}
+	if strings.Index(out, "## Map") > strings.Index(out, "## Sources") {
+		t.Error("the map overview should come before the sources")
+	}
+}
@@ -0,0 +1,204 @@
+package output
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// templatePrefix selects a built-in template in a format name, as in
+// "template:csv".
+const templatePrefix = "template:"
+
+// Edge is a resolved import between two files of the map.
+type Edge struct {
+	From   string
+	To     string
+	Weight int
+}
+
+// Stats summarizes a map for templates.
+type Stats struct {
+	// Files counts every file in the map; Shown those listed in Files.
+	Files, Shown int
+	Packages     int
+	Definitions  int
+	Issues       int
+	Edges        int
+	// Languages counts the files shown per language.
+	Languages map[string]int
+}
+
+// TemplateData is the model a template is executed with. The fields of the
+// map (Name, Files, Packages, Edges, Rollups, Elided, Dependencies) are
+// promoted, so templates read {{.Files}} or {{.Stats.Issues}}.
+type TemplateData struct {
+	*RepoMap
+	Stats Stats
+	// MaxTokens is the token budget, 0 when there is none.
+	MaxTokens int
+}
+
+// TemplateNames returns the names of the built-in templates.
+func TemplateNames() []string {
+	entries, _ := builtinTemplates.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	return names
+}
+
+// templateEncoder parses the template selected by format: a built-in
+// template for "template:<name>", or opts.Template for "template".
+func templateEncoder(format string, opts Options) (func(*RepoMap) ([]byte, error), error) {
+	name, src := "template", opts.Template
+	if strings.HasPrefix(format, templatePrefix) {
+		name = strings.TrimPrefix(format, templatePrefix)
+		data, err := builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("unknown template: %s (built-in templates are %s)", name, strings.Join(TemplateNames(), ", "))
+		}
+		src = string(data)
+	} else if src == "" {
+		return nil, fmt.Errorf("the template format needs a template")
+	}
+
+	// Helpers that depend on the execution are rebound for each run.
+	tmpl, err := template.New(name).Funcs(templateFuncs(0, new(int))).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return func(m *RepoMap) ([]byte, error) {
+		t, err := tmpl.Clone()
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		data := &TemplateData{RepoMap: m, Stats: mapStats(m), MaxTokens: opts.MaxTokens}
+		if err := t.Funcs(templateFuncs(opts.MaxTokens, new(int))).Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to execute template: %w", err)
+		}
+		return buf.Bytes(), nil
+	}, nil
+}
+
+// templateFuncs returns the helpers available to templates. spent counts
+// the tokens charged with spend during one execution.
+func templateFuncs(maxTokens int, spent *int) template.FuncMap {
+	return template.FuncMap{
+		// Text
+		"truncate": truncate,
+		"join":     func(sep string, items []string) string { return strings.Join(items, sep) },
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"repeat":   func(n int, s string) string { return strings.Repeat(s, n) },
+		"trim":     strings.TrimSpace,
+		"csv":      csvField,
+		// Paths
+		"base":      path.Base,
+		"dir":       path.Dir,
+		"ext":       path.Ext,
+		"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"trimPrefix": func(prefix, s string) string {
+			return strings.TrimPrefix(s, prefix)
+		},
+		// Tokens and budget
+		"tokens": CountTokens,
+		"fits": func(s string) bool {
+			return maxTokens <= 0 || *spent+CountTokens(s) <= maxTokens
+		},
+		"spend": func(s string) bool {
+			n := CountTokens(s)
+			if maxTokens > 0 && *spent+n > maxTokens {
+				return false
+			}
+			*spent += n
+			return true
+		},
+		"remaining": func() int {
+			if maxTokens <= 0 {
+				return -1
+			}
+			return maxTokens - *spent
+		},
+		// Model
+		"name": DefinitionName,
+		"imports": func(edges []*Edge, from string) []*Edge {
+			return filterEdges(edges, func(e *Edge) bool { return e.From == from })
+		},
+		"importers": func(edges []*Edge, to string) []*Edge {
+			return filterEdges(edges, func(e *Edge) bool { return e.To == to })
+		},
+	}
+}
+
+// truncate shortens s to at most n runes, marking the cut with "…".
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// csvField quotes s for a CSV record when needed.
+func csvField(s string) string {
+	if !strings.ContainsAny(s, ",\"\n\r") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func filterEdges(edges []*Edge, keep func(*Edge) bool) []*Edge {
+	var kept []*Edge
+	for _, e := range edges {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func mapStats(m *RepoMap) Stats {
+	stats := Stats{
+		Shown:     len(m.Files),
+		Files:     len(m.Files),
+		Packages:  len(m.Packages),
+		Edges:     len(m.Edges),
+		Languages: make(map[string]int),
+	}
+	for _, e := range m.Elided {
+		if e.Level == DetailRollup || e.Level == DetailOmitted {
+			stats.Files += e.Files
+		}
+	}
+
+	for _, f := range m.Files {
+		stats.Definitions += len(f.Definitions)
+		stats.Issues += len(f.Issues)
+		stats.Languages[f.Language]++
+	}
+	for _, p := range m.Packages {
+		stats.Definitions += p.Definitions
+		stats.Issues += p.Issues
+		stats.Files += p.Files
+	}
+	if m.Chunk != nil {
+		// A chunk counts the files of the whole map.
+		stats.Files = 0
+		for _, e := range m.Chunk.Index {
+			stats.Files += e.Files
+		}
+	}
+	return stats
+}
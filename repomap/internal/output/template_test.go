@@ -0,0 +1,75 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func templateFixture() *RepoMap {
+	m := markdownFixture()
+	m.Files[1].Status = "planned, not started"
+	m.Edges = []*Edge{
+		{From: "main.go", To: "internal/server/server.go", Weight: 1},
+		{From: "internal/server/server.go", To: "internal/config.go", Weight: 1},
+	}
+	return m
+}
+
+func TestRender_BuiltinTemplates(t *testing.T) {
+	r, err := Render(templateFixture(), "template:csv", Options{})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	want := "path,language,importance,rank,definitions,issues,status\n" +
+		"internal/server/server.go,go,high,1.0000,2,1,\n" +
+		"main.go,go,low,0.2000,1,0,\"planned, not started\"\n" +
+		"internal/config.go,go,low,0.1000,0,0,\n"
+	if string(r.Data) != want {
+		t.Errorf("unexpected csv output:\n%s", r.Data)
+	}
+
+	for _, name := range TemplateNames() {
+		if _, err := Render(templateFixture(), "template:"+name, Options{MaxTokens: 100}); err != nil {
+			t.Errorf("built-in template %s failed: %v", name, err)
+		}
+	}
+	if _, err := Render(templateFixture(), "template:missing", Options{}); err == nil {
+		t.Error("expected error for an unknown built-in template")
+	}
+}
+
+func TestRender_Template(t *testing.T) {
+	src := `{{.Name}} {{.Stats.Shown}}/{{.Stats.Files}} files, {{.Stats.Issues}} issues
+{{range .Files}}{{if spend .Path}}{{base .Path}} in {{dir .Path}}: {{truncate 12 (join "; " .Definitions)}}
+{{- range imports $.Edges .Path}} -> {{.To}}{{end}}
+{{end}}{{end}}remaining {{remaining}}`
+
+	r, err := Render(templateFixture(), "template", Options{Template: src, MaxTokens: 200})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	want := "repo 3/3 files, 1 issues\n" +
+		"server.go in internal/server: type Server… -> internal/config.go\n" +
+		"main.go in .: func main() -> internal/server/server.go\n" +
+		"config.go in internal: \n" +
+		"remaining "
+	if !strings.HasPrefix(string(r.Data), want) {
+		t.Errorf("unexpected template output:\n%s", r.Data)
+	}
+
+	// spend stops listing files once the budget is used up.
+	r, err = Render(templateFixture(), "template", Options{Template: `{{range .Files}}{{if spend (repeat 45 "abcd")}}{{.Path}} {{end}}{{end}}`, MaxTokens: 100})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if string(r.Data) != "internal/server/server.go main.go " {
+		t.Errorf("expected spend to stop after two files, got %q", r.Data)
+	}
+
+	if err := CheckFormat("template", Options{Template: "{{.Files"}); err == nil {
+		t.Error("expected error for a malformed template")
+	}
+	if err := CheckFormat("template", Options{}); err == nil {
+		t.Error("expected error for a missing template")
+	}
+}
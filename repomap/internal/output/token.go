@@ -1,7 +1,23 @@
 package output
 
-// CountTokens estimates the number of tokens in a string.
-// For the MVP, it uses a simple heuristic: length of string divided by 4.
+// counter is the token counter used by CountTokens.
+var counter = heuristicCount
+
+// CountTokens counts the tokens in a string with the counter set by
+// SetCounter. By default it estimates one token per four bytes.
 func CountTokens(text string) int {
+	return counter(text)
+}
+
+// SetCounter replaces the token counter, e.g. with a BPE tokenizer. A nil
+// count restores the default estimate.
+func SetCounter(count func(text string) int) {
+	if count == nil {
+		count = heuristicCount
+	}
+	counter = count
+}
+
+func heuristicCount(text string) int {
 	return len(text) / 4
 }
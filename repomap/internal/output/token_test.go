@@ -23,3 +23,16 @@ func TestCountTokens(t *testing.T) {
 		}
 	}
 }
+
+func TestSetCounter(t *testing.T) {
+	SetCounter(func(text string) int { return len(text) })
+	defer SetCounter(nil)
+
+	if got := CountTokens("abcd"); got != 4 {
+		t.Errorf("expected the custom counter, got %d", got)
+	}
+	SetCounter(nil)
+	if got := CountTokens("abcd"); got != 1 {
+		t.Errorf("expected the default estimate after reset, got %d", got)
+	}
+}
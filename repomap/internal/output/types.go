@@ -12,7 +12,26 @@ type FileNode struct {
 	Rank        float64  `json:"rank" xml:"rank,attr"`
 	Definitions []string `json:"definitions" xml:"definition"`
 	Imports     []string `json:"imports,omitempty" xml:"import,omitempty"`
-	TokenCount  int       `json:"token_count" xml:"token_count,attr"`
+	// UnresolvedImports lists imports that look local but match no known file.
+	UnresolvedImports []string `json:"unresolved_imports,omitempty" xml:"unresolved_import,omitempty"`
+	TokenCount        int      `json:"token_count" xml:"token_count,attr"`
+	// Detail is set when the file was reduced to fit the token budget:
+	// "names" keeps definition names only, "path" drops them.
+	Detail string `json:"detail,omitempty" xml:"detail,attr,omitempty"`
+	// External marks vendored third-party files included for context.
+	External bool `json:"external,omitempty" xml:"external,attr,omitempty"`
+	// Module is the path of the Go module the file belongs to, if any.
+	Module string `json:"module,omitempty" xml:"module,attr,omitempty"`
+	// Change history, filled when history is read (see --history).
+	Churn        int    `json:"churn,omitempty" xml:"churn,attr,omitempty"`
+	LastModified string `json:"last_modified,omitempty" xml:"last_modified,attr,omitempty"`
+	Authors      int    `json:"authors,omitempty" xml:"authors,attr,omitempty"`
+	// Outline holds the definition lines and their enclosing scopes shown
+	// by the tree format, when they were extracted.
+	Outline []Line `json:"-" xml:"-"`
+	// Source holds the prepared source text shown by the pack format, when
+	// the file is among those considered for it.
+	Source *Source `json:"-" xml:"-"`
 	// Planning Features
 	Status   string    `json:"status,omitempty" xml:"status,attr,omitempty"`
 	Intent   string    `json:"intent,omitempty" xml:"intent,omitempty"`
@@ -20,10 +39,48 @@ type FileNode struct {
 	Comments []Comment `json:"comments,omitempty" xml:"comment,omitempty"`
 }
 
+// Line is a numbered source line.
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Source is the source text of a file, prepared for the pack format.
+type Source struct {
+	Text string
+	// Stripped counts the comment lines removed, Redacted the secrets
+	// replaced and Elided the lines cut by the per-file cap.
+	Stripped, Redacted, Elided int
+	// Skipped says why the source could not be read, when it was not.
+	Skipped string
+}
+
 type Issue struct {
 	Type        string `json:"type" xml:"type,attr"`
 	Description string `json:"description" xml:"description"`
 	Severity    string `json:"severity" xml:"severity,attr"`
+	// Cycle is the import cycle behind a circular_dependency issue.
+	Cycle []string `json:"cycle,omitempty" xml:"cycle,omitempty"`
+	// Locations lists where the issue is found, one per file involved.
+	Locations []Location `json:"locations,omitempty" xml:"location,omitempty"`
+}
+
+// Location is a position in a file. Line is 0 when only the file is known;
+// EndLine is set for locations spanning several lines.
+type Location struct {
+	Path    string `json:"path" xml:"path,attr"`
+	Line    int    `json:"line,omitempty" xml:"line,attr,omitempty"`
+	EndLine int    `json:"end_line,omitempty" xml:"end_line,attr,omitempty"`
+}
+
+// In returns the location of the issue in the file at path, if it has one.
+func (i Issue) In(path string) (Location, bool) {
+	for _, l := range i.Locations {
+		if l.Path == path {
+			return l, true
+		}
+	}
+	return Location{}, false
 }
 
 type Comment struct {
@@ -31,10 +88,76 @@ type Comment struct {
 	Text string `json:"text" xml:"text"`
 }
 
+// Dependency is a third-party module imported by the repository.
+type Dependency struct {
+	Name string `json:"name" xml:"name,attr"`
+	// Version comes from a lockfile when available, otherwise from the
+	// manifest (which may be a range such as "^1.2.0").
+	Version   string `json:"version,omitempty" xml:"version,attr,omitempty"`
+	Ecosystem string `json:"ecosystem" xml:"ecosystem,attr"`
+	// Files lists the files importing the module.
+	Files []string `json:"files" xml:"file"`
+}
+
+// Metadata records what produced a map, so consumers can cache, compare
+// and reproduce it.
+type Metadata struct {
+	// Generated is the generation time (RFC 3339), omitted from
+	// reproducible maps.
+	Generated string `json:"generated,omitempty" xml:"generated,attr,omitempty"`
+	Version   string `json:"version" xml:"version,attr"`
+	Root      string `json:"root" xml:"root,attr"`
+	// Commit is the git HEAD of the root, and Dirty reports uncommitted
+	// changes.
+	Commit    string `json:"commit,omitempty" xml:"commit,attr,omitempty"`
+	Dirty     bool   `json:"dirty,omitempty" xml:"dirty,attr,omitempty"`
+	Tokenizer string `json:"tokenizer" xml:"tokenizer,attr"`
+	MaxTokens int    `json:"max_tokens,omitempty" xml:"max_tokens,attr,omitempty"`
+	// ContentHash is the SHA-256 of the map content, metadata excluded. It
+	// is filled by Render.
+	ContentHash string   `json:"content_hash,omitempty" xml:"content_hash,attr,omitempty"`
+	Modules     []string `json:"modules,omitempty" xml:"module,omitempty"`
+	// Options lists the options the map was generated with.
+	Options []Option `json:"options,omitempty" xml:"option,omitempty"`
+}
+
+// Option is a named option value recorded in the metadata.
+type Option struct {
+	Name  string `json:"name" xml:"name,attr"`
+	Value string `json:"value" xml:"value,attr"`
+}
+
+// SchemaVersion identifies the shape of the JSON and XML maps. It changes
+// whenever a field is renamed or removed or changes meaning, so tools
+// reading maps can tell which schema to validate against.
+const SchemaVersion = "1"
+
 // RepoMap represents the complete repository map output.
 type RepoMap struct {
-	Files   []*FileNode `json:"files" xml:"file"`
-	XMLName struct{}    `json:"-" xml:"repomap"`
+	// SchemaVersion is set to SchemaVersion when the map is rendered.
+	SchemaVersion string `json:"schemaVersion" xml:"schemaVersion,attr"`
+	// Meta describes how the map was generated, when known.
+	Meta *Metadata `json:"metadata,omitempty" xml:"metadata,omitempty"`
+	// Chunk places the map among the chunks of a map split by Split.
+	Chunk *Chunk `json:"chunk,omitempty" xml:"chunk,omitempty"`
+	// Name of the repository, shown in the markdown header.
+	Name  string      `json:"-" xml:"-"`
+	Files []*FileNode `json:"files,omitempty" xml:"file"`
+	// Packages holds the collapsed map when rendering at package or
+	// directory granularity; Files is empty in that case.
+	Packages []*PackageNode `json:"packages,omitempty" xml:"package,omitempty"`
+	// Edges holds the resolved imports between files, for templates.
+	Edges []*Edge `json:"-" xml:"-"`
+	// Rollups summarize directories whose files did not fit the token
+	// budget individually.
+	Rollups []*Rollup `json:"rollups,omitempty" xml:"rollup,omitempty"`
+	// Elided reports how much detail was left out to fit the budget.
+	Elided []Elision `json:"elided,omitempty" xml:"elided,omitempty"`
+	// Manifest lists the sources a pack includes and leaves out.
+	Manifest *Manifest `json:"-" xml:"-"`
+	// Dependencies lists third-party modules when requested.
+	Dependencies []*Dependency `json:"dependencies,omitempty" xml:"dependency,omitempty"`
+	XMLName      struct{}      `json:"-" xml:"repomap"`
 }
 
 // Validate checks if the FileNode is valid.
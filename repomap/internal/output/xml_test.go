@@ -1,6 +1,7 @@
 package output
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -33,7 +34,7 @@ func TestRenderXML(t *testing.T) {
 
 	expectedSubstrings := []string{
 		`<?xml version="1.0" encoding="UTF-8"?>`,
-		`<repomap>`,
+		`<repomap schemaVersion="1">`,
 		`<file path="main.go" language="go" importance="high" rank="0.95" token_count="100">`,
 		`<definition>func main()</definition>`,
 		`<file path="pkg/utils.go" language="go" importance="medium" rank="0.5" token_count="50">`,
@@ -46,23 +47,29 @@ func TestRenderXML(t *testing.T) {
 		}
 	}
 
-	// Case 2: Budget limit (allow first node, truncates second)
-	// Header ~12 tokens. Node 1 cost 100. Total 112.
-	// We set maxTokens to 120. Node 2 cost 50. 112 + 50 = 162 > 120.
-	// So Node 2 should be truncated.
+	// Case 2: Budget limit (main.go keeps its signatures, utils.go is degraded)
+	// Its 20 signatures take about 400 tokens, which do not fit in 137.
+	// main.go in full and utils.go as a bare path, with the elision report,
+	// take 95. So Node 2 is listed by path only.
+	for i := 0; i < 20; i++ {
+		nodes[1].Definitions = append(nodes[1].Definitions, fmt.Sprintf("func Helper%02d(ctx context.Context, opts Options) error", i))
+	}
 
-	xmlOutputLimited, err := RenderXML(nodes, 120)
+	xmlOutputLimited, err := RenderXML(nodes, 137)
 	if err != nil {
 		t.Fatalf("RenderXML limited failed: %v", err)
 	}
 
-	if !strings.Contains(xmlOutputLimited, "main.go") {
-		t.Error("Limited XML should contain main.go")
+	if !strings.Contains(xmlOutputLimited, "<definition>func main()</definition>") {
+		t.Error("Limited XML should keep the definitions of main.go")
+	}
+	if !strings.Contains(xmlOutputLimited, `<file path="pkg/utils.go" language="go" importance="medium" rank="0.5" token_count="50" detail="path">`) {
+		t.Errorf("Limited XML should list pkg/utils.go by path only:\n%s", xmlOutputLimited)
 	}
-	if strings.Contains(xmlOutputLimited, "pkg/utils.go") {
-		t.Error("Limited XML should NOT contain pkg/utils.go")
+	if strings.Contains(xmlOutputLimited, "func Helper()") {
+		t.Error("Limited XML should NOT contain the definitions of pkg/utils.go")
 	}
-	if !strings.Contains(xmlOutputLimited, "Output truncated") {
-		t.Error("Limited XML should contain truncation notice")
+	if !strings.Contains(xmlOutputLimited, `<elided level="path" files="1" symbols="21"></elided>`) {
+		t.Errorf("Limited XML should report the elided symbols:\n%s", xmlOutputLimited)
 	}
 }
@@ -4,5 +4,6 @@ Package parsing provides functionality to parse Go source files and extract rele
 It includes:
 - Definition extraction: Identifies top-level function, method, type, and interface declarations.
 - Import extraction: Identifies imported packages and normalizes paths.
+- Outline extraction: Locates definition lines and their enclosing scopes (see Outliner).
 */
 package parsing
@@ -10,6 +10,10 @@ import (
 type GenericExtractor struct {
 	DefKeywords    []string
 	ImportKeywords []string
+	// ParseImport, when set, replaces keyword matching for imports. It is called
+	// with every non-empty trimmed line, including lines that look like comments
+	// (C's #include), and returns the imports declared on that line.
+	ParseImport func(line string) []string
 }
 
 func (e *GenericExtractor) ExtractImports(filePath string) ([]string, error) {
@@ -23,6 +27,10 @@ func (e *GenericExtractor) ExtractImports(filePath string) ([]string, error) {
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		if line != "" && e.ParseImport != nil {
+			imports = append(imports, e.ParseImport(line)...)
+			continue
+		}
 		if line == "" || isComment(line) {
 			continue
 		}
@@ -66,16 +74,23 @@ func (e *GenericExtractor) ExtractDefinitions(filePath string) ([]string, error)
 			continue
 		}
 
-		for _, kw := range e.DefKeywords {
-			if strings.HasPrefix(line, kw+" ") {
-				definitions = append(definitions, line)
-				break
-			}
+		if e.isDefinition(line) {
+			definitions = append(definitions, line)
 		}
 	}
 	return definitions, scanner.Err()
 }
 
+// isDefinition reports whether a trimmed line starts with a definition keyword.
+func (e *GenericExtractor) isDefinition(line string) bool {
+	for _, kw := range e.DefKeywords {
+		if strings.HasPrefix(line, kw+" ") {
+			return true
+		}
+	}
+	return false
+}
+
 func isComment(line string) bool {
 	return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#")
 }
@@ -86,9 +101,32 @@ func init() {
 		ImportKeywords: []string{"import", "from", "require"},
 	}
 
+	// Languages whose import syntax the keyword scan cannot read get a
+	// dedicated import parser on top of the same definition keywords.
+	importParsers := map[string]func(string) []string{
+		".ts":   parseJSImport,
+		".tsx":  parseJSImport,
+		".js":   parseJSImport,
+		".jsx":  parseJSImport,
+		".py":   parsePythonImport,
+		".rs":   parseRustImport,
+		".java": parseJavaImport,
+		".cpp":  parseCInclude,
+		".c":    parseCInclude,
+		".h":    parseCInclude,
+	}
+
 	// Register for common web/scripting extensions as fallback
 	exts := []string{".ts", ".tsx", ".js", ".jsx", ".py", ".rs", ".java", ".cpp", ".c", ".h", ".cs"}
 	for _, ext := range exts {
-		DefaultRegistry.Register(ext, generic)
+		extractor := generic
+		if parse, ok := importParsers[ext]; ok {
+			extractor = &GenericExtractor{
+				DefKeywords:    generic.DefKeywords,
+				ImportKeywords: generic.ImportKeywords,
+				ParseImport:    parse,
+			}
+		}
+		DefaultRegistry.Register(ext, extractor)
 	}
 }
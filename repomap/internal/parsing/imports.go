@@ -0,0 +1,170 @@
+package parsing
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Line-based import parsers for languages handled by GenericExtractor.
+// Each takes a trimmed source line and returns the raw imports it declares,
+// in the form the matching resolver in internal/graph expects.
+
+// jsImportPattern matches `... from "x"`, `import "x"`, `import("x")`,
+// `require("x")` and `export ... from "x"`, including the closing line of a
+// multi-line import.
+var jsImportPattern = regexp.MustCompile(`(?:\bfrom\s*|\bimport\s*\(?\s*|\brequire\s*\(\s*)['"]([^'"]+)['"]`)
+
+func parseJSImport(line string) []string {
+	if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "/*") {
+		return nil
+	}
+	var imports []string
+	for _, m := range jsImportPattern.FindAllStringSubmatch(line, -1) {
+		imports = append(imports, m[1])
+	}
+	return imports
+}
+
+// parsePythonImport returns dotted module names. Relative imports keep their
+// leading dots, and `from . import a` yields ".a" since a is a sibling module.
+func parsePythonImport(line string) []string {
+	if rest, ok := strings.CutPrefix(line, "import "); ok {
+		var imports []string
+		for _, part := range strings.Split(stripTrailingComment(rest), ",") {
+			if name := firstField(part); name != "" {
+				imports = append(imports, name)
+			}
+		}
+		return imports
+	}
+
+	rest, ok := strings.CutPrefix(line, "from ")
+	if !ok {
+		return nil
+	}
+	module, names, ok := strings.Cut(stripTrailingComment(rest), " import ")
+	if !ok {
+		return nil
+	}
+	module = strings.TrimSpace(module)
+	if strings.Trim(module, ".") != "" {
+		return []string{module}
+	}
+
+	var imports []string
+	for _, part := range strings.Split(strings.Trim(strings.TrimSpace(names), "()"), ",") {
+		if name := firstField(part); name != "" && name != "*" {
+			imports = append(imports, module+name)
+		}
+	}
+	if len(imports) == 0 {
+		return []string{module}
+	}
+	return imports
+}
+
+// parseRustImport handles `use` paths (expanding one level of braces) and
+// `mod x;` declarations, which are reported as "self::x".
+func parseRustImport(line string) []string {
+	line = stripTrailingComment(line)
+	for _, prefix := range []string{"pub(crate) ", "pub(super) ", "pub "} {
+		line = strings.TrimPrefix(line, prefix)
+	}
+
+	if rest, ok := strings.CutPrefix(line, "mod "); ok {
+		name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), ";"))
+		if name == "" || strings.ContainsAny(name, "{ ") {
+			return nil // inline module
+		}
+		return []string{"self::" + name}
+	}
+
+	rest, ok := strings.CutPrefix(line, "use ")
+	if !ok {
+		return nil
+	}
+	rest = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), ";"))
+
+	open := strings.Index(rest, "{")
+	if open == -1 {
+		return []string{rustUsePath(rest)}
+	}
+	prefix := rest[:open]
+	inner := strings.TrimSuffix(rest[open+1:], "}")
+	var imports []string
+	for _, part := range strings.Split(inner, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || strings.ContainsAny(part, "{}") {
+			continue
+		}
+		if part == "self" {
+			imports = append(imports, strings.TrimSuffix(prefix, "::"))
+			continue
+		}
+		imports = append(imports, rustUsePath(prefix+part))
+	}
+	return imports
+}
+
+// rustUsePath drops an `as` alias and a trailing glob from a use path.
+func rustUsePath(p string) string {
+	p, _, _ = strings.Cut(p, " as ")
+	return strings.TrimSuffix(strings.TrimSpace(p), "::*")
+}
+
+// parseCInclude returns quoted includes as-is and system includes wrapped in
+// angle brackets, so resolvers can tell them apart.
+func parseCInclude(line string) []string {
+	rest, ok := strings.CutPrefix(line, "#")
+	if !ok {
+		return nil
+	}
+	rest, ok = strings.CutPrefix(strings.TrimSpace(rest), "include")
+	if !ok {
+		return nil
+	}
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, `"`) {
+		if end := strings.Index(rest[1:], `"`); end != -1 {
+			return []string{rest[1 : end+1]}
+		}
+	}
+	if strings.HasPrefix(rest, "<") {
+		if end := strings.Index(rest, ">"); end != -1 {
+			return []string{rest[:end+1]}
+		}
+	}
+	return nil
+}
+
+// parseJavaImport returns the fully qualified name of `import` and
+// `import static` declarations, keeping a trailing ".*".
+func parseJavaImport(line string) []string {
+	rest, ok := strings.CutPrefix(line, "import ")
+	if !ok {
+		return nil
+	}
+	rest = strings.TrimPrefix(strings.TrimSpace(rest), "static ")
+	rest = strings.TrimSpace(strings.TrimSuffix(stripTrailingComment(rest), ";"))
+	if rest == "" {
+		return nil
+	}
+	return []string{rest}
+}
+
+func stripTrailingComment(s string) string {
+	for _, marker := range []string{"//", " #"} {
+		if i := strings.Index(s, marker); i != -1 {
+			s = s[:i]
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+func firstField(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
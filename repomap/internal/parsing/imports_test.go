@@ -0,0 +1,96 @@
+package parsing
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractImports_Languages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		file string
+		src  string
+		want []string
+	}{
+		{
+			file: "app.tsx",
+			src: `import React from "react";
+import {
+  Graph,
+  Sidebar,
+} from './components';
+export { useMap } from "@/hooks/useMap";
+import './styles.css';
+const lazy = () => import("./Lazy");
+// import "./commented";
+const fs = require('fs');
+`,
+			want: []string{"react", "./components", "@/hooks/useMap", "./styles.css", "./Lazy", "fs"},
+		},
+		{
+			file: "views.py",
+			src: `import os, sys as system
+from ..utils import helper  # comment
+from . import models, forms
+from .pkg.sub import thing
+from package.module import (
+    a,
+)
+`,
+			want: []string{"os", "sys", "..utils", ".models", ".forms", ".pkg.sub", "package.module"},
+		},
+		{
+			file: "lib.rs",
+			src: `mod config;
+pub mod server;
+mod inline { }
+use std::collections::HashMap;
+use crate::config::Config;
+use super::util::{self, parse, Format as F};
+pub(crate) use self::server::*;
+`,
+			want: []string{"self::config", "self::server", "std::collections::HashMap", "crate::config::Config", "super::util", "super::util::parse", "super::util::Format", "self::server"},
+		},
+		{
+			file: "main.c",
+			src: `#include <stdio.h>
+# include "../include/x.h"
+#define X 1
+`,
+			want: []string{"<stdio.h>", "../include/x.h"},
+		},
+		{
+			file: "Main.java",
+			src: `package com.acme.app;
+
+import java.util.List;
+import static com.acme.util.Strings.join;
+import com.acme.model.*;
+`,
+			want: []string{"java.util.List", "com.acme.util.Strings.join", "com.acme.model.*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			extractor := DefaultRegistry.Get(path)
+			if extractor == nil {
+				t.Fatalf("no extractor for %s", tt.file)
+			}
+			got, err := extractor.ExtractImports(path)
+			if err != nil {
+				t.Fatalf("ExtractImports failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package parsing
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Line is a numbered source line.
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Outliner is implemented by extractors that can locate definitions in the
+// source. ExtractOutline returns the definition lines of a file together
+// with the lines of their enclosing scopes, in source order.
+type Outliner interface {
+	ExtractOutline(filePath string) ([]Line, error)
+}
+
+func (e *GoExtractor) ExtractOutline(filePath string) ([]Line, error) {
+	return ExtractGoOutline(filePath)
+}
+
+// ExtractGoOutline returns the package clause, type and function
+// declarations of a Go file, and the methods of its interfaces. Grouped
+// type declarations keep their "type (" line as the enclosing scope.
+func ExtractGoOutline(filePath string) ([]Line, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := map[int]bool{fset.Position(node.Package).Line: true}
+	add := func(pos token.Pos) { numbers[fset.Position(pos).Line] = true }
+
+	for _, decl := range node.Decls {
+		switch x := decl.(type) {
+		case *ast.FuncDecl:
+			add(x.Pos())
+		case *ast.GenDecl:
+			if x.Tok != token.TYPE {
+				continue
+			}
+			if x.Lparen.IsValid() {
+				add(x.Pos())
+			}
+			for _, spec := range x.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				add(typeSpec.Pos())
+				if iface, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+					for _, method := range iface.Methods.List {
+						add(method.Pos())
+					}
+				}
+			}
+		}
+	}
+
+	return sourceLines(string(src), numbers), nil
+}
+
+func (e *GenericExtractor) ExtractOutline(filePath string) ([]Line, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(src), "\n")
+
+	// Definitions are matched by keyword; their scopes are the nearest
+	// preceding lines with less indentation, such as the class of a method.
+	numbers := make(map[int]bool)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isComment(trimmed) || !e.isDefinition(trimmed) {
+			continue
+		}
+		numbers[i+1] = true
+		indent := indentation(line)
+		for j := i - 1; j >= 0 && indent > 0; j-- {
+			trimmed := strings.TrimSpace(lines[j])
+			if trimmed == "" || isComment(trimmed) || indentation(lines[j]) >= indent {
+				continue
+			}
+			numbers[j+1] = true
+			indent = indentation(lines[j])
+		}
+	}
+
+	return sourceLines(string(src), numbers), nil
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// sourceLines returns the numbered lines of src, in order.
+func sourceLines(src string, numbers map[int]bool) []Line {
+	lines := strings.Split(src, "\n")
+	outline := make([]Line, 0, len(numbers))
+	for n := range numbers {
+		if n >= 1 && n <= len(lines) {
+			outline = append(outline, Line{Number: n, Text: strings.TrimRight(lines[n-1], " \t\r")})
+		}
+	}
+	sort.Slice(outline, func(i, j int) bool { return outline[i].Number < outline[j].Number })
+	return outline
+}
@@ -0,0 +1,104 @@
+package parsing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func outlineNumbers(lines []Line) []int {
+	var numbers []int
+	for _, l := range lines {
+		numbers = append(numbers, l.Number)
+	}
+	return numbers
+}
+
+func TestExtractGoOutline(t *testing.T) {
+	src := `package server
+
+import "net/http"
+
+type (
+	Handler interface {
+		Serve(w http.ResponseWriter)
+	}
+	Options struct{ Addr string }
+)
+
+// Server serves requests.
+type Server struct {
+	opts Options
+}
+
+func (s *Server) Start() error {
+	return nil
+}
+`
+	path := filepath.Join(t.TempDir(), "server.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := ExtractGoOutline(path)
+	if err != nil {
+		t.Fatalf("ExtractGoOutline failed: %v", err)
+	}
+	want := []Line{
+		{1, "package server"},
+		{5, "type ("},
+		{6, "	Handler interface {"},
+		{7, "		Serve(w http.ResponseWriter)"},
+		{9, "	Options struct{ Addr string }"},
+		{13, "type Server struct {"},
+		{17, "func (s *Server) Start() error {"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected lines %v, got %v", outlineNumbers(want), outlineNumbers(lines))
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %+v, got %+v", i, want[i], lines[i])
+		}
+	}
+}
+
+func TestGenericExtractOutline(t *testing.T) {
+	src := `import os
+
+class Handler:
+    """Handles requests."""
+
+    def serve(self, request):
+        return None
+
+    @property
+    def name(self):
+        return "handler"
+
+def main():
+    Handler().serve(None)
+`
+	path := filepath.Join(t.TempDir(), "handler.py")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := DefaultRegistry.Get(path).(Outliner).ExtractOutline(path)
+	if err != nil {
+		t.Fatalf("ExtractOutline failed: %v", err)
+	}
+	got := outlineNumbers(lines)
+	want := []int{3, 6, 10, 13}
+	if len(got) != len(want) {
+		t.Fatalf("expected lines %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected lines %v, got %v", want, got)
+		}
+	}
+	if lines[1].Text != "    def serve(self, request):" {
+		t.Errorf("expected the source text with its indentation, got %q", lines[1].Text)
+	}
+}
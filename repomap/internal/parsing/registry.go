@@ -5,6 +5,11 @@ import (
 	"strings"
 )
 
+// Version identifies the output of the extractors. Bump it whenever a
+// change to an extractor changes the definitions, imports or outlines it
+// returns, so that results cached by an older version are not reused.
+const Version = "1"
+
 // Extractor interface for language-specific definition extraction.
 type Extractor interface {
 	ExtractDefinitions(filePath string) ([]string, error)
@@ -0,0 +1,10 @@
+/*
+Package query answers dependency questions over the import graph.
+
+It includes:
+- Deps and Rdeps: files reachable along imports or reverse imports, with a depth limit.
+- Path: the shortest import path between two files or directories.
+- Impact: the transitive reverse closure of a change, ranked by importance.
+- Rendering of results as text, JSON or XML within a token budget.
+*/
+package query
@@ -0,0 +1,180 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+)
+
+// Entry is a file reached by a query. Depth is the number of imports
+// between it and the nearest target.
+type Entry struct {
+	Path       string  `json:"path" xml:"path,attr"`
+	Depth      int     `json:"depth" xml:"depth,attr"`
+	Rank       float64 `json:"rank" xml:"rank,attr"`
+	Importance string  `json:"importance,omitempty" xml:"importance,attr,omitempty"`
+}
+
+// Querier runs queries against one graph.
+type Querier struct {
+	g       *graph.Graph
+	reverse map[string][]string
+	// Ranks and Importance annotate entries; either may be nil.
+	Ranks      map[string]float64
+	Importance map[string]string
+}
+
+// New prepares a graph for querying.
+func New(g *graph.Graph) *Querier {
+	q := &Querier{g: g, reverse: make(map[string][]string)}
+	for src, dsts := range g.Edges {
+		for _, dst := range dsts {
+			q.reverse[dst] = append(q.reverse[dst], src)
+		}
+	}
+	for dst := range q.reverse {
+		sort.Strings(q.reverse[dst])
+	}
+	return q
+}
+
+// Resolve maps a query argument to graph nodes: the node itself, or every
+// node under it when it names a directory.
+func (q *Querier) Resolve(arg string) ([]string, error) {
+	arg = strings.TrimSuffix(strings.TrimPrefix(arg, "./"), "/")
+	if _, ok := q.g.Nodes[arg]; ok {
+		return []string{arg}, nil
+	}
+
+	var nodes []string
+	for p := range q.g.Nodes {
+		if arg == "." || arg == "" || strings.HasPrefix(p, arg+"/") {
+			nodes = append(nodes, p)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no file or directory %q in the graph", arg)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// Deps returns the nodes the targets import, directly or transitively, up to
+// depth imports away (0 for no limit).
+func (q *Querier) Deps(targets []string, depth int) []Entry {
+	return q.walk(targets, depth, q.g.Edges)
+}
+
+// Rdeps returns the nodes importing the targets, directly or transitively,
+// up to depth imports away (0 for no limit).
+func (q *Querier) Rdeps(targets []string, depth int) []Entry {
+	return q.walk(targets, depth, q.reverse)
+}
+
+// Impact returns every node that may be affected by a change to the targets
+// (the transitive reverse closure), most important first.
+func (q *Querier) Impact(targets []string) []Entry {
+	entries := q.Rdeps(targets, 0)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Rank != entries[j].Rank {
+			return entries[i].Rank > entries[j].Rank
+		}
+		if entries[i].Depth != entries[j].Depth {
+			return entries[i].Depth < entries[j].Depth
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// Path returns the shortest import path from any node in from to any node in
+// to, including both ends, or nil when there is none. Ties are broken by
+// path name so the result is stable.
+func (q *Querier) Path(from, to []string) []string {
+	goal := make(map[string]bool, len(to))
+	for _, p := range to {
+		goal[p] = true
+	}
+
+	parent := make(map[string]string)
+	visited := make(map[string]bool)
+	frontier := append([]string(nil), from...)
+	sort.Strings(frontier)
+	for _, p := range frontier {
+		visited[p] = true
+	}
+
+	for len(frontier) > 0 {
+		var next []string
+		for _, node := range frontier {
+			if goal[node] {
+				return q.trace(parent, node)
+			}
+			dsts := append([]string(nil), q.g.Edges[node]...)
+			sort.Strings(dsts)
+			for _, dst := range dsts {
+				if !visited[dst] {
+					visited[dst] = true
+					parent[dst] = node
+					next = append(next, dst)
+				}
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
+
+func (q *Querier) trace(parent map[string]string, node string) []string {
+	path := []string{node}
+	for {
+		prev, ok := parent[node]
+		if !ok {
+			break
+		}
+		path = append([]string{prev}, path...)
+		node = prev
+	}
+	return path
+}
+
+// walk runs a breadth-first search from the targets along edges. Targets
+// themselves are not reported. Entries are ordered by depth, then path.
+func (q *Querier) walk(targets []string, depth int, edges map[string][]string) []Entry {
+	visited := make(map[string]bool)
+	for _, t := range targets {
+		visited[t] = true
+	}
+
+	var entries []Entry
+	frontier := targets
+	for level := 1; len(frontier) > 0 && (depth <= 0 || level <= depth); level++ {
+		var next []string
+		for _, node := range frontier {
+			for _, dst := range edges[node] {
+				if visited[dst] {
+					continue
+				}
+				visited[dst] = true
+				next = append(next, dst)
+			}
+		}
+		sort.Strings(next)
+		for _, p := range next {
+			entries = append(entries, q.entry(p, level))
+		}
+		frontier = next
+	}
+	return entries
+}
+
+func (q *Querier) entry(p string, depth int) Entry {
+	return Entry{
+		Path:       p,
+		Depth:      depth,
+		Rank:       q.Ranks[p],
+		Importance: q.Importance[p],
+	}
+}
@@ -0,0 +1,156 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// cmd/main.go -> pkg/a/a.go -> pkg/b/b.go -> pkg/c/c.go
+// cmd/main.go -> pkg/c/c.go
+// tools/gen.go -> pkg/b/b.go
+func testQuerier() *Querier {
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{},
+		Edges: map[string][]string{
+			"cmd/main.go":  {"pkg/a/a.go", "pkg/c/c.go"},
+			"pkg/a/a.go":   {"pkg/b/b.go"},
+			"pkg/b/b.go":   {"pkg/c/c.go"},
+			"tools/gen.go": {"pkg/b/b.go"},
+		},
+	}
+	for _, p := range []string{"cmd/main.go", "pkg/a/a.go", "pkg/b/b.go", "pkg/c/c.go", "tools/gen.go"} {
+		g.Nodes[p] = &graph.Node{Path: p}
+	}
+	q := New(g)
+	q.Ranks = map[string]float64{"pkg/c/c.go": 1, "pkg/b/b.go": 0.6, "pkg/a/a.go": 0.3, "tools/gen.go": 0.3}
+	return q
+}
+
+func paths(entries []Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Path)
+	}
+	return out
+}
+
+func TestResolve(t *testing.T) {
+	q := testQuerier()
+	if got, _ := q.Resolve("./pkg/a/a.go"); !reflect.DeepEqual(got, []string{"pkg/a/a.go"}) {
+		t.Errorf("unexpected file resolution: %v", got)
+	}
+	if got, _ := q.Resolve("pkg/"); len(got) != 3 {
+		t.Errorf("expected 3 files under pkg/, got %v", got)
+	}
+	if _, err := q.Resolve("missing"); err == nil {
+		t.Error("expected error for unknown path")
+	}
+}
+
+func TestDepsAndRdeps(t *testing.T) {
+	q := testQuerier()
+
+	deps := q.Deps([]string{"cmd/main.go"}, 0)
+	if !reflect.DeepEqual(paths(deps), []string{"pkg/a/a.go", "pkg/c/c.go", "pkg/b/b.go"}) {
+		t.Errorf("unexpected deps: %+v", deps)
+	}
+	if deps[1].Depth != 1 || deps[2].Depth != 2 {
+		t.Errorf("unexpected depths: %+v", deps)
+	}
+
+	if got := paths(q.Deps([]string{"cmd/main.go"}, 1)); !reflect.DeepEqual(got, []string{"pkg/a/a.go", "pkg/c/c.go"}) {
+		t.Errorf("depth limit not applied: %v", got)
+	}
+
+	if got := paths(q.Rdeps([]string{"pkg/b/b.go"}, 0)); !reflect.DeepEqual(got, []string{"pkg/a/a.go", "tools/gen.go", "cmd/main.go"}) {
+		t.Errorf("unexpected rdeps: %v", got)
+	}
+}
+
+func TestImpact(t *testing.T) {
+	q := testQuerier()
+	got := paths(q.Impact([]string{"pkg/c/c.go"}))
+	want := []string{"pkg/b/b.go", "pkg/a/a.go", "tools/gen.go", "cmd/main.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected impact %v, got %v", want, got)
+	}
+}
+
+func TestPath(t *testing.T) {
+	q := testQuerier()
+	if got := q.Path([]string{"tools/gen.go"}, []string{"pkg/c/c.go"}); !reflect.DeepEqual(got, []string{"tools/gen.go", "pkg/b/b.go", "pkg/c/c.go"}) {
+		t.Errorf("unexpected path: %v", got)
+	}
+	if got := q.Path([]string{"cmd/main.go"}, []string{"pkg/c/c.go"}); len(got) != 2 {
+		t.Errorf("expected the direct edge, got %v", got)
+	}
+	if got := q.Path([]string{"pkg/c/c.go"}, []string{"cmd/main.go"}); got != nil {
+		t.Errorf("expected no path, got %v", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	q := testQuerier()
+	r := &Result{Command: "rdeps", Targets: []string{"pkg/b/b.go"}, Entries: q.Rdeps([]string{"pkg/b/b.go"}, 0)}
+
+	var text bytes.Buffer
+	if err := Render(&text, r, "text", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "  1  tools/gen.go") || !strings.Contains(text.String(), "  2  cmd/main.go") {
+		t.Errorf("unexpected text output:\n%s", text.String())
+	}
+
+	var xmlOut bytes.Buffer
+	if err := Render(&xmlOut, r, "xml", 30); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(xmlOut.String(), `<query command="rdeps" truncated="`) {
+		t.Errorf("expected truncated XML output:\n%s", xmlOut.String())
+	}
+	if strings.Contains(xmlOut.String(), "cmd/main.go") {
+		t.Errorf("budget should drop the last entries:\n%s", xmlOut.String())
+	}
+
+	var jsonOut bytes.Buffer
+	p := &Result{Command: "path", Targets: []string{"a", "b"}, Path: []string{"a", "b"}}
+	if err := Render(&jsonOut, p, "json", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(jsonOut.String(), `"path": [`) {
+		t.Errorf("unexpected JSON output:\n%s", jsonOut.String())
+	}
+}
+
+func TestRenderFitsBudget(t *testing.T) {
+	r := &Result{Command: "rdeps", Targets: []string{"pkg/b/b.go"}}
+	for i := 0; i < 40; i++ {
+		r.Entries = append(r.Entries, Entry{
+			Path:       fmt.Sprintf("internal/very/deeply/nested/package%02d/implementation_file.go", i),
+			Depth:      1,
+			Rank:       0.123456,
+			Importance: "medium",
+		})
+	}
+
+	for _, format := range []string{"text", "json", "xml"} {
+		for _, budget := range []int{120, 300, 600} {
+			var out bytes.Buffer
+			if err := Render(&out, r, format, budget); err != nil {
+				t.Fatal(err)
+			}
+			if got := output.CountTokens(out.String()); got > budget {
+				t.Errorf("%s at %d tokens: rendered %d tokens", format, budget, got)
+			}
+			if !strings.Contains(out.String(), "package00") {
+				t.Errorf("%s at %d tokens: expected the first entry to fit:\n%s", format, budget, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package query
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+)
+
+// Result is the answer to a query, ready for rendering.
+type Result struct {
+	XMLName struct{} `json:"-" xml:"query"`
+	Command string   `json:"command" xml:"command,attr"`
+	Targets []string `json:"targets" xml:"target"`
+	// Depth is the depth limit of deps and rdeps (0 for none).
+	Depth   int     `json:"depth,omitempty" xml:"depth,attr,omitempty"`
+	Entries []Entry `json:"files,omitempty" xml:"file,omitempty"`
+	// Path holds the steps of a path query, from source to destination.
+	Path []string `json:"path,omitempty" xml:"step,omitempty"`
+	// Truncated counts the entries dropped to fit the token budget.
+	Truncated int `json:"truncated,omitempty" xml:"truncated,attr,omitempty"`
+}
+
+// Render writes the result as text, json or xml. When maxTokens is positive,
+// trailing entries are dropped until the rendered result fits and the number
+// dropped is reported.
+func Render(w io.Writer, r *Result, format string, maxTokens int) error {
+	out, err := fit(r, format, maxTokens)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// encode renders the result in the given format.
+func encode(r *Result, format string) (string, error) {
+	switch format {
+	case "text":
+		return renderText(r), nil
+	case "json":
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out) + "\n", nil
+	case "xml":
+		out, err := xml.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return xml.Header + string(out) + "\n", nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// fit renders r with as many leading entries as fit in maxTokens. The
+// rendered output is measured, and the number of entries bisected, as the
+// cost of an entry depends on the format. With no entries at all the result
+// is returned even if it does not fit.
+func fit(r *Result, format string, maxTokens int) (string, error) {
+	out, err := encode(r, format)
+	if err != nil || maxTokens <= 0 || output.CountTokens(out) <= maxTokens {
+		return out, err
+	}
+
+	first := func(n int) (string, error) {
+		fitted := *r
+		fitted.Entries = r.Entries[:n]
+		fitted.Truncated = r.Truncated + len(r.Entries) - n
+		return encode(&fitted, format)
+	}
+
+	// lo entries always fit, or are none; hi never fit.
+	lo, hi := 0, len(r.Entries)
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		out, err := first(mid)
+		if err != nil {
+			return "", err
+		}
+		if output.CountTokens(out) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return first(lo)
+}
+
+func renderText(r *Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", r.Command, strings.Join(r.Targets, " "))
+	if r.Depth > 0 {
+		fmt.Fprintf(&b, " (depth %d)", r.Depth)
+	}
+	b.WriteString("\n")
+
+	if r.Command == "path" {
+		if len(r.Path) == 0 {
+			b.WriteString("  no import path found\n")
+		}
+		for i, step := range r.Path {
+			if i == 0 {
+				fmt.Fprintf(&b, "  %s\n", step)
+			} else {
+				fmt.Fprintf(&b, "  -> %s\n", step)
+			}
+		}
+		return b.String()
+	}
+
+	if len(r.Entries) == 0 && r.Truncated == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for _, e := range r.Entries {
+		fmt.Fprintf(&b, "  %d  %s", e.Depth, e.Path)
+		if e.Importance != "" {
+			fmt.Fprintf(&b, "  [%s %.2f]", e.Importance, e.Rank)
+		}
+		b.WriteString("\n")
+	}
+	if r.Truncated > 0 {
+		fmt.Fprintf(&b, "  ... %d more (token limit)\n", r.Truncated)
+	}
+	return b.String()
+}
@@ -1,7 +1,11 @@
 /*
 Package ranking implements algorithms to rank files based on their importance in the dependency graph.
 
-It currently uses In-Degree Centrality to assign a score to each file, which is then mapped
-to an importance level (high, medium, low).
+Score runs PageRank over the weighted import graph, optionally blended with in-degree,
+out-degree and definition counts, and normalizes the result so the top file scores 1.0.
+With a personalization vector PageRank is seeded from a set of files, ranking the
+rest of the repository by its relevance to them rather than globally.
+Rank keeps the simpler in-degree centrality. Scores are mapped to importance levels
+(high, medium, low) using configurable thresholds.
 */
 package ranking
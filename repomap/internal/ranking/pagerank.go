@@ -0,0 +1,303 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+)
+
+// Options configures PageRank and the blend of signals that make up a score.
+type Options struct {
+	// Damping is the probability of following an import rather than jumping
+	// to a random file.
+	Damping float64
+	// MaxIterations caps the number of power iterations.
+	MaxIterations int
+	// Tolerance stops iterating once the L1 change between iterations drops below it.
+	Tolerance float64
+	// Weights blends PageRank with simpler signals.
+	Weights Weights
+	// Personalization biases random jumps towards the given nodes, in
+	// proportion to their values. Empty means jumps are uniform.
+	Personalization map[string]float64
+}
+
+// Weights are the relative contributions of each signal to a blended score.
+// Each signal is normalized to 0-1 before blending.
+type Weights struct {
+	PageRank    float64
+	InDegree    float64
+	OutDegree   float64
+	Definitions float64
+	// Churn, Recency and Authors weigh signals read from version control
+	// history; see Signals.
+	Churn   float64
+	Recency float64
+	Authors float64
+}
+
+// Signals are per-node values blended into a score next to the graph
+// structure. Nil maps and missing nodes count as zero.
+type Signals struct {
+	// Definitions is the number of definitions in each node.
+	Definitions map[string]int
+	// Churn is the number of commits that changed each node.
+	Churn map[string]int
+	// Recency is 1 for a node changed just now, decaying towards 0 with age.
+	Recency map[string]float64
+	// Authors is the number of distinct authors of each node.
+	Authors map[string]int
+}
+
+// UsesHistory reports whether any history signal has a positive weight.
+func (w Weights) UsesHistory() bool {
+	return w.Churn > 0 || w.Recency > 0 || w.Authors > 0
+}
+
+// DefaultOptions returns the options used by Score. A small definition-count
+// weight breaks ties between files that receive the same PageRank, such as
+// the files of a Go package imported as a whole.
+func DefaultOptions() Options {
+	return Options{
+		Damping:       0.85,
+		MaxIterations: 100,
+		Tolerance:     1e-6,
+		Weights:       Weights{PageRank: 1, Definitions: 0.1},
+	}
+}
+
+// PageRank computes the PageRank of every node, following weighted edges.
+// Random jumps and mass from files without imports land on the
+// personalization nodes when opts.Personalization is set, and are spread
+// evenly over all files otherwise. The scores sum to 1.
+func PageRank(g *graph.Graph, opts Options) map[string]float64 {
+	nodes := sortedNodes(g)
+	n := len(nodes)
+	scores := make(map[string]float64, n)
+	if n == 0 {
+		return scores
+	}
+
+	// Iterate over indexes rather than paths: large graphs have millions of
+	// edges, and each iteration visits all of them.
+	index := make(map[string]int, n)
+	for i, p := range nodes {
+		index[p] = i
+	}
+	type edge struct {
+		dst    int
+		weight float64
+	}
+	out := make([][]edge, n)
+	outWeight := make([]float64, n)
+	for i, src := range nodes {
+		for _, dst := range g.Edges[src] {
+			if j, ok := index[dst]; ok {
+				w := float64(g.Weight(src, dst))
+				out[i] = append(out[i], edge{j, w})
+				outWeight[i] += w
+			}
+		}
+	}
+
+	teleport := teleportVector(nodes, opts.Personalization)
+	rank := make([]float64, n)
+	next := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+
+	for iter := 0; iter < opts.MaxIterations; iter++ {
+		dangling := 0.0
+		for i := range rank {
+			if outWeight[i] == 0 {
+				dangling += rank[i]
+			}
+		}
+
+		jump := 1 - opts.Damping + opts.Damping*dangling
+		for i := range next {
+			next[i] = jump * teleport[i]
+		}
+		for i, edges := range out {
+			if outWeight[i] == 0 {
+				continue
+			}
+			share := opts.Damping * rank[i] / outWeight[i]
+			for _, e := range edges {
+				next[e.dst] += share * e.weight
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < opts.Tolerance {
+			break
+		}
+	}
+	for i, p := range nodes {
+		scores[p] = rank[i]
+	}
+	return scores
+}
+
+// teleportVector returns the probability of a random jump landing on each
+// node, in the order of nodes: proportional to personalization, or uniform
+// when it selects no node.
+func teleportVector(nodes []string, personalization map[string]float64) []float64 {
+	total := 0.0
+	for _, p := range nodes {
+		if v := personalization[p]; v > 0 {
+			total += v
+		}
+	}
+
+	teleport := make([]float64, len(nodes))
+	for i, p := range nodes {
+		switch {
+		case total == 0:
+			teleport[i] = 1 / float64(len(nodes))
+		case personalization[p] > 0:
+			teleport[i] = personalization[p] / total
+		}
+	}
+	return teleport
+}
+
+// Score blends PageRank, in-degree, out-degree and the per-node signals
+// according to opts.Weights and returns scores normalized so the top node
+// has 1.0.
+func Score(g *graph.Graph, values Signals, opts Options) map[string]float64 {
+	w := opts.Weights
+	total := w.PageRank + w.InDegree + w.OutDegree + w.Definitions + w.Churn + w.Recency + w.Authors
+	if total <= 0 {
+		return Rank(g)
+	}
+
+	signals := []struct {
+		weight float64
+		values map[string]float64
+	}{
+		{w.PageRank, nil},
+		{w.InDegree, make(map[string]float64)},
+		{w.OutDegree, make(map[string]float64)},
+		{w.Definitions, make(map[string]float64)},
+		{w.Churn, make(map[string]float64)},
+		{w.Recency, make(map[string]float64)},
+		{w.Authors, make(map[string]float64)},
+	}
+	if w.PageRank > 0 {
+		signals[0].values = PageRank(g, opts)
+	}
+	for p, node := range g.Nodes {
+		signals[1].values[p] = float64(node.InDegree)
+		signals[2].values[p] = float64(len(g.Edges[p]))
+		signals[3].values[p] = float64(values.Definitions[p])
+		signals[4].values[p] = float64(values.Churn[p])
+		signals[5].values[p] = values.Recency[p]
+		signals[6].values[p] = float64(values.Authors[p])
+	}
+
+	scores := make(map[string]float64, len(g.Nodes))
+	for p := range g.Nodes {
+		scores[p] = 0
+	}
+	for _, s := range signals {
+		if s.weight <= 0 {
+			continue
+		}
+		for p, v := range normalize(s.values) {
+			scores[p] += s.weight / total * v
+		}
+	}
+	return normalize(scores)
+}
+
+// normalize scales values so the largest is 1. All-zero input stays zero.
+func normalize(values map[string]float64) map[string]float64 {
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	out := make(map[string]float64, len(values))
+	for p, v := range values {
+		if max > 0 {
+			out[p] = v / max
+		} else {
+			out[p] = 0
+		}
+	}
+	return out
+}
+
+func sortedNodes(g *graph.Graph) []string {
+	nodes := make([]string, 0, len(g.Nodes))
+	for p := range g.Nodes {
+		nodes = append(nodes, p)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// ParseWeights parses a weight list such as "pagerank=1,definitions=0.1".
+// Signals that are not listed get weight 0.
+func ParseWeights(s string) (Weights, error) {
+	var w Weights
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return w, fmt.Errorf("invalid weight %q (expected name=value)", part)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || v < 0 {
+			return w, fmt.Errorf("invalid weight %q: must be a non-negative number", part)
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "pagerank":
+			w.PageRank = v
+		case "indegree", "in-degree":
+			w.InDegree = v
+		case "outdegree", "out-degree":
+			w.OutDegree = v
+		case "definitions":
+			w.Definitions = v
+		case "churn":
+			w.Churn = v
+		case "recency":
+			w.Recency = v
+		case "authors":
+			w.Authors = v
+		default:
+			return w, fmt.Errorf("unknown ranking signal %q (expected pagerank, indegree, outdegree, definitions, churn, recency or authors)", name)
+		}
+	}
+	return w, nil
+}
+
+// String formats the weights in the form accepted by ParseWeights. History
+// signals are only listed when weighted.
+func (w Weights) String() string {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	s := fmt.Sprintf("pagerank=%s,indegree=%s,outdegree=%s,definitions=%s",
+		format(w.PageRank), format(w.InDegree), format(w.OutDegree), format(w.Definitions))
+	for _, h := range []struct {
+		name  string
+		value float64
+	}{{"churn", w.Churn}, {"recency", w.Recency}, {"authors", w.Authors}} {
+		if h.value > 0 {
+			s += "," + h.name + "=" + format(h.value)
+		}
+	}
+	return s
+}
@@ -0,0 +1,197 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/graph"
+)
+
+// leafGraph has ten leaf files that each import util.go and one of three
+// services; the services are built on core.go. util.go has the highest
+// in-degree, but core.go carries the system.
+func leafGraph() *graph.Graph {
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{
+			"util.go": {Path: "util.go", InDegree: 10},
+			"core.go": {Path: "core.go", InDegree: 3},
+		},
+		Edges: map[string][]string{},
+	}
+	for i := 0; i < 3; i++ {
+		svc := fmt.Sprintf("svc%d.go", i)
+		g.Nodes[svc] = &graph.Node{Path: svc}
+		g.Edges[svc] = []string{"core.go"}
+	}
+	for i := 0; i < 10; i++ {
+		leaf := fmt.Sprintf("leaf%d.go", i)
+		svc := fmt.Sprintf("svc%d.go", i%3)
+		g.Nodes[leaf] = &graph.Node{Path: leaf}
+		g.Nodes[svc].InDegree++
+		g.Edges[leaf] = []string{"util.go", svc}
+	}
+	return g
+}
+
+func TestPageRank(t *testing.T) {
+	g := leafGraph()
+	scores := PageRank(g, DefaultOptions())
+
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("expected scores to sum to 1, got %f", sum)
+	}
+	if scores["core.go"] <= scores["util.go"] {
+		t.Errorf("core.go (%f) should outrank util.go (%f)", scores["core.go"], scores["util.go"])
+	}
+	if scores["svc0.go"] <= scores["leaf0.go"] {
+		t.Errorf("services should outrank the leaves")
+	}
+
+	// In-degree ranks the other way round.
+	if in := Rank(g); in["core.go"] >= in["util.go"] {
+		t.Errorf("expected in-degree to favour util.go, got %v", in)
+	}
+}
+
+func TestPageRank_IterationCap(t *testing.T) {
+	g := leafGraph()
+	opts := DefaultOptions()
+	opts.MaxIterations = 1
+	once := PageRank(g, opts)
+	converged := PageRank(g, DefaultOptions())
+	if math.Abs(once["core.go"]-converged["core.go"]) < 1e-6 {
+		t.Error("expected a single iteration to differ from the converged result")
+	}
+}
+
+func TestPageRank_Weighted(t *testing.T) {
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{
+			"a": {Path: "a"}, "b": {Path: "b"}, "c": {Path: "c"},
+		},
+		Edges:   map[string][]string{"a": {"b", "c"}},
+		Weights: map[string]map[string]int{"a": {"b": 5, "c": 1}},
+	}
+	scores := PageRank(g, DefaultOptions())
+	if scores["b"] <= scores["c"] {
+		t.Errorf("heavier edge should carry more rank: b=%f c=%f", scores["b"], scores["c"])
+	}
+}
+
+func TestScore(t *testing.T) {
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{
+			"main.go":     {Path: "main.go"},
+			"pkg/a.go":    {Path: "pkg/a.go", InDegree: 1},
+			"pkg/b.go":    {Path: "pkg/b.go", InDegree: 1},
+			"pkg/util.go": {Path: "pkg/util.go"},
+		},
+		Edges: map[string][]string{"main.go": {"pkg/a.go", "pkg/b.go"}},
+	}
+	defs := Signals{Definitions: map[string]int{"pkg/a.go": 10, "pkg/b.go": 2}}
+
+	scores := Score(g, defs, DefaultOptions())
+	if scores["pkg/a.go"] != 1 {
+		t.Errorf("expected the top file to score 1, got %f", scores["pkg/a.go"])
+	}
+	if scores["pkg/a.go"] <= scores["pkg/b.go"] {
+		t.Error("definition count should break the tie between files of one package")
+	}
+
+	opts := DefaultOptions()
+	opts.Weights = Weights{OutDegree: 1}
+	if scores := Score(g, defs, opts); scores["main.go"] != 1 || scores["pkg/a.go"] != 0 {
+		t.Errorf("out-degree only: unexpected scores %v", scores)
+	}
+
+	// History signals lift a recently and frequently changed file.
+	history := Signals{
+		Definitions: defs.Definitions,
+		Churn:       map[string]int{"pkg/util.go": 12, "pkg/b.go": 1},
+		Recency:     map[string]float64{"pkg/util.go": 0.9, "pkg/a.go": 0.1},
+		Authors:     map[string]int{"pkg/util.go": 3, "pkg/a.go": 1},
+	}
+	opts = DefaultOptions()
+	opts.Weights.Churn, opts.Weights.Recency, opts.Weights.Authors = 1, 1, 1
+	if scores := Score(g, history, opts); scores["pkg/util.go"] != 1 {
+		t.Errorf("expected the most changed file on top, got %v", scores)
+	}
+	if scores := Score(g, history, DefaultOptions()); scores["pkg/util.go"] >= scores["pkg/b.go"] {
+		t.Errorf("history should not count unless weighted, got %v", scores)
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights("pagerank=0.7, indegree=0.2,definitions=0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != (Weights{PageRank: 0.7, InDegree: 0.2, Definitions: 0.1}) {
+		t.Errorf("unexpected weights: %+v", w)
+	}
+	if w.String() != "pagerank=0.7,indegree=0.2,outdegree=0,definitions=0.1" {
+		t.Errorf("unexpected String(): %s", w.String())
+	}
+	w, err = ParseWeights("pagerank=1,recency=0.5,churn=0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.UsesHistory() || w.String() != "pagerank=1,indegree=0,outdegree=0,definitions=0,churn=0.2,recency=0.5" {
+		t.Errorf("unexpected history weights: %s", w.String())
+	}
+	for _, bad := range []string{"pagerank", "pagerank=x", "centrality=1", "pagerank=-1"} {
+		if _, err := ParseWeights(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestAssignImportanceWith(t *testing.T) {
+	th, err := ParseThresholds("0.5, 0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := AssignImportanceWith(map[string]float64{"a": 0.6, "b": 0.1, "c": 0.05}, th)
+	if got["a"] != "high" || got["b"] != "medium" || got["c"] != "low" {
+		t.Errorf("unexpected importance: %v", got)
+	}
+	if _, err := ParseThresholds("0.1,0.5"); err == nil {
+		t.Error("expected error when medium exceeds high")
+	}
+}
+
+func TestPageRankPersonalization(t *testing.T) {
+	g := leafGraph()
+	opts := DefaultOptions()
+	opts.Personalization = map[string]float64{"leaf1.go": 1}
+	scores := PageRank(g, opts)
+
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("expected scores to sum to 1, got %f", sum)
+	}
+	// leaf1.go imports svc1.go, so svc1.go now outranks the other services.
+	if scores["svc1.go"] <= scores["svc0.go"] || scores["svc1.go"] <= scores["svc2.go"] {
+		t.Errorf("expected svc1.go to outrank the other services, got %v", scores)
+	}
+	if scores["leaf1.go"] <= scores["leaf0.go"] {
+		t.Errorf("expected the seed to outrank other leaves")
+	}
+	if scores["leaf0.go"] != 0 {
+		t.Errorf("expected files unreachable from the seed to score 0, got %f", scores["leaf0.go"])
+	}
+
+	// Seeds missing from the graph fall back to uniform jumps.
+	opts.Personalization = map[string]float64{"missing.go": 1}
+	if got, want := PageRank(g, opts)["leaf0.go"], PageRank(g, DefaultOptions())["leaf0.go"]; math.Abs(got-want) > 1e-9 {
+		t.Errorf("expected uniform ranking for unknown seeds, got %f want %f", got, want)
+	}
+}
@@ -1,10 +1,15 @@
 package ranking
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/spanexx/agents-cli/repomap/internal/graph"
 )
 
-// Rank calculates the importance score for each file in the graph based on in-degree centrality.
+// Rank calculates the importance score for each file in the graph based on
+// in-degree centrality, the simple alternative to the PageRank-based Score.
 // Returns a map of file paths to their normalized score (0.0 - 1.0).
 func Rank(g *graph.Graph) map[string]float64 {
 	scores := make(map[string]float64)
@@ -30,16 +35,49 @@ func Rank(g *graph.Graph) map[string]float64 {
 	return scores
 }
 
+// Thresholds are the score boundaries between importance levels.
+type Thresholds struct {
+	// High is the score above which a file is "high".
+	High float64
+	// Medium is the score at or above which a file is at least "medium".
+	Medium float64
+}
+
+// DefaultThresholds returns the thresholds used by AssignImportance.
+func DefaultThresholds() Thresholds {
+	return Thresholds{High: 0.7, Medium: 0.3}
+}
+
+// ParseThresholds parses "high,medium", e.g. "0.7,0.3".
+func ParseThresholds(s string) (Thresholds, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return Thresholds{}, fmt.Errorf("invalid thresholds %q (expected high,medium)", s)
+	}
+	high, errHigh := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	medium, errMedium := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errHigh != nil || errMedium != nil || medium > high {
+		return Thresholds{}, fmt.Errorf("invalid thresholds %q (expected numbers with high >= medium)", s)
+	}
+	return Thresholds{High: high, Medium: medium}, nil
+}
+
 // AssignImportance maps normalized scores to importance levels.
 // > 0.7: high
 // 0.3 - 0.7: medium
 // < 0.3: low
 func AssignImportance(scores map[string]float64) map[string]string {
+	return AssignImportanceWith(scores, DefaultThresholds())
+}
+
+// AssignImportanceWith maps normalized scores to importance levels using
+// the given thresholds.
+func AssignImportanceWith(scores map[string]float64, t Thresholds) map[string]string {
 	importance := make(map[string]string)
 	for path, score := range scores {
-		if score > 0.7 {
+		if score > t.High {
 			importance[path] = "high"
-		} else if score >= 0.3 {
+		} else if score >= t.Medium {
 			importance[path] = "medium"
 		} else {
 			importance[path] = "low"
@@ -0,0 +1,10 @@
+/*
+Package schema publishes versioned JSON Schemas for repository maps and plans.
+
+The schemas are generated from the Go types (output.RepoMap and
+planning.Plan) by Generate, written next to this file by go generate and
+embedded in the binary. Validate checks a JSON document against one of
+them and reports every violation with the path of the offending value,
+such as $.files[3].issues[0].severity.
+*/
+package schema
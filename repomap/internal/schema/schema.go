@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+	"github.com/spanexx/agents-cli/repomap/internal/planning"
+)
+
+//go:generate go run ./gen
+
+//go:embed *.schema.json
+var embedded embed.FS
+
+// Names lists the published schemas.
+var Names = []string{"repomap", "plan"}
+
+// roots maps each schema to the Go type it describes.
+var roots = map[string]reflect.Type{
+	"repomap": reflect.TypeOf(output.RepoMap{}),
+	"plan":    reflect.TypeOf(planning.Plan{}),
+}
+
+var titles = map[string]string{
+	"repomap": "Repository map",
+	"plan":    "Change plan",
+}
+
+// draft is the JSON Schema dialect of the generated schemas.
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema, limited to the keywords Generate emits.
+type Schema struct {
+	Draft       string             `json:"$schema,omitempty"`
+	ID          string             `json:"$id,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Type        interface{}        `json:"type,omitempty"`
+	Const       interface{}        `json:"const,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// AdditionalProperties is false for structs and a schema for maps.
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// FileName returns the name of the file holding the named schema.
+func FileName(name string) string {
+	return name + ".schema.json"
+}
+
+// Load returns the embedded schema of the given name.
+func Load(name string) ([]byte, error) {
+	data, err := embedded.ReadFile(FileName(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema: %s (expected one of %s)", name, strings.Join(Names, ", "))
+	}
+	return data, nil
+}
+
+// Generate builds the named schema from its Go type. Every struct becomes
+// a definition in $defs; fields follow their json tags, and fields without
+// omitempty are required. Slices, maps and pointers that are not omitted
+// when empty may be null. The map schema pins schemaVersion to
+// output.SchemaVersion.
+func Generate(name string) ([]byte, error) {
+	root, ok := roots[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema: %s (expected one of %s)", name, strings.Join(Names, ", "))
+	}
+
+	g := &generator{defs: make(map[string]*Schema)}
+	s := g.schemaFor(root)
+	s.Draft = draft
+	s.ID = fmt.Sprintf("%s-%s.schema.json", name, output.SchemaVersion)
+	s.Title = fmt.Sprintf("%s, schema version %s", titles[name], output.SchemaVersion)
+	s.Defs = g.defs
+	if name == "repomap" {
+		g.defs[root.Name()].Properties["schemaVersion"].Const = output.SchemaVersion
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+// schemaFor returns the schema of a value of type t, adding the structs it
+// uses to the definitions.
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			def := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+			g.defs[t.Name()] = def
+			g.addFields(def, t)
+		}
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	// Interfaces and other kinds accept any value.
+	return &Schema{}
+}
+
+func (g *generator) addFields(def *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		omitEmpty := strings.Contains(opts, "omitempty")
+
+		s := g.schemaFor(field.Type)
+		if !omitEmpty {
+			def.Required = append(def.Required, name)
+			if k := field.Type.Kind(); k == reflect.Slice || k == reflect.Map || k == reflect.Ptr {
+				s = nullable(s)
+			}
+		}
+		def.Properties[name] = s
+	}
+}
+
+// nullable widens s to also accept null.
+func nullable(s *Schema) *Schema {
+	if typ, ok := s.Type.(string); ok {
+		s.Type = []string{typ, "null"}
+		return s
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spanexx/agents-cli/repomap/internal/output"
+	"github.com/spanexx/agents-cli/repomap/internal/planning"
+)
+
+func TestEmbeddedSchemasUpToDate(t *testing.T) {
+	for _, name := range Names {
+		want, err := Generate(name)
+		if err != nil {
+			t.Fatalf("Generate(%s) failed: %v", name, err)
+		}
+		got, err := Load(name)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run go generate ./internal/schema", FileName(name))
+		}
+	}
+	if _, err := Load("missing"); err == nil {
+		t.Error("expected error for an unknown schema")
+	}
+}
+
+func TestValidate_RenderedMap(t *testing.T) {
+	m := &output.RepoMap{
+		Meta: &output.Metadata{Version: "dev", Root: ".", Tokenizer: "cl100k_base", Options: []output.Option{{Name: "max-tokens", Value: "100"}}},
+		Files: []*output.FileNode{
+			{
+				Path: "main.go", Language: "go", Importance: "high", Rank: 1,
+				Definitions: []string{"func main()"}, TokenCount: 10,
+				Issues:   []output.Issue{{Type: "cycle", Description: "import cycle", Severity: "high", Cycle: []string{"a", "b"}}},
+				Comments: []output.Comment{{User: "ann", Text: "check this"}},
+			},
+			{Path: "util.go", Language: "go", Importance: "low", Rank: 0.25},
+		},
+		Dependencies: []*output.Dependency{{Name: "x", Ecosystem: "go", Files: []string{"main.go"}}},
+	}
+	r, err := output.Render(m, "json", output.Options{})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	violations, err := Validate("repomap", r.Data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("rendered map should be valid, got %v\n%s", violations, r.Data)
+	}
+	if got := Detect(r.Data); got != "repomap" {
+		t.Errorf("expected a map to be detected as repomap, got %s", got)
+	}
+}
+
+func TestValidate_Violations(t *testing.T) {
+	doc := `{
+		"schemaVersion": "2",
+		"files": [
+			{"path": "a.go", "language": "go", "importance": "high", "rank": 1, "definitions": null, "token_count": 1},
+			{"path": "b.go", "language": "go", "importance": "high", "rank": "1", "definitions": [], "token_count": 1.5,
+			 "issues": [{"type": "cycle", "description": "d", "severity": 3}], "extra": true}
+		],
+		"metadata": {"version": "dev", "root": "."}
+	}`
+	violations, err := Validate("repomap", []byte(doc))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	want := []string{
+		`$.files[1].extra: unknown property`,
+		`$.files[1].issues[0].severity: expected string, got integer`,
+		`$.files[1].rank: expected number, got string`,
+		`$.files[1].token_count: expected integer, got number`,
+		`$.metadata: missing required property "tokenizer"`,
+		`$.schemaVersion: must be "1", got "2"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Validate("repomap", []byte(`{"files": [`)); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+	if _, err := Validate("repomap", []byte(`{} {}`)); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestValidate_Plan(t *testing.T) {
+	plan := planning.Plan{
+		Version: "1",
+		Intent:  "split the server",
+		Changes: []planning.FileChange{{Path: "server.go", Status: "modified", Issues: []output.Issue{{Type: "todo", Description: "d", Severity: "low"}}}},
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Detect(data); got != "plan" {
+		t.Fatalf("expected a plan to be detected, got %s", got)
+	}
+	violations, err := Validate("plan", data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("plan should be valid, got %v", violations)
+	}
+
+	violations, _ = Validate("plan", []byte(`{"version": "1", "intent": "x", "changes": [{"path": "a.go"}]}`))
+	if len(violations) != 1 || violations[0].String() != `$.changes[0]: missing required property "status"` {
+		t.Errorf("unexpected violations: %v", violations)
+	}
+}
@@ -0,0 +1,249 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Violation is a value of a document that does not match its schema.
+type Violation struct {
+	// Path locates the value, as in $.files[3].issues[0].severity.
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// Detect returns the name of the schema a document claims to follow: plans
+// list their changes, maps do not.
+func Detect(doc []byte) string {
+	var keys map[string]json.RawMessage
+	if json.Unmarshal(doc, &keys) == nil {
+		if _, ok := keys["changes"]; ok {
+			return "plan"
+		}
+	}
+	return "repomap"
+}
+
+// Validate checks the JSON document doc against the named schema and
+// returns its violations, ordered by path. The error reports documents
+// that are not JSON at all.
+func Validate(name string, doc []byte) ([]Violation, error) {
+	data, err := Load(name)
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid %s schema: %w", name, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the document")
+	}
+
+	v := &validator{defs: asObject(root["$defs"])}
+	v.check(root, value, "$")
+	sort.SliceStable(v.violations, func(i, j int) bool { return v.violations[i].Path < v.violations[j].Path })
+	return v.violations, nil
+}
+
+type validator struct {
+	defs       map[string]interface{}
+	violations []Violation
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// check validates value against schema s, reporting violations at path.
+func (v *validator) check(s map[string]interface{}, value interface{}, path string) {
+	if ref, ok := s["$ref"].(string); ok {
+		def := asObject(v.defs[strings.TrimPrefix(ref, "#/$defs/")])
+		if def == nil {
+			v.fail(path, "schema references unknown definition %s", ref)
+			return
+		}
+		v.check(def, value, path)
+		return
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		v.checkAnyOf(anyOf, value, path)
+		return
+	}
+	if c, ok := s["const"]; ok && !sameValue(c, value) {
+		v.fail(path, "must be %s, got %s", describe(c), describe(value))
+		return
+	}
+	if typ, ok := s["type"]; ok && !matchesType(typ, value) {
+		v.fail(path, "expected %s, got %s", typeNames(typ), jsonType(value))
+		return
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.checkObject(s, val, path)
+	case []interface{}:
+		if items := asObject(s["items"]); items != nil {
+			for i, item := range val {
+				v.check(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *validator) checkObject(s map[string]interface{}, obj map[string]interface{}, path string) {
+	required, _ := s["required"].([]interface{})
+	for _, name := range required {
+		if _, ok := obj[fmt.Sprint(name)]; !ok {
+			v.fail(path, "missing required property %q", name)
+		}
+	}
+	props := asObject(s["properties"])
+	for _, key := range sortedKeys(obj) {
+		if prop := asObject(props[key]); prop != nil {
+			v.check(prop, obj[key], propertyPath(path, key))
+			continue
+		}
+		switch extra := s["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.fail(propertyPath(path, key), "unknown property")
+			}
+		case map[string]interface{}:
+			v.check(extra, obj[key], propertyPath(path, key))
+		}
+	}
+}
+
+// checkAnyOf accepts value if one alternative matches. Otherwise it
+// reports the violations of the first alternative that failed below path
+// rather than at it, since that one had the right type, or those of the
+// first alternative.
+func (v *validator) checkAnyOf(anyOf []interface{}, value interface{}, path string) {
+	var first, nested []Violation
+	for _, alt := range anyOf {
+		sub := &validator{defs: v.defs}
+		sub.check(asObject(alt), value, path)
+		if len(sub.violations) == 0 {
+			return
+		}
+		if first == nil {
+			first = sub.violations
+		}
+		if nested == nil && sub.violations[0].Path != path {
+			nested = sub.violations
+		}
+	}
+	if nested == nil {
+		nested = first
+	}
+	v.violations = append(v.violations, nested...)
+}
+
+func asObject(value interface{}) map[string]interface{} {
+	obj, _ := value.(map[string]interface{})
+	return obj
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// propertyPath appends key to path, quoting keys that are not identifiers.
+func propertyPath(path, key string) string {
+	for i, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Sprintf("%s[%q]", path, key)
+		}
+	}
+	if key == "" {
+		return path + `[""]`
+	}
+	return path + "." + key
+}
+
+func matchesType(typ interface{}, value interface{}) bool {
+	if types, ok := typ.([]interface{}); ok {
+		for _, t := range types {
+			if matchesType(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	actual := jsonType(value)
+	if typ == "number" && actual == "integer" {
+		return true
+	}
+	return typ == actual
+}
+
+func typeNames(typ interface{}) string {
+	if types, ok := typ.([]interface{}); ok {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = fmt.Sprint(t)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(typ)
+}
+
+// jsonType returns the JSON Schema type of a decoded value.
+func jsonType(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// sameValue compares a scalar of the schema with one of the document,
+// whose numbers are json.Number.
+func sameValue(want, got interface{}) bool {
+	if _, ok := want.(string); ok {
+		return want == got
+	}
+	_, isString := got.(string)
+	return !isString && fmt.Sprint(want) == fmt.Sprint(got)
+}
+
+func describe(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return jsonType(value)
+}
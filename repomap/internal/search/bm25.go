@@ -0,0 +1,162 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: k1 controls term frequency saturation, b the length
+// normalization.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Hit is a document matching a query.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+type document struct {
+	id     string
+	terms  map[string]int
+	length int
+}
+
+// Index is a BM25 index over documents.
+type Index struct {
+	docs     []document
+	docFreq  map[string]int
+	totalLen int
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{docFreq: make(map[string]int)}
+}
+
+// Add indexes a document made of the given text fields.
+func (ix *Index) Add(id string, fields ...string) {
+	doc := document{id: id, terms: make(map[string]int)}
+	for _, field := range fields {
+		for _, term := range Tokenize(field) {
+			if doc.terms[term] == 0 {
+				ix.docFreq[term]++
+			}
+			doc.terms[term]++
+			doc.length++
+		}
+	}
+	ix.docs = append(ix.docs, doc)
+	ix.totalLen += doc.length
+}
+
+// Len returns the number of indexed documents.
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// Search scores every document against the query and returns those
+// matching at least one term, best first. Ties are broken by ID.
+func (ix *Index) Search(query string) []Hit {
+	if len(ix.docs) == 0 {
+		return nil
+	}
+	n := float64(len(ix.docs))
+	avgLen := float64(ix.totalLen) / n
+
+	terms := unique(Tokenize(query))
+	var hits []Hit
+	for _, doc := range ix.docs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(doc.terms[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(ix.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - b
+			if avgLen > 0 {
+				norm += b * float64(doc.length) / avgLen
+			}
+			score += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+		if score > 0 {
+			hits = append(hits, Hit{ID: doc.id, Score: score})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits
+}
+
+// Tokenize splits text into lower-case terms. Words are split at
+// punctuation and compound identifiers are indexed both whole and by
+// their parts: "PageRank" yields "pagerank", "page" and "rank".
+// Single-character terms are dropped.
+func Tokenize(text string) []string {
+	var terms []string
+	add := func(term string) {
+		if len(term) > 1 {
+			terms = append(terms, strings.ToLower(term))
+		}
+	}
+
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			add(strings.ReplaceAll(word, "_", ""))
+		}
+		for _, part := range parts {
+			add(part)
+		}
+	}
+	return terms
+}
+
+// splitIdentifier splits an identifier at underscores and case changes,
+// keeping acronyms together: "parseHTTPRequest" yields "parse", "HTTP"
+// and "Request".
+func splitIdentifier(word string) []string {
+	var parts []string
+	for _, segment := range strings.Split(word, "_") {
+		runes := []rune(segment)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			lowerToUpper := unicode.IsLower(prev) && unicode.IsUpper(cur)
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	var out []string
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"internal/ranking/pagerank.go", []string{"internal", "ranking", "pagerank", "go"}},
+		{"func PageRank(g *graph.Graph)", []string{"func", "pagerank", "page", "rank", "graph", "graph"}},
+		{"parseHTTPRequest", []string{"parsehttprequest", "parse", "http", "request"}},
+		{"load_rank_config", []string{"loadrankconfig", "load", "rank", "config"}},
+		{"a b", nil},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := NewIndex()
+	ix.Add("internal/ranking/pagerank.go", "internal/ranking/pagerank.go", "func PageRank(g *graph.Graph, opts Options)", "Ranking")
+	ix.Add("internal/ranking/ranker.go", "internal/ranking/ranker.go", "func Rank(g *graph.Graph)", "Ranking")
+	ix.Add("internal/output/xml.go", "internal/output/xml.go", "func RenderXML(nodes []*FileNode)", "Output rendering")
+	ix.Add("cmd/repomap/main.go", "cmd/repomap/main.go", "func main()", "Entry point")
+
+	hits := ix.Search("page rank scoring")
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %v", hits)
+	}
+	if hits[0].ID != "internal/ranking/pagerank.go" {
+		t.Errorf("expected pagerank.go first, got %v", hits)
+	}
+
+	if hits := ix.Search("xml"); len(hits) != 1 || hits[0].ID != "internal/output/xml.go" {
+		t.Errorf("expected xml.go, got %v", hits)
+	}
+	if hits := ix.Search("nothing matches"); len(hits) != 0 {
+		t.Errorf("expected no hits, got %v", hits)
+	}
+
+	// Rare terms weigh more than common ones.
+	hits = ix.Search("graph entry")
+	if hits[0].ID != "cmd/repomap/main.go" {
+		t.Errorf("expected the rare term to win, got %v", hits)
+	}
+}
@@ -0,0 +1,8 @@
+/*
+Package search implements a small BM25 lexical index over repository files.
+
+Documents are indexed from free text such as paths, definitions and intents.
+Identifiers are split at case changes, underscores and punctuation, so a query
+for "page rank" matches PageRank and page_rank alike.
+*/
+package search
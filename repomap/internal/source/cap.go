@@ -0,0 +1,34 @@
+package source
+
+import "strings"
+
+// Cap keeps the lines of text from the top while they fit in maxTokens, as
+// counted by count, and returns them with the number of lines cut. At
+// least one line is kept, cut to fit when it is too long by itself.
+func Cap(text string, maxTokens int, count func(string) int) (string, int) {
+	if maxTokens <= 0 || count(text) <= maxTokens {
+		return text, 0
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	used, kept := 0, 0
+	for _, line := range lines {
+		n := count(line)
+		if used+n > maxTokens {
+			break
+		}
+		used += n
+		kept++
+	}
+	if kept == 0 {
+		first := []rune(lines[0])
+		for len(first) > 0 && count(string(first)) > maxTokens {
+			first = first[:len(first)*3/4]
+		}
+		return string(first) + "\n", len(lines) - 1
+	}
+	return strings.Join(lines[:kept], ""), len(lines) - kept
+}
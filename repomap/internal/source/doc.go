@@ -0,0 +1,10 @@
+/*
+Package source prepares the source text of files for inclusion in a context
+pack.
+
+Read loads a file and, depending on the Options, removes its comments
+(Strip), replaces credentials with a placeholder (Redact) and cuts it down
+to a token cap (Cap). Comment syntax is chosen by file extension; files of
+unknown languages keep their comments.
+*/
+package source
@@ -0,0 +1,72 @@
+package source
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"unicode/utf8"
+)
+
+// ErrBinary is returned by Read for files that are not text.
+var ErrBinary = errors.New("binary file")
+
+// Options select how a file is prepared.
+type Options struct {
+	// StripComments removes comments; Redact replaces secrets.
+	StripComments bool
+	Redact        bool
+	// MaxTokens caps the tokens kept per file, 0 for no cap.
+	MaxTokens int
+	// Count counts the tokens of a text, for the cap.
+	Count func(string) int
+}
+
+// File is the prepared source of a file.
+type File struct {
+	Text string
+	// Lines is the number of lines of the file as read.
+	Lines int
+	// Stripped counts the comment lines removed, Redacted the secrets
+	// replaced and Elided the lines cut by the cap.
+	Stripped int
+	Redacted int
+	Elided   int
+}
+
+// Read loads the file at path and prepares it. Secrets are redacted before
+// comments are stripped and the cap is applied last, so the cap counts only
+// what is kept.
+func Read(path, language string, opts Options) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, ErrBinary
+	}
+
+	text := string(data)
+	f := &File{Lines: countLines(text)}
+	if opts.Redact {
+		text, f.Redacted = Redact(text)
+	}
+	if opts.StripComments {
+		text, f.Stripped = Strip(text, language)
+	}
+	if opts.MaxTokens > 0 && opts.Count != nil {
+		text, f.Elided = Cap(text, opts.MaxTokens, opts.Count)
+	}
+	f.Text = text
+	return f, nil
+}
+
+func countLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	n := bytes.Count([]byte(text), []byte("\n"))
+	if text[len(text)-1] != '\n' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,147 @@
+package source
+
+import "strings"
+
+// commentStyle describes the comment and string syntax of a language.
+type commentStyle struct {
+	line       string
+	blockOpen  string
+	blockClose string
+	// quotes start string literals, inside which comments are text.
+	// Backquoted strings may span lines; the others end at the line.
+	quotes string
+}
+
+var (
+	slashStyle = commentStyle{line: "//", blockOpen: "/*", blockClose: "*/", quotes: "\"'`"}
+	hashStyle  = commentStyle{line: "#", quotes: "\"'"}
+)
+
+// commentStyles maps languages, named by file extension, to their syntax.
+var commentStyles = map[string]commentStyle{
+	"go": slashStyle, "js": slashStyle, "jsx": slashStyle, "mjs": slashStyle, "cjs": slashStyle,
+	"ts": slashStyle, "tsx": slashStyle, "java": slashStyle, "kt": slashStyle, "scala": slashStyle,
+	"c": slashStyle, "h": slashStyle, "cc": slashStyle, "cpp": slashStyle, "hpp": slashStyle,
+	"cs": slashStyle, "swift": slashStyle, "php": slashStyle,
+	// Single quotes in Rust also mark lifetimes, so only double quotes
+	// start strings.
+	"rs": {line: "//", blockOpen: "/*", blockClose: "*/", quotes: "\""},
+	"py": hashStyle, "rb": hashStyle, "sh": hashStyle, "bash": hashStyle,
+	"yaml": hashStyle, "yml": hashStyle, "toml": hashStyle, "pl": hashStyle, "r": hashStyle,
+}
+
+// keptComments are comment lines that change how the code is built or run.
+var keptComments = []string{"//go:", "// +build", "#!"}
+
+// Strip removes the comments of text written in language, given as a file
+// extension such as "go" or "py", and returns the text with the number of
+// lines removed. Lines left blank by a removed comment are dropped, and so
+// are runs of blank lines. Build directives and shebang lines are kept.
+// Text of an unknown language is returned unchanged.
+func Strip(text, language string) (string, int) {
+	style, ok := commentStyles[language]
+	if !ok {
+		return text, 0
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	var b strings.Builder
+	removed := 0
+	inBlock, inRaw := false, byte(0)
+	blank := false
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if !inBlock && inRaw == 0 && keepComment(trimmed) {
+			b.WriteString(line)
+			blank = false
+			continue
+		}
+
+		var code string
+		inString := inRaw != 0
+		code, inBlock, inRaw = stripLine(line, style, inBlock, inRaw)
+		if inString {
+			// The line continues a string, whose blank lines are text.
+			if strings.HasSuffix(line, "\n") && !strings.HasSuffix(code, "\n") {
+				code += "\n"
+			}
+			b.WriteString(code)
+			blank = false
+			continue
+		}
+		stripped := strings.TrimRight(code, " \t\r\n")
+		if stripped == "" {
+			if trimmed != "" {
+				removed++
+				continue
+			}
+			// Collapse runs of blank lines.
+			if blank {
+				removed++
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		if strings.HasSuffix(line, "\n") {
+			stripped += "\n"
+		}
+		b.WriteString(stripped)
+	}
+	return b.String(), removed
+}
+
+func keepComment(trimmed string) bool {
+	for _, prefix := range keptComments {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripLine removes the comments of one line. inBlock and inRaw carry an
+// open block comment or multi-line string over from the previous line.
+func stripLine(line string, style commentStyle, inBlock bool, inRaw byte) (string, bool, byte) {
+	var b strings.Builder
+	var quote byte
+	if inRaw != 0 {
+		quote = inRaw
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inBlock:
+			if strings.HasPrefix(line[i:], style.blockClose) {
+				inBlock = false
+				i += len(style.blockClose) - 1
+			}
+		case quote != 0:
+			b.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(line) {
+				i++
+				b.WriteByte(line[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case style.blockOpen != "" && strings.HasPrefix(line[i:], style.blockOpen):
+			inBlock = true
+			i += len(style.blockOpen) - 1
+		case strings.HasPrefix(line[i:], style.line):
+			return b.String(), false, 0
+		case strings.IndexByte(style.quotes, c) >= 0:
+			quote = c
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if quote == '`' {
+		return b.String(), inBlock, quote
+	}
+	return b.String(), inBlock, 0
+}
@@ -0,0 +1,219 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// whitespace matches Unicode white space. RE2's \s is ASCII only, while the
+// reference pre-tokenizers use the Unicode definition.
+const whitespace = `\t\n\v\f\r\x{85}\p{Z}`
+
+// unicodeSpaces rewrites \s in a pattern to Unicode white space, both
+// inside negated classes and on its own.
+func unicodeSpaces(pattern string) string {
+	pattern = strings.ReplaceAll(pattern, `[^\s`, `[^`+whitespace)
+	return strings.ReplaceAll(pattern, `\s`, `[`+whitespace+`]`)
+}
+
+// Pre-tokenization patterns of cl100k_base and o200k_base. RE2 has no
+// lookahead, so the trailing `\s+(?!\S)|\s+` alternatives are written as
+// a single captured `(\s+)` and the lookahead is applied by split.
+var (
+	cl100kPattern = unicodeSpaces(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|(\s+)`)
+	o200kPattern  = unicodeSpaces(`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|(\s+)`)
+)
+
+// cacheLimit caps the number of pieces whose counts are remembered.
+const cacheLimit = 1 << 16
+
+// window is the input length matched at a time. Short inputs let the
+// regexp package use its backtracking matcher, several times faster than
+// matching against the rest of a large file; matches reaching the end of
+// the window are retried on a larger one.
+const window = 128
+
+// BPE is a byte-level byte-pair encoding tokenizer.
+type BPE struct {
+	name    string
+	ranks   map[string]int
+	pattern *regexp.Regexp
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+// NewBPE returns a tokenizer merging bytes by ranks (lower merges first)
+// after splitting text with the pre-tokenization pattern. A final
+// captured group in the pattern stands for `\s+(?!\S)|\s+`.
+func NewBPE(name string, ranks map[string]int, pattern string) (*BPE, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-tokenization pattern: %w", err)
+	}
+	return &BPE{name: name, ranks: ranks, pattern: re, cache: make(map[string]int)}, nil
+}
+
+// ParseRanks reads a vocabulary in the tiktoken format: one base64
+// encoded token and its rank per line.
+func ParseRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a token and a rank", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rank %q", line, rank)
+		}
+		ranks[string(decoded)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("empty vocabulary")
+	}
+	return ranks, nil
+}
+
+func (b *BPE) Name() string { return b.name }
+
+// Count returns the number of tokens in text.
+func (b *BPE) Count(text string) int {
+	n := 0
+	for _, piece := range b.split(text) {
+		n += b.countPiece(piece)
+	}
+	return n
+}
+
+// Encode returns the token ranks of text. Byte sequences missing from an
+// incomplete vocabulary are reported as -1.
+func (b *BPE) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range b.split(text) {
+		for _, part := range b.merge(piece) {
+			if rank, ok := b.ranks[part]; ok {
+				tokens = append(tokens, rank)
+			} else {
+				tokens = append(tokens, -1)
+			}
+		}
+	}
+	return tokens
+}
+
+// split cuts text into pre-tokenization pieces.
+func (b *BPE) split(text string) []string {
+	var pieces []string
+	for pos := 0; pos < len(text); {
+		m := b.match(text, pos)
+		if m == nil || m[1] == 0 {
+			// Unreachable for the built-in patterns, which match any
+			// character; keep going a rune at a time regardless.
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			pieces = append(pieces, text[pos:pos+size])
+			pos += size
+			continue
+		}
+		end := m[1]
+		// \s+(?!\S): leave the last space of a run to the next word.
+		if len(m) >= 4 && m[2] == 0 && pos+end < len(text) {
+			next, _ := utf8.DecodeRuneInString(text[pos+end:])
+			last, size := utf8.DecodeLastRuneInString(text[pos : pos+end])
+			if !unicode.IsSpace(next) && end > size && unicode.IsSpace(last) {
+				end -= size
+			}
+		}
+		pieces = append(pieces, text[pos:pos+end])
+		pos += end
+	}
+	return pieces
+}
+
+// match matches the pattern at pos, growing the window until the match
+// ends before the window does or the window covers the rest of the text.
+func (b *BPE) match(text string, pos int) []int {
+	for size := window; ; size *= 4 {
+		if pos+size >= len(text) {
+			return b.pattern.FindStringSubmatchIndex(text[pos:])
+		}
+		if m := b.pattern.FindStringSubmatchIndex(text[pos : pos+size]); m != nil && m[1] < size {
+			return m
+		}
+	}
+}
+
+// countPiece returns the number of tokens in one piece, remembering the
+// result for repeated pieces such as common identifiers.
+func (b *BPE) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+	b.mu.Lock()
+	n, ok := b.cache[piece]
+	b.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = len(b.merge(piece))
+	b.mu.Lock()
+	if len(b.cache) < cacheLimit {
+		b.cache[piece] = n
+	}
+	b.mu.Unlock()
+	return n
+}
+
+// merge repeatedly joins the adjacent pair with the lowest rank until no
+// pair is in the vocabulary, and returns the resulting parts.
+func (b *BPE) merge(piece string) []string {
+	if _, ok := b.ranks[piece]; ok {
+		return []string{piece}
+	}
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, at := math.MaxInt, -1
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < best {
+				best, at = rank, i
+			}
+		}
+		if at < 0 {
+			break
+		}
+		bounds = append(bounds[:at+1], bounds[at+2:]...)
+	}
+
+	parts := make([]string, len(bounds)-1)
+	for i := range parts {
+		parts[i] = piece[bounds[i]:bounds[i+1]]
+	}
+	return parts
+}
@@ -0,0 +1,250 @@
+package tokenizer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// byteRanks returns a vocabulary holding every single byte.
+func byteRanks() map[string]int {
+	ranks := make(map[string]int, 256)
+	for i := 0; i < 256; i++ {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	return ranks
+}
+
+// train learns merges from text the way BPE vocabularies are built:
+// repeatedly merging the most frequent adjacent pair within pieces.
+func train(t testing.TB, text, pattern string, merges int) map[string]int {
+	ranks := byteRanks()
+	b, err := NewBPE("test", ranks, pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words [][]string
+	for _, piece := range b.split(text) {
+		parts := make([]string, len(piece))
+		for i := range piece {
+			parts[i] = piece[i : i+1]
+		}
+		words = append(words, parts)
+	}
+	for len(ranks) < 256+merges {
+		counts := make(map[string]int)
+		best := ""
+		for _, w := range words {
+			for i := 0; i+1 < len(w); i++ {
+				pair := w[i] + "\x00" + w[i+1]
+				counts[pair]++
+				if c := counts[pair]; c > counts[best] || c == counts[best] && pair < best {
+					best = pair
+				}
+			}
+		}
+		if best == "" {
+			break
+		}
+		left, right, _ := strings.Cut(best, "\x00")
+		ranks[left+right] = len(ranks)
+		for j, w := range words {
+			var merged []string
+			for i := 0; i < len(w); i++ {
+				if i+1 < len(w) && w[i] == left && w[i+1] == right {
+					merged = append(merged, left+right)
+					i++
+				} else {
+					merged = append(merged, w[i])
+				}
+			}
+			words[j] = merged
+		}
+	}
+	return ranks
+}
+
+func TestSplitCL100K(t *testing.T) {
+	b, err := NewBPE("cl100k", byteRanks(), cl100kPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"  foo", []string{" ", " foo"}},
+		{"x = 10000\n\n", []string{"x", " =", " ", "100", "00", "\n\n"}},
+		{"don't", []string{"don", "'t"}},
+		{"func main() {\n\treturn\n}", []string{"func", " main", "()", " {\n", "\treturn", "\n", "}"}},
+		{"ab  \ncd  ", []string{"ab", "  \n", "cd", "  "}},
+		{"a  b", []string{"a", " ", " b"}},
+		{"héllo wörld", []string{"héllo", " wörld"}},
+	}
+	for _, tt := range tests {
+		if got := b.split(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitO200K(t *testing.T) {
+	b, err := NewBPE("o200k", byteRanks(), o200kPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"HelloWorld", []string{"Hello", "World"}},
+		{"parseHTTPRequest", []string{"parse", "HTTPRequest"}},
+		{"x//y\n", []string{"x", "//", "y", "\n"}},
+		{"We're done", []string{"We're", " done"}},
+	}
+	for _, tt := range tests {
+		if got := b.split(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ranks := byteRanks()
+	ranks["he"] = 256
+	ranks["ll"] = 257
+	ranks["llo"] = 258
+	b, err := NewBPE("test", ranks, cl100kPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Encode("hello"); !reflect.DeepEqual(got, []int{256, 258}) {
+		t.Errorf("Encode(hello) = %v, want [256 258]", got)
+	}
+	if got := b.Count("hello hello"); got != 5 {
+		t.Errorf("Count = %d, want 5 (he, llo, space, he, llo)", got)
+	}
+	// Non-ASCII text counts every byte that is not merged.
+	if got := b.Count("日本"); got != 6 {
+		t.Errorf("Count(日本) = %d, want 6", got)
+	}
+}
+
+func TestTrainedVocabularyCountsCodeBetterThanBytes(t *testing.T) {
+	src, err := os.ReadFile("bpe.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewBPE("trained", train(t, string(src), cl100kPattern, 300), cl100kPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := b.Count(string(src))
+	if n <= 0 || n >= len(src)/2 {
+		t.Errorf("expected merges to compress the source, got %d tokens for %d bytes", n, len(src))
+	}
+	if again := b.Count(string(src)); again != n {
+		t.Errorf("cached count %d differs from %d", again, n)
+	}
+}
+
+func TestParseRanks(t *testing.T) {
+	in := base64.StdEncoding.EncodeToString([]byte("a")) + " 0\n" +
+		base64.StdEncoding.EncodeToString([]byte(" the")) + " 1\n\n"
+	ranks, err := ParseRanks(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ranks, map[string]int{"a": 0, " the": 1}) {
+		t.Errorf("unexpected ranks %v", ranks)
+	}
+	for _, bad := range []string{"", "YQ==", "!!! 1", "YQ== x"} {
+		if _, err := ParseRanks(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tok, err := New("heuristic", "")
+	if err != nil || tok.Name() != "heuristic" || tok.Count("12345678") != 2 {
+		t.Fatalf("unexpected heuristic tokenizer %v (%v)", tok, err)
+	}
+	if _, err := New("gpt2", ""); err == nil {
+		t.Error("expected an error for an unknown tokenizer")
+	}
+	dir := t.TempDir()
+	if _, err := New("cl100k", dir); err == nil {
+		t.Error("expected an error for a missing vocabulary")
+	}
+
+	var vocab strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&vocab, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	fmt.Fprintf(&vocab, "%s 256\n", base64.StdEncoding.EncodeToString([]byte(" world")))
+	if err := os.WriteFile(filepath.Join(dir, "cl100k_base.tiktoken"), []byte(vocab.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tok, err = New("cl100k_base", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Name() != "cl100k" || tok.Count("hi world") != 3 {
+		t.Errorf("expected 3 tokens (h, i, world), got %d", tok.Count("hi world"))
+	}
+
+	// The compressed form, as gen writes it, is read the same way.
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(vocab.String()))
+	zw.Close()
+	if err := os.WriteFile(filepath.Join(dir, "o200k_base.tiktoken.gz"), gz.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tok, err = New("o200k", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Name() != "o200k" || tok.Count("hi world") != 3 {
+		t.Errorf("expected 3 tokens from the compressed vocabulary, got %d", tok.Count("hi world"))
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	src, err := os.ReadFile("bpe.go")
+	if err != nil {
+		b.Fatal(err)
+	}
+	text := string(src)
+	ranks := train(b, text, cl100kPattern, 500)
+
+	b.Run("heuristic", func(b *testing.B) {
+		b.SetBytes(int64(len(text)))
+		for i := 0; i < b.N; i++ {
+			Heuristic{}.Count(text)
+		}
+	})
+	b.Run("bpe", func(b *testing.B) {
+		tok, _ := NewBPE("trained", ranks, cl100kPattern)
+		b.SetBytes(int64(len(text)))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			tok.Count(text)
+		}
+	})
+	b.Run("bpe-cold", func(b *testing.B) {
+		b.SetBytes(int64(len(text)))
+		for i := 0; i < b.N; i++ {
+			tok, _ := NewBPE("trained", ranks, cl100kPattern)
+			tok.Count(text)
+		}
+	})
+}
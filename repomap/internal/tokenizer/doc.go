@@ -0,0 +1,14 @@
+/*
+Package tokenizer counts tokens for token budgets.
+
+Two kinds of tokenizer are available:
+- Heuristic: one token per four bytes. Fast, but it undercounts punctuation and non-ASCII text.
+- BPE: byte-pair encoding with the pre-tokenization rules of cl100k_base and o200k_base.
+
+Vocabularies are read in the tiktoken format (one base64 token and its
+rank per line), plain or gzip compressed. They are looked up in a directory
+at run time, by default the user cache directory (for example
+~/.cache/repomap). Vocabularies placed in the vocab directory before a build,
+for instance by go generate, are embedded and take precedence.
+*/
+package tokenizer
@@ -0,0 +1,77 @@
+// Command gen downloads the BPE vocabularies used by package tokenizer,
+// checks them against their published hashes and writes them gzip
+// compressed to the directory given as its argument, vocab by default.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// vocabularies are the tiktoken files with the SHA-256 that tiktoken
+// expects of them.
+var vocabularies = []struct {
+	file string
+	hash string
+}{
+	{"cl100k_base.tiktoken", "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7"},
+	{"o200k_base.tiktoken", "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d"},
+}
+
+const baseURL = "https://openaipublic.blob.core.windows.net/encodings/"
+
+func main() {
+	dir := "vocab"
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, v := range vocabularies {
+		if err := fetch(dir, v.file, v.hash); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func fetch(dir, file, hash string) error {
+	resp, err := http.Get(baseURL + file)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", file, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+		return fmt.Errorf("%s: unexpected SHA-256 %x", file, sum)
+	}
+
+	var b bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&b, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	zw.Name = file
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, file+".gz"), b.Bytes(), 0644)
+}
@@ -0,0 +1,127 @@
+package tokenizer
+
+import (
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:generate go run ./gen
+
+//go:embed vocab
+var embedded embed.FS
+
+// Tokenizer counts the tokens in a text.
+type Tokenizer interface {
+	// Name identifies the tokenizer, e.g. "heuristic" or "cl100k".
+	Name() string
+	// Count returns the number of tokens in text.
+	Count(text string) int
+}
+
+// Heuristic estimates one token per four bytes.
+type Heuristic struct{}
+
+func (Heuristic) Name() string { return "heuristic" }
+
+func (Heuristic) Count(text string) int { return len(text) / 4 }
+
+// encoding describes a BPE vocabulary.
+type encoding struct {
+	file    string
+	pattern string
+}
+
+var encodings = map[string]encoding{
+	"cl100k": {file: "cl100k_base.tiktoken", pattern: cl100kPattern},
+	"o200k":  {file: "o200k_base.tiktoken", pattern: o200kPattern},
+}
+
+// Names lists the accepted tokenizer names.
+var Names = []string{"heuristic", "cl100k", "o200k"}
+
+// DefaultDir returns the directory searched for vocabularies: repomap in the
+// user cache directory.
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "repomap")
+}
+
+// New returns the named tokenizer. BPE vocabularies are read from the
+// embedded copies first and from dir otherwise, plain or gzip compressed.
+// The "_base" suffix of encoding names is optional.
+func New(name, dir string) (Tokenizer, error) {
+	name = strings.TrimSuffix(strings.ToLower(name), "_base")
+	if name == "" || name == "heuristic" {
+		return Heuristic{}, nil
+	}
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q (expected one of %s)", name, strings.Join(Names, ", "))
+	}
+
+	f, err := openVocabulary(enc.file, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ranks, err := ParseRanks(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", enc.file, err)
+	}
+	return NewBPE(name, ranks, enc.pattern)
+}
+
+// openVocabulary opens the first of the embedded and dir copies of file
+// that exists, decompressing it if it is the gzip form, file.gz.
+func openVocabulary(file, dir string) (io.ReadCloser, error) {
+	sources := []fs.FS{mustSub(embedded, "vocab")}
+	if dir != "" {
+		sources = append(sources, os.DirFS(dir))
+	}
+	for _, fsys := range sources {
+		if f, err := fsys.Open(file + ".gz"); err == nil {
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s.gz: %w", file, err)
+			}
+			return gzipFile{zr, f}, nil
+		}
+		if f, err := fsys.Open(file); err == nil {
+			return f, nil
+		}
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("vocabulary %s not found: no tokenizer directory is set", file)
+	}
+	return nil, fmt.Errorf("vocabulary %s not found in %s (see --tokenizer-dir)", file, dir)
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// gzipFile reads a compressed vocabulary and closes the file under it.
+type gzipFile struct {
+	*gzip.Reader
+	file fs.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
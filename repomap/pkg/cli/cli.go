@@ -13,6 +13,7 @@ type App struct {
 	Description string
 	UsageText   string
 	Examples    []string
+	Commands    []Command
 
 	// Flags registry (Task 1.2.2)
 	flagSet    *flag.FlagSet
@@ -56,6 +57,19 @@ func (a *App) SetUsage(usage string) *App {
 	return a
 }
 
+// Command describes a subcommand for the help text.
+type Command struct {
+	Name  string
+	Usage string
+}
+
+// AddCommand adds a subcommand to the help text. Commands are read from the
+// positional arguments returned by Flags.Args.
+func (a *App) AddCommand(name, usage string) *App {
+	a.Commands = append(a.Commands, Command{Name: name, Usage: usage})
+	return a
+}
+
 // AddExample adds an example to the help text.
 func (a *App) AddExample(example string) *App {
 	a.Examples = append(a.Examples, example)
@@ -10,6 +10,12 @@ import (
 type Flags struct {
 	values  map[string]interface{}
 	visited map[string]bool
+	args    []string
+}
+
+// Args returns the positional arguments, in order, with flags removed.
+func (f *Flags) Args() []string {
+	return f.args
 }
 
 // GetValues returns the underlying map of flag values.
@@ -58,9 +64,26 @@ func (a *App) AddFlag(name string, usage string, defaultValue interface{}) *App
 }
 
 // Parse parses the arguments and returns the flag values.
+// Flags and positional arguments may be interleaved
+// (e.g. `deps main.go --depth 2`); everything after "--" is positional.
 func (a *App) Parse(args []string) (*Flags, error) {
-	if err := a.flagSet.Parse(args); err != nil {
-		return nil, err
+	var positional []string
+	for {
+		if err := a.flagSet.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := a.flagSet.Args()
+		if len(rest) == 0 {
+			break
+		}
+		// The flag package consumes a "--" terminator; anything after it
+		// is positional even if it looks like a flag.
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
 	}
 
 	visited := make(map[string]bool)
@@ -71,6 +94,7 @@ func (a *App) Parse(args []string) (*Flags, error) {
 	return &Flags{
 		values:  a.flagValues,
 		visited: visited,
+		args:    positional,
 	}, nil
 }
 
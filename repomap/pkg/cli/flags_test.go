@@ -70,6 +70,28 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParsePositionalArgs(t *testing.T) {
+	app := NewApp("test-app", "1.0.0")
+	app.AddFlag("depth", "depth flag", 0)
+	app.AddFlag("verbose", "verbose flag", false)
+
+	flags, err := app.Parse([]string{"deps", "--depth", "2", "main.go", "--verbose", "--", "--not-a-flag"})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if val := flags.GetInt("depth"); val != 2 {
+		t.Errorf("expected depth to be 2, got %d", val)
+	}
+	if !flags.GetBool("verbose") {
+		t.Error("expected verbose to be set after a positional argument")
+	}
+	expected := []string{"deps", "main.go", "--not-a-flag"}
+	if !reflect.DeepEqual(flags.Args(), expected) {
+		t.Errorf("expected args %v, got %v", expected, flags.Args())
+	}
+}
+
 func TestSliceFlagDefaultReplacement(t *testing.T) {
 	app := NewApp("test-app", "1.0.0")
 
@@ -20,10 +20,20 @@ func (a *App) GenerateHelp() string {
 	fmt.Fprintf(&b, "\nUsage:\n")
 	if a.UsageText != "" {
 		fmt.Fprintf(&b, "  %s\n", a.UsageText)
+	} else if len(a.Commands) > 0 {
+		fmt.Fprintf(&b, "  %s [command] [arguments] [options]\n", a.Name)
 	} else {
 		fmt.Fprintf(&b, "  %s [options]\n", a.Name)
 	}
 
+	// Commands
+	if len(a.Commands) > 0 {
+		fmt.Fprintf(&b, "\nCommands:\n")
+		for _, c := range a.Commands {
+			fmt.Fprintf(&b, "  %-23s %s\n", c.Name, c.Usage)
+		}
+	}
+
 	// 3. Options (Flags)
 	var flagsBuf bytes.Buffer
 	a.flagSet.VisitAll(func(f *flag.Flag) {
@@ -72,3 +72,15 @@ func TestGenerateHelp_Minimal(t *testing.T) {
 		t.Error("Help text should not contain Examples section when no examples are present")
 	}
 }
+
+func TestGenerateHelp_Commands(t *testing.T) {
+	app := NewApp("test-app", "1.0.0")
+	app.AddCommand("deps <path>", "List dependencies")
+
+	helpText := app.GenerateHelp()
+	for _, section := range []string{"  test-app [command] [arguments] [options]", "Commands:", "deps <path>", "List dependencies"} {
+		if !strings.Contains(helpText, section) {
+			t.Errorf("Help text missing expected section: %q", section)
+		}
+	}
+}
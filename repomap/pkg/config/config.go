@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Config holds the configuration data.
@@ -63,6 +64,61 @@ func (c *Config) GetBool(key string) bool {
 	return false
 }
 
+// GetInt returns an int value from the config.
+// JSON numbers are decoded as float64 and truncated.
+func (c *Config) GetInt(key string) int {
+	if v, ok := c.Settings[key]; ok {
+		switch n := v.(type) {
+		case float64:
+			return int(n)
+		case int:
+			return n
+		}
+	}
+	return 0
+}
+
+// GetFloat returns a numeric setting as a float64, or 0 if it is missing.
+func (c *Config) GetFloat(key string) float64 {
+	if v, ok := c.Settings[key]; ok {
+		switch n := v.(type) {
+		case float64:
+			return n
+		case int:
+			return float64(n)
+		}
+	}
+	return 0
+}
+
+// GetStringSlice returns a list value from the config.
+// Both JSON arrays of strings and comma-separated strings are accepted.
+func (c *Config) GetStringSlice(key string) []string {
+	v, ok := c.Settings[key]
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	switch list := v.(type) {
+	case []interface{}:
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = append(values, list...)
+	case string:
+		for _, part := range strings.Split(list, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
 // DefaultPaths returns standard configuration paths for a tool.
 // e.g. ./.toolrc, ~/.toolrc
 func DefaultPaths(toolName string) []string {
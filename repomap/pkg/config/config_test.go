@@ -72,3 +72,34 @@ func TestConfig_Getters_Defaults(t *testing.T) {
 		t.Errorf("expected false default, got %v", val)
 	}
 }
+
+func TestConfig_ListAndIntGetters(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".testrc")
+
+	content := `{"skip-dirs": [".git", "node_modules"], "include-ext": ".go, .ts", "max-tokens": 8000}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig([]string{configPath})
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if got := cfg.GetStringSlice("skip-dirs"); len(got) != 2 || got[0] != ".git" || got[1] != "node_modules" {
+		t.Errorf("unexpected skip-dirs: %v", got)
+	}
+	if got := cfg.GetStringSlice("include-ext"); len(got) != 2 || got[1] != ".ts" {
+		t.Errorf("unexpected include-ext: %v", got)
+	}
+	if got := cfg.GetStringSlice("missing"); got != nil {
+		t.Errorf("expected nil for missing key, got %v", got)
+	}
+	if got := cfg.GetInt("max-tokens"); got != 8000 {
+		t.Errorf("expected max-tokens=8000, got %d", got)
+	}
+	if got := cfg.GetFloat("max-tokens"); got != 8000 {
+		t.Errorf("expected max-tokens=8000 as float, got %v", got)
+	}
+}
@@ -16,8 +16,12 @@ func NewJSONWriter() Writer {
 	return &JSONWriter{}
 }
 
-// Write writes the data as indented JSON to stdout.
+// Write writes the data as indented JSON to stdout. Maps are rendered as
+// the repomap command renders them.
 func (w *JSONWriter) Write(data interface{}) error {
+	if ok, err := writeMap(data, "json"); ok {
+		return err
+	}
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(data); err != nil {
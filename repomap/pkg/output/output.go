@@ -1,7 +1,15 @@
+// Package output writes repository maps to stdout.
+//
+// Deprecated: repomap renders every format, within the token budget, with
+// the map renderer of the repomap command. The writers here pass maps to
+// that renderer and will be removed in the next release.
 package output
 
 import (
 	"fmt"
+	"os"
+
+	maps "github.com/spanexx/agents-cli/repomap/internal/output"
 )
 
 // NewWriter creates a new Writer implementation based on the format string.
@@ -18,3 +26,18 @@ func NewWriter(format string) (Writer, error) {
 		return nil, fmt.Errorf("unsupported output format: %s", format)
 	}
 }
+
+// writeMap renders data with the map renderer if it is a map, and reports
+// whether it was.
+func writeMap(data interface{}, format string) (bool, error) {
+	m, ok := data.(*maps.RepoMap)
+	if !ok {
+		return false, nil
+	}
+	r, err := maps.Render(m, format, maps.Options{})
+	if err != nil {
+		return true, err
+	}
+	_, err = os.Stdout.Write(r.Data)
+	return true, err
+}
@@ -1,7 +1,11 @@
 package output
 
 import (
+	"io"
+	"os"
 	"testing"
+
+	maps "github.com/spanexx/agents-cli/repomap/internal/output"
 )
 
 func TestNewWriter(t *testing.T) {
@@ -36,3 +40,26 @@ func TestNewWriter(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteMap(t *testing.T) {
+	m := &maps.RepoMap{Name: "demo", Files: []*maps.FileNode{{Path: "main.go", Language: "go"}}}
+	want, err := maps.Render(m, "xml", maps.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	werr := NewXMLWriter().Write(m)
+	os.Stdout = stdout
+	w.Close()
+	got, _ := io.ReadAll(r)
+
+	if werr != nil || string(got) != string(want.Data) {
+		t.Errorf("expected the map renderer's output, got %q (%v)", got, werr)
+	}
+}
@@ -17,8 +17,11 @@ func NewTextWriter() Writer {
 
 // Write writes the data as text to stdout.
 // For complex structures, it uses basic Go formatting (%+v).
-// Future improvements can add reflection-based table formatting.
+// Maps are rendered as the repomap command renders them.
 func (w *TextWriter) Write(data interface{}) error {
+	if ok, err := writeMap(data, "text"); ok {
+		return err
+	}
 	// Simple implementation for now: just print the data
 	// If data is a string, print it directly
 	if s, ok := data.(string); ok {
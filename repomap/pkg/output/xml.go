@@ -16,8 +16,12 @@ func NewXMLWriter() Writer {
 	return &XMLWriter{}
 }
 
-// Write writes the data as XML to stdout.
+// Write writes the data as XML to stdout. Maps are rendered as the repomap
+// command renders them.
 func (w *XMLWriter) Write(data interface{}) error {
+	if ok, err := writeMap(data, "xml"); ok {
+		return err
+	}
 	output, err := xml.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal XML: %w", err)
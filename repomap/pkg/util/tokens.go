@@ -1,23 +1,18 @@
 package util
 
-// CountTokens estimates the number of tokens in a text string.
-// It uses a simple approximation: 1 token ~= 4 characters.
-// This is a standard heuristic for GPT-based models when a tokenizer is not available.
+import "github.com/spanexx/agents-cli/repomap/internal/output"
+
+// CountTokens counts the tokens in a text string with the counter used for
+// map budgets.
+//
+// Deprecated: Use output.CountTokens, which honours the --tokenizer choice.
 func CountTokens(text string) int {
-	if text == "" {
-		return 0
-	}
-	// Use ceil to ensure non-empty strings count as at least 1 token if they have chars
-	// but integer division is standard.
-	// Let's use simple division as per spec: len(text)/4.
-	// But commonly we round up.
-	// Spec says: "Document approximation method".
-	// Let's stick to len/4 but ensure at least 1 if not empty?
-	// The prompt memory said "len(text)/4".
-	return len(text) / 4
+	return output.CountTokens(text)
 }
 
-// CountTokensRough is an alias for CountTokens, explicitly stating it's an estimate.
+// CountTokensRough is an alias for CountTokens.
+//
+// Deprecated: Use output.CountTokens.
 func CountTokensRough(text string) int {
 	return CountTokens(text)
 }
@@ -39,7 +39,7 @@ func TestIntegration_SimpleFixture(t *testing.T) {
 	output := stdout.String()
 
 	// Basic XML validation
-	if !strings.Contains(output, "<repomap>") {
+	if !strings.Contains(output, `<repomap schemaVersion="1">`) {
 		t.Errorf("Output does not contain <repomap> tag")
 	}
 	if !strings.Contains(output, "main.go") {
@@ -118,6 +118,37 @@ func TestFramework_OutputFormats(t *testing.T) {
 	}
 }
 
+func TestFramework_ConfigOptions(t *testing.T) {
+	binPath := buildBinary(t)
+	defer os.Remove(binPath)
+
+	fixturePath, _ := filepath.Abs("../../tests/fixtures/simple")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".repomaprc"), []byte(`{"max-tokens": 500, "output": "xml"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The config file sets the budget; the command line overrides its format.
+	cmd := exec.Command(binPath, "--root", fixturePath, "--output", "json")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to run with a config file: %v", err)
+	}
+
+	for _, want := range []string{
+		`"max_tokens": 500`,
+		`"name": "max-tokens",
+        "value": "500"`,
+		`"name": "output",
+        "value": "json"`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Expected the metadata to contain %s, got: %s", want, out)
+		}
+	}
+}
+
 func buildBinary(t *testing.T) string {
 	binName := filepath.Join(os.TempDir(), "repomap_test_"+t.Name())
 	if strings.Contains(t.Name(), "/") {